@@ -0,0 +1,65 @@
+// Command admin manages the users from the server, for what the API offers no
+// endpoint, like granting permissions:
+//
+//	admin grant -email alice@example.com -permission reviews:moderate
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+)
+
+func main() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	if len(os.Args) < 2 || os.Args[1] != "grant" {
+		fmt.Fprintln(os.Stderr, "usage: admin grant -email address -permission code")
+		os.Exit(2)
+	}
+	if err := grant(os.Args[2:], logger); err != nil {
+		logger.PrintFatal(err, nil)
+		os.Exit(1)
+	}
+}
+
+func grant(args []string, logger *jsonlog.Logger) error {
+	var email, code string
+	flags := flag.NewFlagSet("grant", flag.ExitOnError)
+	flags.StringVar(&email, "email", "", "email address of the user")
+	flags.StringVar(&code, "permission", "", "one of "+strings.Join(models.PermissionCodes, ", "))
+	_ = flags.Parse(args)
+
+	if !validator.In(code, models.PermissionCodes...) {
+		return fmt.Errorf("unknown permission %q", code)
+	}
+
+	cfg, err := config.New("./debug.env")
+	if err != nil {
+		return err
+	}
+	db, err := provider.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	user, err := provider.NewUserProvider(db, cfg).GetByEmail(email)
+	if errors.Is(err, provider.ErrEmailNotFound) {
+		return fmt.Errorf("no user with email %q", email)
+	}
+	if err != nil {
+		return err
+	}
+	if err = provider.NewPermissionProvider(db, cfg).AddForUser(user.ID, code); err != nil {
+		return err
+	}
+	logger.PrintInfo("granted permission", map[string]string{"email": user.Email, "permission": code})
+	return nil
+}
@@ -9,10 +9,18 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
+	"yamda_go/internal/activity"
+	"yamda_go/internal/clientip"
 	"yamda_go/internal/config"
 	"yamda_go/internal/data/provider"
 	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/loadshed"
+	"yamda_go/internal/mailer"
 	"yamda_go/internal/models"
+	"yamda_go/internal/moderation"
+	"yamda_go/internal/ratelimit"
+	"yamda_go/internal/recommend"
 )
 
 // TODO generate this automatically at build time
@@ -24,10 +32,31 @@ const (
 // Application type contains all dependencies for the top layer of
 // the API.
 type Application struct {
-	config        *config.Settings
-	movieProvider provider.IMovieProvider
-	userProvider  provider.IUserProvider
-	logger        *jsonlog.Logger
+	config              *config.Settings
+	movieProvider       provider.IMovieProvider
+	similarMovies       provider.ISimilarMovieProvider
+	genreProvider       provider.IGenreProvider
+	personProvider      provider.IPersonProvider
+	ratingProvider      provider.IRatingProvider
+	reviewProvider      provider.IReviewProvider
+	listProvider        provider.IListProvider
+	historyProvider     provider.IHistoryProvider
+	collectionProvider  provider.ICollectionProvider
+	relationProvider    provider.IRelationProvider
+	translationProvider provider.ITranslationProvider
+	activityProvider    provider.IActivityProvider
+	userProvider        provider.IUserProvider
+	tokenProvider       provider.ITokenProvider
+	permissions         provider.IPermissionProvider
+	screener            *moderation.Screener
+	mailer              mailer.Mailer
+	recommender         *recommend.Recommender
+	activity            *activity.Buffer //views, list additions and ratings, see activityProvider
+	logger              *jsonlog.Logger
+	routeTable          routeTable
+	clientIP            *clientip.Resolver
+	limiterStore        ratelimit.Store
+	concurrency         *loadshed.Limiter
 }
 
 // ParseId parses the parameter id present in a given
@@ -49,6 +78,15 @@ func (app *Application) ParseId(p httprouter.Params) (int64, error) {
 	return id, nil
 }
 
+// parseIdParam is ParseId for routes with several ids, e.g. :review_id.
+func (app *Application) parseIdParam(p httprouter.Params, name string) (int64, error) {
+	id, err := strconv.ParseInt(p.ByName(name), 10, 64)
+	if err != nil || id < 1 {
+		return -1, fmt.Errorf("invalid %s parameter from route parameters", name)
+	}
+	return id, nil
+}
+
 // envelope type. Allow inserting types and self-document them in JSON responses.
 type envelope map[string]interface{}
 
@@ -195,6 +233,82 @@ func (app *Application) resourceEditConflictResponse(w http.ResponseWriter) {
 	}
 }
 
+func (app *Application) resourceConflictResponse(w http.ResponseWriter, err error) {
+	problem := models.ErrorProblem{
+		Title:  "conflict with the current state of the resource",
+		Status: http.StatusConflict,
+		Detail: err.Error(),
+	}
+	if err = app.writeError(w, http.StatusConflict, problem, nil); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) invalidCredentialsResponse(w http.ResponseWriter) {
+	problem := models.ErrorProblem{
+		Title:  "invalid credentials",
+		Status: http.StatusUnauthorized,
+		Detail: "invalid authentication credentials",
+	}
+	if err := app.writeError(w, http.StatusUnauthorized, problem, nil); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) invalidAuthenticationTokenResponse(w http.ResponseWriter) {
+	problem := models.ErrorProblem{
+		Title:  "invalid token",
+		Status: http.StatusUnauthorized,
+		Detail: "invalid or missing authentication token",
+	}
+	headers := make(http.Header)
+	headers.Set("WWW-Authenticate", "Bearer")
+	if err := app.writeError(w, http.StatusUnauthorized, problem, headers); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) authenticationRequiredResponse(w http.ResponseWriter) {
+	problem := models.ErrorProblem{
+		Title:  "authentication required",
+		Status: http.StatusUnauthorized,
+		Detail: "you must be authenticated to access this resource",
+	}
+	headers := make(http.Header)
+	headers.Set("WWW-Authenticate", "Bearer")
+	if err := app.writeError(w, http.StatusUnauthorized, problem, headers); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) inactiveAccountResponse(w http.ResponseWriter) {
+	problem := models.ErrorProblem{
+		Title:  "inactive account",
+		Status: http.StatusForbidden,
+		Detail: "your user account must be activated to access this resource",
+	}
+	if err := app.writeError(w, http.StatusForbidden, problem, nil); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) notPermittedResponse(w http.ResponseWriter) {
+	problem := models.ErrorProblem{
+		Title:  "not permitted",
+		Status: http.StatusForbidden,
+		Detail: "your user account doesn't have the necessary permissions to access this resource",
+	}
+	if err := app.writeError(w, http.StatusForbidden, problem, nil); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (app *Application) serverErrorResponse(w http.ResponseWriter, err error) {
 	problem := models.ErrorProblem{
 		Title:  "the server encountered a problem and could not process your request",
@@ -207,8 +321,30 @@ func (app *Application) serverErrorResponse(w http.ResponseWriter, err error) {
 	}
 }
 
-func (app *Application) rateLimitExceededResponse(w http.ResponseWriter) {
-	message := "rate limit exceeded"
-	w.WriteHeader(http.StatusTooManyRequests)
-	w.Write([]byte(message))
+func (app *Application) rateLimitExceededResponse(w http.ResponseWriter, retryAfter time.Duration) {
+	problem := models.ErrorProblem{
+		Title:  "rate limit exceeded",
+		Status: http.StatusTooManyRequests,
+		Detail: "too many requests, please slow down and try again later",
+	}
+	headers := make(http.Header)
+	headers.Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+	if err := app.writeError(w, http.StatusTooManyRequests, problem, headers); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (app *Application) serviceUnavailableResponse(w http.ResponseWriter, retryAfter time.Duration) {
+	problem := models.ErrorProblem{
+		Title:  "service unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: "the server is overloaded, please try again later",
+	}
+	headers := make(http.Header)
+	headers.Set("Retry-After", strconv.Itoa(ceilSeconds(retryAfter)))
+	if err := app.writeError(w, http.StatusServiceUnavailable, problem, headers); err != nil {
+		app.logger.PrintError(err, nil)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
 }
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/collections -> 200 OK with JSON content
+*
+* Every collection by name, without its movies.
+**/
+func (app *Application) ListCollectionsHandler(w http.ResponseWriter, _ *http.Request) {
+	collections, err := app.collectionProvider.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"collections": collections}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/collections -> 201 CREATED with JSON content
+*
+* {"name": "The Godfather trilogy", "description": "..."}
+* Movies are added with PUT /v1/collections/:id/movies.
+**/
+func (app *Application) CreateCollectionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	collection := &models.Collection{
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+	}
+	v := validator.New()
+	if collection.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if _, err := app.collectionProvider.Insert(collection); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrDuplicateCollection):
+			v.AddError("name", "a collection with this name already exists")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/collections/%d", collection.ID))
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"collection": collection}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/collections/:id -> 200 OK with JSON content
+*
+* The collection with its movies, in order.
+**/
+func (app *Application) GetCollectionHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	collection, ok := app.readCollection(w, p)
+	if !ok {
+		return
+	}
+	if err := app.writeJSON(w, http.StatusOK, envelope{"collection": collection}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PATCH /v1/collections/:id -> 200 OK with JSON content
+*
+* {"name": "...", "description": "..."}, the version, when given, must match
+* the current one.
+**/
+func (app *Application) UpdateCollectionHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	collection, ok := app.readCollection(w, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Version     *int    `json:"version"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if input.Version != nil && *input.Version != collection.Version {
+		app.resourceEditConflictResponse(w)
+		return
+	}
+	if input.Name != nil {
+		collection.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		collection.Description = strings.TrimSpace(*input.Description)
+	}
+
+	v := validator.New()
+	if collection.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.collectionProvider.Update(collection); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrDuplicateCollection):
+			v.AddError("name", "a collection with this name already exists")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"collection": collection}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/collections/:id -> 200 OK
+*
+* The movies of the collection stay in the catalogue.
+**/
+func (app *Application) DeleteCollectionHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if err = app.collectionProvider.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("collection with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+/**
+* PUT /v1/collections/:id/movies -> 200 OK with JSON content
+*
+* {"movieIds": [1, 2, 3]}
+* Replaces the movies of the collection, in order. A movie belongs to one
+* collection at most.
+**/
+func (app *Application) SetCollectionMoviesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		MovieIDs []int64 `json:"movieIds"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if models.ValidateCollectionMovies(v, input.MovieIDs); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err = app.collectionProvider.SetMovies(id, input.MovieIDs); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			//either the collection or one of the movies
+			if _, getErr := app.collectionProvider.Get(id); errors.Is(getErr, provider.ErrRecordNotFound) {
+				app.resourceNotFoundResponse(w, fmt.Errorf("collection with id %d not found", id))
+				return
+			}
+			v.AddError("movieIds", "must contain existing movies only")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrMovieInCollection):
+			v.AddError("movieIds", "must not contain movies of another collection")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	collection, ok := app.readCollection(w, p)
+	if !ok {
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"collection": collection}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// readCollection loads the collection of the :id parameter, writing the error
+// response when it can't.
+func (app *Application) readCollection(w http.ResponseWriter, p httprouter.Params) (*models.Collection, bool) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	collection, err := app.collectionProvider.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("collection with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+	return collection, true
+}
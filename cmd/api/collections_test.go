@@ -0,0 +1,123 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appCollectionsTest *Application = nil
+
+func setupCollectionsTestCase(p provmock.CollectionProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appCollectionsTest = &Application{
+		logger:             logger,
+		config:             cfg,
+		collectionProvider: p,
+	}
+	return func() {
+		//some teardown
+		appCollectionsTest = nil
+	}
+}
+
+func TestApplication_CreateCollectionHandler(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.CollectionProviderMock{}
+	mock.CreateCollectionMock = func(c *models.Collection) (*models.Collection, error) {
+		if c.Name == "Alien" {
+			return nil, provider.ErrDuplicateCollection
+		}
+		c.ID, c.Version, c.Movies = 2, 1, []*models.CollectionMovie{}
+		return c, nil
+	}
+	teardown := setupCollectionsTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("POST", "localhost:8081/v1/collections", strings.NewReader(`{"name": " The Godfather trilogy "}`))
+	w := httptest.NewRecorder()
+	appCollectionsTest.CreateCollectionHandler(w, req, httprouter.Params{})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	is.Equal("/v1/collections/2", resp.Header.Get("Location"))
+	is.Equal(`{"collection":{"id":2,"name":"The Godfather trilogy","description":"","movieCount":0,"version":1}}`, strings.TrimSpace(string(body)))
+
+	for content, status := range map[string]int{
+		`{"name": "Alien"}`:  http.StatusUnprocessableEntity,
+		`{"name": " "}`:      http.StatusUnprocessableEntity,
+		`{"title": "Alien"}`: http.StatusBadRequest,
+	} {
+		req = httptest.NewRequest("POST", "localhost:8081/v1/collections", strings.NewReader(content))
+		w = httptest.NewRecorder()
+		appCollectionsTest.CreateCollectionHandler(w, req, httprouter.Params{})
+		is.Equal(status, w.Result().StatusCode)
+	}
+}
+
+func TestApplication_SetCollectionMoviesHandler(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.CollectionProviderMock{}
+	mock.SetCollectionMoviesMock = func(id int64, movieIDs []int64) error {
+		switch {
+		case id != 2:
+			return provider.ErrRecordNotFound
+		case movieIDs[0] == 9:
+			return provider.ErrMovieInCollection
+		case movieIDs[0] == 99:
+			return provider.ErrRecordNotFound
+		}
+		is.Equal(movieIDs, []int64{3, 1, 2})
+		return nil
+	}
+	mock.GetCollectionMock = func(id int64) (*models.Collection, error) {
+		if id != 2 {
+			return nil, provider.ErrRecordNotFound
+		}
+		return &models.Collection{ID: 2, Name: "The Godfather trilogy", MovieCount: 1, Version: 1,
+			Movies: []*models.CollectionMovie{{ID: 3, Title: "The Godfather", Year: 1972, Position: 1}}}, nil
+	}
+	teardown := setupCollectionsTestCase(mock)
+	defer teardown()
+
+	put := func(id, content string) (int, string) {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/collections/"+id+"/movies", strings.NewReader(content))
+		w := httptest.NewRecorder()
+		appCollectionsTest.SetCollectionMoviesHandler(w, req, httprouter.Params{{Key: "id", Value: id}})
+		body, _ := io.ReadAll(w.Result().Body)
+		return w.Result().StatusCode, strings.TrimSpace(string(body))
+	}
+
+	status, body := put("2", `{"movieIds": [3, 1, 2]}`)
+	is.Equal(http.StatusOK, status)
+	is.Equal(`{"collection":{"id":2,"name":"The Godfather trilogy","description":"","movieCount":1,"movies":[{"id":3,"title":"The Godfather","year":1972,"position":1}],"version":1}}`, body)
+
+	status, body = put("2", `{"movieIds": [9]}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	is.True(strings.Contains(body, "must not contain movies of another collection"))
+
+	status, body = put("2", `{"movieIds": [99]}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	is.True(strings.Contains(body, "must contain existing movies only"))
+
+	status, _ = put("2", `{"movieIds": [1, 1]}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+
+	status, _ = put("5", `{"movieIds": [1]}`)
+	is.Equal(http.StatusNotFound, status)
+}
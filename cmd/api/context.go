@@ -0,0 +1,27 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"yamda_go/internal/models"
+)
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+// contextSetUser returns a copy of the request with the given user added to its context.
+func (app *Application) contextSetUser(r *http.Request, user *models.User) *http.Request {
+	ctx := context.WithValue(r.Context(), userContextKey, user)
+	return r.WithContext(ctx)
+}
+
+// contextGetUser retrieves the user stored in the request context. It returns
+// nil when the request was not authenticated.
+func (app *Application) contextGetUser(r *http.Request) *models.User {
+	user, ok := r.Context().Value(userContextKey).(*models.User)
+	if !ok {
+		return nil
+	}
+	return user
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/movies/:id/credits -> 200 OK with JSON content
+*
+* The cast and crew of the movie, in billing order.
+**/
+func (app *Application) ListCreditsHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if _, err = app.movieProvider.Get(id); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	credits, err := app.personProvider.Credits(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"credits": credits[id]}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PUT /v1/movies/:id/credits -> 200 OK with JSON content
+*
+* Replaces the whole cast and crew of the movie. Credits are billed in the
+* order they are given unless their billing says otherwise.
+**/
+func (app *Application) SetCreditsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Credits []struct {
+			PersonID  int64  `json:"personId"`
+			Role      string `json:"role"`
+			Character string `json:"character"`
+			Billing   int    `json:"billing"`
+		} `json:"credits"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	credits := make([]*models.Credit, 0, len(input.Credits))
+	for i, c := range input.Credits {
+		credit := &models.Credit{
+			PersonID:  c.PersonID,
+			Role:      strings.ToLower(strings.TrimSpace(c.Role)),
+			Character: strings.TrimSpace(c.Character),
+			Billing:   c.Billing,
+		}
+		if credit.Billing == 0 {
+			credit.Billing = i + 1
+		}
+		credits = append(credits, credit)
+	}
+
+	v := validator.New()
+	if models.ValidateCredits(v, credits); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err = app.personProvider.SetCredits(id, credits); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		case errors.Is(err, provider.ErrPersonNotFound):
+			v.AddError("personId", err.Error())
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	saved, err := app.personProvider.Credits(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"credits": saved[id]}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+)
+
+// movieFields are the fields clients can pick with ?fields=.
+var movieFields = []string{"id", "title", "originalTitle", "overview", "runtime", "genres", "year", "version", "averageRating", "votes"}
+
+// movieExpansion loads a related resource for a batch of movies, keyed by movie id.
+type movieExpansion func(movies []*models.Movie) (map[int64]interface{}, error)
+
+// movieExpansions returns the related resources that can be embedded in movie
+// responses with ?expand=, keyed by the name used in the query string.
+func (app *Application) movieExpansions() map[string]movieExpansion {
+	return map[string]movieExpansion{
+		"credits":    app.expandCredits,
+		"collection": app.expandCollection,
+		"related":    app.expandRelated,
+	}
+}
+
+// movieFranchise are the expansions embedded in a single movie unless a sparse
+// fieldset leaves them out.
+var movieFranchise = []string{"collection", "related"}
+
+// expandCredits loads the cast and crew of the movies in a single query.
+func (app *Application) expandCredits(movies []*models.Movie) (map[int64]interface{}, error) {
+	ids := make([]int64, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	credits, err := app.personProvider.Credits(ids...)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]interface{}, len(movies))
+	for _, m := range movies {
+		if c, ok := credits[m.ID]; ok {
+			out[m.ID] = c
+		} else {
+			out[m.ID] = []*models.Credit{}
+		}
+	}
+	return out, nil
+}
+
+// expandCollection loads the collection of the movies, null for the movies in none.
+func (app *Application) expandCollection(movies []*models.Movie) (map[int64]interface{}, error) {
+	ids := make([]int64, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	collections, err := app.collectionProvider.ForMovies(ids...)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]interface{}, len(movies))
+	for _, m := range movies {
+		if c, ok := collections[m.ID]; ok {
+			out[m.ID] = c
+		} else {
+			out[m.ID] = nil
+		}
+	}
+	return out, nil
+}
+
+// expandRelated loads the sequels, prequels, remakes... of the movies.
+func (app *Application) expandRelated(movies []*models.Movie) (map[int64]interface{}, error) {
+	ids := make([]int64, 0, len(movies))
+	for _, m := range movies {
+		ids = append(ids, m.ID)
+	}
+	related, err := app.relationProvider.Related(ids...)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[int64]interface{}, len(movies))
+	for _, m := range movies {
+		if r, ok := related[m.ID]; ok {
+			out[m.ID] = r
+		} else {
+			out[m.ID] = []*models.RelatedMovie{}
+		}
+	}
+	return out, nil
+}
+
+// movieView describes how movies are rendered: which fields (all of them when
+// empty) and which related resources are embedded.
+type movieView struct {
+	fields []string
+	expand []string
+}
+
+func (mv movieView) isDefault() bool {
+	return len(mv.fields) == 0 && len(mv.expand) == 0
+}
+
+// readMovieView reads the ?fields= and ?expand= parameters, adding an error to
+// the validator for every unknown value. Fields naming a related resource
+// expand it, e.g. ?fields=title,collection.
+// e.g: /v1/movies?fields=id,title,year&expand=ratings
+func (app *Application) readMovieView(qs url.Values, v *validator.Validator) movieView {
+	mv := movieView{expand: app.readCSV(qs, "expand", nil)}
+	expansions := app.movieExpansions()
+	for _, f := range app.readCSV(qs, "fields", nil) {
+		switch _, expansion := expansions[f]; {
+		case expansion:
+			if !validator.In(f, mv.expand...) {
+				mv.expand = append(mv.expand, f)
+			}
+		case validator.In(f, movieFields...):
+			mv.fields = append(mv.fields, f)
+		default:
+			v.AddError("fields", fmt.Sprintf("unknown field %q", f))
+		}
+	}
+	for _, e := range mv.expand {
+		if _, ok := expansions[e]; !ok {
+			v.AddError("expand", fmt.Sprintf("unknown resource %q", e))
+		}
+	}
+	return mv
+}
+
+// renderMovies projects the movies according to the view and embeds the
+// expanded resources. With links, each movie carries its "_links", whatever
+// fields were picked.
+func (app *Application) renderMovies(movies []*models.Movie, mv movieView, links bool) ([]interface{}, error) {
+	expanded := make(map[string]map[int64]interface{})
+	expansions := app.movieExpansions()
+	for _, e := range mv.expand {
+		res, err := expansions[e](movies)
+		if err != nil {
+			return nil, err
+		}
+		expanded[e] = res
+	}
+
+	out := make([]interface{}, 0, len(movies))
+	for _, m := range movies {
+		var resource interface{} = m
+		keep := mv.fields
+		if links {
+			resource = app.movieResource(m)
+			if len(keep) > 0 {
+				keep = append(keep[:len(keep):len(keep)], "_links")
+			}
+		}
+		if mv.isDefault() {
+			out = append(out, resource)
+			continue
+		}
+		obj, err := project(resource, keep)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range mv.expand {
+			obj = obj.with(e, expanded[e][m.ID])
+		}
+		out = append(out, obj)
+	}
+	return out, nil
+}
+
+// renderMovie is renderMovies for a single movie.
+func (app *Application) renderMovie(m *models.Movie, mv movieView, links bool) (interface{}, error) {
+	out, err := app.renderMovies([]*models.Movie{m}, mv, links)
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+// jsonMember is a member of a JSON object.
+type jsonMember struct {
+	key   string
+	value interface{}
+}
+
+// jsonObject is a JSON object whose members keep their order when encoded,
+// unlike a map.
+type jsonObject []jsonMember
+
+func (o jsonObject) with(key string, value interface{}) jsonObject {
+	return append(o, jsonMember{key: key, value: value})
+}
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(m.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// project encodes v as JSON and keeps only the listed members of the resulting
+// object, in their original order. An empty list keeps every member.
+func project(v interface{}, keep []string) (jsonObject, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err = dec.Token(); err != nil { //opening brace
+		return nil, err
+	}
+	var obj jsonObject
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		key, _ := t.(string)
+		var value json.RawMessage
+		if err = dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if len(keep) == 0 || validator.In(key, keep...) {
+			obj = obj.with(key, value)
+		}
+	}
+	return obj, nil
+}
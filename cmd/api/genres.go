@@ -0,0 +1,200 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/genres -> 200 OK with JSON content
+**/
+func (app *Application) ListGenresHandler(w http.ResponseWriter, _ *http.Request) {
+	genres, err := app.genreProvider.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"genres": genres}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/genres -> 201 CREATED with JSON content
+*
+* The slug is derived from the name when not given.
+**/
+func (app *Application) CreateGenreHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Slug    string   `json:"slug"`
+		Name    string   `json:"name"`
+		Aliases []string `json:"aliases"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	genre := &models.Genre{
+		Slug:    input.Slug,
+		Name:    input.Name,
+		Aliases: input.Aliases,
+	}
+	if genre.Slug == "" {
+		genre.Slug = models.Slugify(genre.Name)
+	}
+	if genre.Aliases == nil {
+		genre.Aliases = []string{}
+	}
+
+	v := validator.New()
+	if genre.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if _, err := app.genreProvider.Insert(genre); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrDuplicateGenre):
+			v.AddError("genre", "a genre with this slug, name or alias already exists")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/genres/%d", genre.ID))
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"genre": genre}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/genres/:id -> 200 OK with JSON content
+**/
+func (app *Application) GetGenreHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	genre, ok := app.readGenre(w, p)
+	if !ok {
+		return
+	}
+	if err := app.writeJSON(w, http.StatusOK, envelope{"genre": genre}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PATCH /v1/genres/:id -> 200 OK with JSON content
+*
+* Renaming a genre renames it in every movie. The version, when given, must
+* match the current one.
+**/
+func (app *Application) UpdateGenreHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	genre, ok := app.readGenre(w, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Slug    *string  `json:"slug"`
+		Name    *string  `json:"name"`
+		Aliases []string `json:"aliases"`
+		Version *int     `json:"version"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if input.Version != nil && *input.Version != genre.Version {
+		app.resourceEditConflictResponse(w)
+		return
+	}
+	if input.Slug != nil {
+		genre.Slug = *input.Slug
+	}
+	if input.Name != nil {
+		genre.Name = *input.Name
+	}
+	if input.Aliases != nil {
+		genre.Aliases = input.Aliases
+	}
+
+	v := validator.New()
+	if genre.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.genreProvider.Update(genre); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrDuplicateGenre):
+			v.AddError("genre", "a genre with this slug, name or alias already exists")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"genre": genre}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/genres/:id -> 200 OK
+*
+* Genres still used by movies can't be deleted.
+**/
+func (app *Application) DeleteGenreHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if err = app.genreProvider.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("genre with id %d not found", id))
+		case errors.Is(err, provider.ErrGenreInUse):
+			app.resourceConflictResponse(w, fmt.Errorf("genre with id %d is used by some movies", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// readGenre loads the genre of the :id parameter, writing the error response
+// when it can't.
+func (app *Application) readGenre(w http.ResponseWriter, p httprouter.Params) (*models.Genre, bool) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	genre, err := app.genreProvider.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("genre with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+	return genre, true
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+)
+
+var appGenresTest *Application = nil
+
+func setupGenresTestCase(p provmock.GenreProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appGenresTest = &Application{
+		logger:        logger,
+		config:        cfg,
+		genreProvider: p,
+	}
+	return func() {
+		//some teardown
+		appGenresTest = nil
+	}
+}
+
+func TestApplication_CreateGenreHandler_SlugFromName(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.GenreProviderMock{}
+	mock.CreateGenreMock = func(g *models.Genre) (*models.Genre, error) {
+		g.ID, g.Version = 7, 1
+		return g, nil
+	}
+	teardown := setupGenresTestCase(mock)
+	defer teardown()
+
+	content := `{"name": "Film Noir", "aliases": ["noir"]}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/genres", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appGenresTest.CreateGenreHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	is.Equal("/v1/genres/7", resp.Header.Get("Location"))
+	is.Equal(`{"genre":{"id":7,"slug":"film-noir","name":"Film Noir","aliases":["noir"],"version":1}}`, string(body))
+}
+
+func TestApplication_CreateGenreHandler_Duplicate(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.GenreProviderMock{}
+	mock.CreateGenreMock = func(g *models.Genre) (*models.Genre, error) {
+		return nil, provider.ErrDuplicateGenre
+	}
+	teardown := setupGenresTestCase(mock)
+	defer teardown()
+
+	content := `{"name": "Drama"}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/genres", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appGenresTest.CreateGenreHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"genre":"a genre with this slug, name or alias already exists"}}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_UpdateGenreHandler_VersionMismatch(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.GenreProviderMock{}
+	mock.GetGenreMock = func(id int64) (*models.Genre, error) {
+		return &models.Genre{ID: id, Slug: "drama", Name: "Drama", Aliases: []string{}, Version: 3}, nil
+	}
+	teardown := setupGenresTestCase(mock)
+	defer teardown()
+
+	content := `{"name": "Dramas", "version": 2}`
+	req := httptest.NewRequest("PATCH", "localhost:8081/v1/genres/1", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appGenresTest.UpdateGenreHandler(w, req, httprouter.Params{{Key: "id", Value: "1"}})
+
+	is.Equal(http.StatusConflict, w.Result().StatusCode)
+}
+
+func TestApplication_DeleteGenreHandler_InUse(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.GenreProviderMock{}
+	mock.DeleteGenreMock = func(id int64) error {
+		return provider.ErrGenreInUse
+	}
+	teardown := setupGenresTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("DELETE", "localhost:8081/v1/genres/4", nil)
+	w := httptest.NewRecorder()
+	appGenresTest.DeleteGenreHandler(w, req, httprouter.Params{{Key: "id", Value: "4"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusConflict, resp.StatusCode)
+	expectedBody := `{"title":"conflict with the current state of the resource","status":409,"detail":"genre with id 4 is used by some movies"}`
+	is.Equal(expectedBody, string(body))
+}
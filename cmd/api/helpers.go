@@ -4,6 +4,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
+	"yamda_go/internal/data"
 	"yamda_go/internal/validator"
 )
 
@@ -43,3 +45,44 @@ func (app *Application) readInt(qs url.Values, key string, defaultVal int, v *va
 
 	return i
 }
+
+// readTime parses a timestamp (RFC 3339) or a date (YYYY-MM-DD) from a key in the query string.
+// e.g: /v1/movies?created_after=2023-04-18 -> 2023-04-18 00:00:00 UTC for the key "created_after"
+func (app *Application) readTime(qs url.Values, key string, defaultVal time.Time, v *validator.Validator) time.Time {
+	s := qs.Get(key)
+	if s == "" {
+		return defaultVal
+	}
+
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	v.AddError(key, "must be a date (YYYY-MM-DD) or an RFC 3339 timestamp")
+	return defaultVal
+}
+
+// readCursor decodes an opaque pagination cursor from a key in the query string.
+// e.g: /v1/movies?after=eyJzIjoiaWQiLCJ2IjoiMTIiLCJpZCI6MTJ9.xxx
+// It returns nil when the key is not present.
+func (app *Application) readCursor(qs url.Values, key string, codec data.CursorCodec, v *validator.Validator) *data.Cursor {
+	s := qs.Get(key)
+	if s == "" {
+		return nil
+	}
+
+	c, err := codec.Decode(s)
+	if err != nil {
+		v.AddError(key, "must be a valid cursor")
+		return nil
+	}
+
+	return &c
+}
+
+// cursorCodec returns the codec used to sign pagination cursors.
+func (app *Application) cursorCodec() data.CursorCodec {
+	return data.CursorCodec{Secret: []byte(app.config.CursorSecret)}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* POST /v1/users/me/history -> 201 CREATED with JSON content
+*
+* {"movieId": 5, "watchedOn": "2024-03-31", "rewatch": true}
+* The day defaults to today. Without rewatch, watching a movie already in the
+* history is a rewatch.
+**/
+func (app *Application) LogWatchHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		MovieID   int64  `json:"movieId"`
+		WatchedOn string `json:"watchedOn"`
+		Rewatch   *bool  `json:"rewatch"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	watch := &models.Watch{
+		UserID:    app.contextGetUser(r).ID,
+		MovieID:   input.MovieID,
+		WatchedOn: strings.TrimSpace(input.WatchedOn),
+		Rewatch:   input.Rewatch,
+	}
+	if watch.WatchedOn == "" {
+		watch.WatchedOn = time.Now().UTC().Format(models.DateLayout)
+	}
+	v := validator.New()
+	if watch.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.historyProvider.Insert(watch); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			v.AddError("movieId", fmt.Sprintf("movie with id %d not found", watch.MovieID))
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"watch": watch}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/users/me/stats -> 200 OK with JSON content
+*
+* Runtime watched, favourite genres, movies per year and month and the
+* longest runs of days with a movie.
+**/
+func (app *Application) WatchStatsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	stats, err := app.historyProvider.Stats(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"stats": stats}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+var appHistoryTest *Application = nil
+
+func setupHistoryTestCase(p provmock.HistoryProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appHistoryTest = &Application{
+		logger:          logger,
+		config:          cfg,
+		historyProvider: p,
+	}
+	return func() {
+		//some teardown
+		appHistoryTest = nil
+	}
+}
+
+func TestApplication_LogWatchHandler_DefaultsToToday(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.HistoryProviderMock{}
+	mock.InsertWatchMock = func(w *models.Watch) error {
+		is.Equal(w.UserID, int64(3))
+		is.Equal(w.Rewatch, nil) //left to the provider
+		rewatch := true
+		w.ID, w.Rewatch, w.Runtime = 1, &rewatch, 102
+		return nil
+	}
+	teardown := setupHistoryTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("POST", "localhost:8081/v1/users/me/history", strings.NewReader(`{"movieId": 5}`))
+	req = appHistoryTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appHistoryTest.LogWatchHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	today := time.Now().UTC().Format(models.DateLayout)
+	is.Equal(`{"watch":{"id":1,"movieId":5,"watchedOn":"`+today+`","rewatch":true,"runtime":"102 mins"}}`, strings.TrimSpace(string(body)))
+}
+
+func TestApplication_LogWatchHandler_InvalidInput(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.HistoryProviderMock{}
+	mock.InsertWatchMock = func(w *models.Watch) error { return provider.ErrRecordNotFound }
+	teardown := setupHistoryTestCase(mock)
+	defer teardown()
+
+	future := time.Now().UTC().AddDate(0, 0, 3).Format(models.DateLayout)
+	for input, expected := range map[string]string{
+		`{"movieId": 5, "watchedOn": "31/03/2024"}`:     `{"watchedOn":"must be a date like 2024-03-31"}`,
+		`{"movieId": 5, "watchedOn": "` + future + `"}`: `{"watchedOn":"must not be in the future"}`,
+		`{"watchedOn": "2024-03-31"}`:                   `{"movieId":"must be provided"}`,
+		`{"movieId": 5, "watchedOn": "2024-03-31"}`:     `{"movieId":"movie with id 5 not found"}`,
+	} {
+		req := httptest.NewRequest("POST", "localhost:8081/v1/users/me/history", strings.NewReader(input))
+		req = appHistoryTest.contextSetUser(req, &models.User{ID: 3})
+		w := httptest.NewRecorder()
+		appHistoryTest.LogWatchHandler(w, req, nil)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		is.True(strings.Contains(string(body), `"errors":`+expected))
+	}
+}
+
+func TestApplication_WatchStatsHandler(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.HistoryProviderMock{}
+	mock.StatsMock = func(userID int64) (*models.WatchStats, error) {
+		return &models.WatchStats{
+			Watched:   2,
+			Movies:    2,
+			Runtime:   250,
+			TopGenres: []models.GenreCount{{Genre: "Drama", Count: 2}},
+			PerYear:   []models.PeriodCount{{Period: "2024", Count: 2}},
+			PerMonth:  []models.PeriodCount{{Period: "2024-03", Count: 2}},
+			Streaks:   []models.Streak{{From: "2024-03-30", To: "2024-03-31", Days: 2}},
+		}, nil
+	}
+	teardown := setupHistoryTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/users/me/stats", nil)
+	req = appHistoryTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appHistoryTest.WatchStatsHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.True(strings.Contains(string(body), `"runtime":"250 mins"`))
+	is.True(strings.Contains(string(body), `"longestStreaks":[{"from":"2024-03-30","to":"2024-03-31","days":2}]`))
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"yamda_go/internal/models"
+)
+
+// link is an hypermedia link, as found in the "_links" object of a resource.
+type link struct {
+	Href string `json:"href"`
+}
+
+// movieResource is a movie as sent to clients, together with its links.
+type movieResource struct {
+	*models.Movie
+	Links map[string]link `json:"_links"`
+}
+
+func (app *Application) movieResource(m *models.Movie) movieResource {
+	return movieResource{
+		Movie: m,
+		Links: map[string]link{"self": {Href: fmt.Sprintf("/v1/movies/%d", m.ID)}},
+	}
+}
+
+// paginationLinks builds the value of a Link header (RFC 8288) pointing at the
+// first, previous, next and last pages of a listing. The links keep every other
+// parameter of the current request, like filters and sort.
+// In cursor mode there is no "last" link, since we don't count the records.
+func (app *Application) paginationLinks(u *url.URL, meta *models.Metadata) string {
+	if meta == nil {
+		return ""
+	}
+
+	build := func(rel string, set map[string]string) string {
+		qs := u.Query()
+		for _, key := range []string{"page", "after", "before"} {
+			qs.Del(key)
+		}
+		for key, value := range set {
+			qs.Set(key, value)
+		}
+		return fmt.Sprintf(`<%s>; rel="%s"`, (&url.URL{Path: u.Path, RawQuery: qs.Encode()}).String(), rel)
+	}
+
+	var links []string
+	qs := u.Query()
+	if qs.Get("after") != "" || qs.Get("before") != "" {
+		links = append(links, build("first", nil))
+		if meta.Prev != "" {
+			links = append(links, build("prev", map[string]string{"before": meta.Prev}))
+		}
+		if meta.Next != "" {
+			links = append(links, build("next", map[string]string{"after": meta.Next}))
+		}
+		return strings.Join(links, ", ")
+	}
+
+	if meta.LastPage == 0 {
+		//no records, nothing to navigate to
+		return ""
+	}
+	page := func(n int) map[string]string {
+		return map[string]string{"page": strconv.Itoa(n)}
+	}
+	links = append(links, build("first", page(meta.FirstPage)))
+	if meta.CurrentPage > meta.FirstPage {
+		links = append(links, build("prev", page(meta.CurrentPage-1)))
+	}
+	if meta.CurrentPage < meta.LastPage {
+		links = append(links, build("next", page(meta.CurrentPage+1)))
+	}
+	links = append(links, build("last", page(meta.LastPage)))
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,444 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/activity"
+	"yamda_go/internal/data"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/users/me/lists -> 200 OK with JSON content
+*
+* The lists of the user, without their items. ?name= finds the ones whose name
+* contains it.
+**/
+func (app *Application) ListMyListsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	app.listLists(w, r, app.contextGetUser(r).ID)
+}
+
+/**
+* GET /v1/lists -> 200 OK with JSON content
+*
+* The public lists of everybody, without their items.
+**/
+func (app *Application) ListPublicListsHandler(w http.ResponseWriter, r *http.Request) {
+	app.listLists(w, r, 0)
+}
+
+func (app *Application) listLists(w http.ResponseWriter, r *http.Request, userID int64) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input := data.ListSearch{
+		UserID: userID,
+		Name:   strings.TrimSpace(app.readString(qs, "name", "")),
+		Filters: data.Filter{
+			Page:         app.readInt(qs, "page", 1, v),
+			PageSize:     app.readInt(qs, "page_size", 20, v),
+			Sort:         app.readString(qs, "sort", "-id"),
+			SortSafelist: []string{"id", "name", "created_at", "-id", "-name", "-created_at"},
+		},
+	}
+	if input.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	lists, meta, err := app.listProvider.GetAll(input)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r.URL, meta); links != "" {
+		headers.Set("Link", links)
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"metadata": meta, "lists": lists}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/users/me/lists -> 201 CREATED with JSON content
+*
+* {"name": "Watch later", "description": "...", "visibility": "private"}
+* Lists are private unless told otherwise.
+**/
+func (app *Application) CreateListHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Visibility  string `json:"visibility"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	list := &models.List{
+		UserID:      app.contextGetUser(r).ID,
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Visibility:  input.Visibility,
+	}
+	if list.Visibility == "" {
+		list.Visibility = models.ListPrivate
+	}
+	v := validator.New()
+	if list.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if _, err := app.listProvider.Insert(list); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/users/me/lists/%d", list.ID))
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"list": list}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/users/me/lists/:id -> 200 OK with JSON content
+*
+* The list along with its movies, in order.
+**/
+func (app *Application) GetMyListHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+	if err := app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/lists/:slug -> 200 OK with JSON content
+*
+* Unlisted and public lists, for whoever has their share slug.
+**/
+func (app *Application) GetSharedListHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	slug := p.ByName("slug")
+	list, err := app.listProvider.GetBySlug(slug)
+	if err == nil && list.Visibility == models.ListPrivate {
+		err = provider.ErrRecordNotFound
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("list %q not found", slug))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PATCH /v1/users/me/lists/:id -> 200 OK with JSON content
+*
+* {"newSlug": true} replaces the share slug, so that the old one stops working.
+* The version, when given, must match the current one.
+**/
+func (app *Application) UpdateListHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		Visibility  *string `json:"visibility"`
+		NewSlug     bool    `json:"newSlug"`
+		Version     *int    `json:"version"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if input.Version != nil && *input.Version != list.Version {
+		app.resourceEditConflictResponse(w)
+		return
+	}
+	if input.Name != nil {
+		list.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		list.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Visibility != nil {
+		list.Visibility = *input.Visibility
+	}
+
+	v := validator.New()
+	if list.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+	if input.NewSlug {
+		slug, err := models.GenerateSlug()
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		list.Slug = slug
+	}
+
+	if err := app.listProvider.Update(list); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"list": list}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/users/me/lists/:id -> 200 OK
+**/
+func (app *Application) DeleteListHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+
+	if err := app.listProvider.Delete(list.ID); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("list with id %d not found", list.ID))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+/**
+* POST /v1/users/me/lists/:id/items -> 201 CREATED with JSON content
+*
+* {"movieId": 5, "note": "...", "position": 1}
+* Movies go at the end of the list unless a position is given.
+**/
+func (app *Application) AddListItemHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MovieID  int64  `json:"movieId"`
+		Note     string `json:"note"`
+		Position int    `json:"position"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	item := &models.ListItem{
+		MovieID:  input.MovieID,
+		Note:     strings.TrimSpace(input.Note),
+		Position: input.Position,
+	}
+	v := validator.New()
+	if item.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.listProvider.AddItem(list.ID, item); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			v.AddError("movieId", fmt.Sprintf("movie with id %d not found", item.MovieID))
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrDuplicateListItem):
+			v.AddError("movieId", "is already in the list")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrListFull):
+			v.AddError("items", fmt.Sprintf("must not contain more than %d movies", models.MaxListItems))
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	app.activity.Record(item.MovieID, activity.ListAdd)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/users/me/lists/%d", list.ID))
+	app.writeList(w, http.StatusCreated, list.ID, headers)
+}
+
+/**
+* PATCH /v1/users/me/lists/:id/items/:movie_id -> 200 OK with JSON content
+*
+* {"note": "..."}
+**/
+func (app *Application) UpdateListItemHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+	movieID, err := app.parseIdParam(p, "movie_id")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Note string `json:"note"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	item := &models.ListItem{MovieID: movieID, Note: strings.TrimSpace(input.Note)}
+	v := validator.New()
+	if item.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err = app.listProvider.UpdateNote(list.ID, item); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d is not in the list", movieID))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	app.writeList(w, http.StatusOK, list.ID, nil)
+}
+
+/**
+* DELETE /v1/users/me/lists/:id/items/:movie_id -> 200 OK with JSON content
+**/
+func (app *Application) RemoveListItemHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+	movieID, err := app.parseIdParam(p, "movie_id")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if err = app.listProvider.RemoveItem(list.ID, movieID); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d is not in the list", movieID))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	app.writeList(w, http.StatusOK, list.ID, nil)
+}
+
+/**
+* PUT /v1/users/me/lists/:id/order -> 200 OK with JSON content
+*
+* {"movieIds": [3, 1, 2]}
+* Every movie of the list, in the new order. The list is reordered at once or
+* not at all.
+**/
+func (app *Application) ReorderListHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	list, ok := app.readOwnList(w, r, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		MovieIDs []int64 `json:"movieIds"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if models.ValidateOrder(v, input.MovieIDs); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.listProvider.Reorder(list.ID, input.MovieIDs); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrInvalidOrder):
+			v.AddError("movieIds", "must contain every movie of the list once")
+			app.failedValidationResponse(w, v.Errors)
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("list with id %d not found", list.ID))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	app.writeList(w, http.StatusOK, list.ID, nil)
+}
+
+// readOwnList loads the list of the :id parameter, writing the error response
+// when it can't. Lists of other users are not found, whatever their visibility.
+func (app *Application) readOwnList(w http.ResponseWriter, r *http.Request, p httprouter.Params) (*models.List, bool) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	list, err := app.listProvider.Get(id)
+	if err == nil && list.UserID != app.contextGetUser(r).ID {
+		err = provider.ErrRecordNotFound
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("list with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+	return list, true
+}
+
+// writeList responds with the list as it is after a change to its items.
+func (app *Application) writeList(w http.ResponseWriter, status int, id int64, headers http.Header) {
+	list, err := app.listProvider.Get(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, status, envelope{"list": list}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
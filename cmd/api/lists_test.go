@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appListsTest *Application = nil
+
+func setupListsTestCase(p provmock.ListProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appListsTest = &Application{
+		logger:       logger,
+		config:       cfg,
+		listProvider: p,
+	}
+	return func() {
+		//some teardown
+		appListsTest = nil
+	}
+}
+
+// listOf returns a mock serving the list, owned by the user with ownerID.
+func listOf(ownerID int64, visibility string) provmock.ListProviderMock {
+	list := func() *models.List {
+		return &models.List{ID: 4, UserID: ownerID, Name: "Watch later", Visibility: visibility, Slug: "abc", Version: 1, Items: []*models.ListItem{}}
+	}
+	return provmock.ListProviderMock{
+		GetListMock: func(id int64) (*models.List, error) {
+			if id != 4 {
+				return nil, provider.ErrRecordNotFound
+			}
+			return list(), nil
+		},
+		GetListBySlugMock: func(slug string) (*models.List, error) {
+			if slug != "abc" {
+				return nil, provider.ErrRecordNotFound
+			}
+			return list(), nil
+		},
+	}
+}
+
+func TestApplication_CreateListHandler_PrivateByDefault(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.ListProviderMock{}
+	mock.CreateListMock = func(l *models.List) (*models.List, error) {
+		l.ID, l.Slug, l.Version = 4, "abc", 1
+		return l, nil
+	}
+	teardown := setupListsTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("POST", "localhost:8081/v1/users/me/lists", strings.NewReader(`{"name": " Watch later "}`))
+	req = appListsTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appListsTest.CreateListHandler(w, req, nil)
+
+	resp := w.Result()
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	is.Equal("/v1/users/me/lists/4", resp.Header.Get("Location"))
+
+	var body struct {
+		List models.List `json:"list"`
+	}
+	is.NoErr(json.NewDecoder(resp.Body).Decode(&body))
+	is.Equal(body.List.Name, "Watch later")
+	is.Equal(body.List.UserID, int64(3))
+	is.Equal(body.List.Visibility, models.ListPrivate)
+}
+
+func TestApplication_GetMyListHandler_OtherUser(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupListsTestCase(listOf(3, models.ListPublic))
+	defer teardown()
+
+	//public or not, lists of others can't be reached through /me
+	req := httptest.NewRequest("GET", "localhost:8081/v1/users/me/lists/4", nil)
+	req = appListsTest.contextSetUser(req, &models.User{ID: 8})
+	w := httptest.NewRecorder()
+	appListsTest.GetMyListHandler(w, req, httprouter.Params{{Key: "id", Value: "4"}})
+
+	is.Equal(http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestApplication_GetSharedListHandler_Visibility(t *testing.T) {
+	is := is2.New(t)
+
+	for visibility, status := range map[string]int{
+		models.ListPrivate:  http.StatusNotFound,
+		models.ListUnlisted: http.StatusOK,
+		models.ListPublic:   http.StatusOK,
+	} {
+		teardown := setupListsTestCase(listOf(3, visibility))
+
+		req := httptest.NewRequest("GET", "localhost:8081/v1/lists/abc", nil)
+		w := httptest.NewRecorder()
+		appListsTest.GetSharedListHandler(w, req, httprouter.Params{{Key: "slug", Value: "abc"}})
+		is.Equal(status, w.Result().StatusCode)
+
+		teardown()
+	}
+}
+
+func TestApplication_AddListItemHandler_Errors(t *testing.T) {
+	is := is2.New(t)
+
+	for err, expected := range map[error]string{
+		provider.ErrRecordNotFound:    `{"movieId":"movie with id 7 not found"}`,
+		provider.ErrDuplicateListItem: `{"movieId":"is already in the list"}`,
+		provider.ErrListFull:          `{"items":"must not contain more than 1000 movies"}`,
+	} {
+		mock := listOf(3, models.ListPrivate)
+		mock.AddItemMock = func(int64, *models.ListItem) error { return err }
+		teardown := setupListsTestCase(mock)
+
+		req := httptest.NewRequest("POST", "localhost:8081/v1/users/me/lists/4/items", strings.NewReader(`{"movieId": 7}`))
+		req = appListsTest.contextSetUser(req, &models.User{ID: 3})
+		w := httptest.NewRecorder()
+		appListsTest.AddListItemHandler(w, req, httprouter.Params{{Key: "id", Value: "4"}})
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		is.True(strings.Contains(string(body), `"errors":`+expected))
+
+		teardown()
+	}
+}
+
+func TestApplication_ReorderListHandler(t *testing.T) {
+	is := is2.New(t)
+
+	var order []int64
+	mock := listOf(3, models.ListPrivate)
+	mock.ReorderMock = func(listID int64, movieIDs []int64) error {
+		if len(movieIDs) != 3 {
+			return provider.ErrInvalidOrder
+		}
+		order = movieIDs
+		return nil
+	}
+	teardown := setupListsTestCase(mock)
+	defer teardown()
+
+	for input, status := range map[string]int{
+		`{"movieIds": [3, 1, 3]}`: http.StatusUnprocessableEntity, //duplicates
+		`{"movieIds": [3, 1]}`:    http.StatusUnprocessableEntity, //missing one
+		`{"movieIds": [3, 1, 2]}`: http.StatusOK,
+	} {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/users/me/lists/4/order", strings.NewReader(input))
+		req = appListsTest.contextSetUser(req, &models.User{ID: 3})
+		w := httptest.NewRecorder()
+		appListsTest.ReorderListHandler(w, req, httprouter.Params{{Key: "id", Value: "4"}})
+		is.Equal(status, w.Result().StatusCode)
+	}
+	is.Equal(order, []int64{3, 1, 2})
+}
@@ -1,10 +1,19 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"time"
+	"yamda_go/internal/activity"
+	"yamda_go/internal/clientip"
 	"yamda_go/internal/config"
 	"yamda_go/internal/data/provider"
 	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/loadshed"
+	"yamda_go/internal/mailer"
+	"yamda_go/internal/moderation"
+	"yamda_go/internal/ratelimit"
+	"yamda_go/internal/recommend"
 )
 
 func main() {
@@ -15,11 +24,116 @@ func main() {
 		logger.PrintFatal(err, nil)
 	}
 
+	//on bare-metal deployments logs go to a rotating file instead of stdout
+	if cfg.LogFile != "" {
+		out, err := jsonlog.NewRotatingFile(
+			cfg.LogFile,
+			int64(cfg.LogMaxSizeMB)*1024*1024,
+			time.Duration(cfg.LogRotateHours)*time.Hour,
+			time.Duration(cfg.LogMaxAgeHours)*time.Hour,
+			cfg.LogMaxBackups,
+			cfg.LogCompress)
+		if err != nil {
+			logger.PrintFatal(err, nil)
+			panic(err)
+		}
+		defer out.Close()
+		logger = jsonlog.New(out, jsonlog.LevelInfo)
+	}
+
+	//anybody could forge pagination cursors signed with an empty secret
+	if cfg.CursorSecret == "" {
+		err = errors.New("CURSOR_SECRET is not set")
+		logger.PrintFatal(err, nil)
+		panic(err)
+	}
+
+	resolver, err := clientip.NewResolver(cfg.TrustedProxies)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+		panic(err)
+	}
+
+	//one pool of connections for all the providers
+	db, err := provider.OpenDB(cfg)
+	if err != nil {
+		logger.PrintFatal(err, nil)
+		panic(err)
+	}
+	defer db.Close()
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.LimiterStore == "redis" {
+		redis := ratelimit.NewRedisStore(cfg.LimiterRedisAddr, "yamda:ratelimit:", cfg.LimiterRedisPool)
+		defer redis.Close()
+		store = redis
+	}
+
+	var concurrency *loadshed.Limiter
+	if cfg.ConcurrencyEnabled {
+		concurrency = loadshed.NewLimiter(
+			cfg.ConcurrencyInitial,
+			cfg.ConcurrencyMin,
+			cfg.ConcurrencyMax,
+			time.Duration(cfg.ConcurrencyTargetLatencyMs)*time.Millisecond)
+	}
+
+	//free text search is served by an in-process index, filled from the database once
+	indexed := provider.NewIndexedMovieProvider(provider.NewMovieProvider(db, cfg))
+	if err = indexed.Rebuild(); err != nil {
+		logger.PrintFatal(err, nil)
+		panic(err)
+	}
+
+	//and so are the features of similar movies, refreshed in the background then
+	people := provider.NewPersonProvider(db, cfg)
+	movies := provider.NewSimilarMovieProvider(indexed, people.Credits)
+	if err = movies.Rebuild(); err != nil {
+		logger.PrintFatal(err, nil)
+		panic(err)
+	}
+
+	//the recommendation model is trained offline, the API does without until then
+	recommender := recommend.NewRecommender(cfg.RecommendModelPath)
+	if _, err = recommender.Reload(); err != nil {
+		logger.PrintError(err, map[string]string{"model": cfg.RecommendModelPath})
+	}
+
+	//events are buffered, never slowing down the requests recording them
+	activities := provider.NewActivityProvider(db, cfg)
+	buffer := activity.NewBuffer(activities.Save, cfg.ActivityMaxPending)
+
+	//activation emails are only logged until there is an SMTP server
+	var mails mailer.Mailer = mailer.NewLog(logger)
+	if cfg.SmtpHost != "" {
+		mails = mailer.NewSMTP(cfg.SmtpHost, cfg.SmtpPort, cfg.SmtpUsername, cfg.SmtpPassword, cfg.SmtpSender)
+	}
+
 	app := &Application{
-		config:        cfg,
-		logger:        logger,
-		movieProvider: provider.NewMovieProvider(cfg, logger),
-		userProvider:  provider.NewUserProvider(cfg, logger),
+		config:              cfg,
+		logger:              logger,
+		movieProvider:       movies,
+		similarMovies:       movies,
+		genreProvider:       provider.NewGenreProvider(db, cfg),
+		personProvider:      people,
+		ratingProvider:      provider.NewRatingProvider(db, cfg),
+		reviewProvider:      provider.NewReviewProvider(db, cfg),
+		listProvider:        provider.NewListProvider(db, cfg),
+		historyProvider:     provider.NewHistoryProvider(db, cfg),
+		activityProvider:    activities,
+		collectionProvider:  provider.NewCollectionProvider(db, cfg),
+		relationProvider:    provider.NewRelationProvider(db, cfg),
+		translationProvider: provider.NewTranslationProvider(db, cfg),
+		userProvider:        provider.NewUserProvider(db, cfg),
+		tokenProvider:       provider.NewTokenProvider(db, cfg),
+		permissions:         provider.NewPermissionProvider(db, cfg),
+		screener:            moderation.NewScreener(cfg.ReviewFlaggedWords),
+		mailer:              mails,
+		recommender:         recommender,
+		activity:            buffer,
+		clientIP:            resolver,
+		limiterStore:        store,
+		concurrency:         concurrency,
 	}
 
 	if err = app.serve(); err != nil {
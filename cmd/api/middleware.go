@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"net"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/ratelimit"
+	"yamda_go/internal/validator"
 
-	"golang.org/x/time/rate"
+	"github.com/julienschmidt/httprouter"
 )
 
 func (app *Application) recoverPanic(next http.Handler) http.Handler {
@@ -38,66 +45,276 @@ func (app *Application) recoverPanic(next http.Handler) http.Handler {
 	})
 }
 
-func (app *Application) rateLimit(next http.Handler) http.Handler {
+// statusRecorder remembers the status code written by the handlers downstream.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// logRequest writes an access log entry for each request, with the address of
+// the client as resolved through the trusted proxies.
+func (app *Application) logRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(sr, r)
+
+		ip, err := app.clientIP.ClientIP(r)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		app.logger.PrintInfo("request", map[string]string{
+			"client_ip": ip,
+			"method":    r.Method,
+			"uri":       r.URL.RequestURI(),
+			"proto":     r.Proto,
+			"status":    strconv.Itoa(sr.status),
+			"duration":  time.Since(start).String(),
+		})
+	})
+}
 
-	type client struct {
-		limiter *rate.Limiter
-		//with the last time seen we can run a goroutine to clean up the mapping.
-		lastSeen time.Time // last time this client connected
+// shedLoad limits how many requests are processed at the same time across the
+// whole server. Requests above the (adaptive) limit get a 503 right away instead
+// of piling up on the database. Priority routes, like the healthcheck, are never shed.
+func (app *Application) shedLoad(next http.Handler) http.Handler {
+	if app.concurrency == nil {
+		return next
 	}
 
-	var (
-		mu      sync.Mutex
-		clients = make(map[string]*client)
-	)
-
-	//clean up the clients map every minute.
-	go func() {
-		for {
-			time.Sleep(time.Minute)
-
-			mu.Lock()
-			for ip, c := range clients {
-				if time.Since(c.lastSeen) > 3*time.Minute {
-					delete(clients, ip)
-				}
-			}
-			mu.Unlock()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if priorityRoutes[app.routeTable.match(r.Method, r.URL.Path)] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !app.concurrency.Acquire() {
+			app.serviceUnavailableResponse(w, time.Second)
+			return
 		}
-	}()
+
+		start := time.Now()
+		sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			//clients hanging up (closed tabs, autocomplete keystrokes) are no overload
+			if errors.Is(r.Context().Err(), context.Canceled) {
+				app.concurrency.Abandon()
+				return
+			}
+			overloaded := sr.status == http.StatusServiceUnavailable ||
+				sr.status == http.StatusGatewayTimeout ||
+				errors.Is(r.Context().Err(), context.DeadlineExceeded)
+			app.concurrency.Release(time.Since(start), overloaded)
+		}()
+
+		next.ServeHTTP(sr, r)
+	})
+}
+
+func (app *Application) rateLimit(next http.Handler) http.Handler {
+	//the limiter can be switched off from the outside (e.g. load tests).
+	if !app.config.LimiterEnabled {
+		return next
+	}
+
+	def := ratelimit.Policy{RPS: float64(app.config.LimiterRPS), Burst: app.config.LimiterBurst}
+	policies, err := ratelimit.ParsePolicies(def, app.config.LimiterPolicies)
+	if err != nil {
+		//our configuration is broken, we can't start like this.
+		app.logger.PrintFatal(err, nil)
+		panic(err)
+	}
+
+	store := app.limiterStore
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 
-		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		identity, err := app.clientIdentity(r)
 		if err != nil {
 			app.serverErrorResponse(w, err)
 			return
 		}
 
-		mu.Lock()
+		//each route with a policy of its own gets separate buckets.
+		scope, policy := policies.For(app.routeTable.match(r.Method, r.URL.Path))
 
-		// Check to see if the IP address already exists in the map. If it doesn't, then
-		// initialize a new rate limiter and add the IP address and limiter to the map.
-		if _, found := clients[ip]; !found {
-			nl := rate.NewLimiter(rate.Limit(app.config.LimiterRPS), app.config.LimiterBurst)
-			clients[ip] = &client{limiter: nl}
+		res, err := store.Take(r.Context(), scope+"|"+identity, policy)
+		if err != nil {
+			//fail open: an unavailable store must not take the whole API down.
+			app.logger.PrintError(err, map[string]string{"identity": identity})
+			next.ServeHTTP(w, r)
+			return
 		}
 
-		// Update the last seen time for the client.
-		clients[ip].lastSeen = time.Now()
+		setRateLimitHeaders(w.Header(), res)
 
 		//if the request is not allowed, respond with rate limit error code
-		if !clients[ip].limiter.Allow() {
-			mu.Unlock()
-			app.rateLimitExceededResponse(w)
+		if !res.Allowed {
+			app.rateLimitExceededResponse(w, res.RetryAfter)
 			return
 		}
 
-		// Notice that we DON'T use defer to unlock the mutex, as that would mean
-		// that the mutex isn't unlocked until all the handlers downstream of this
-		// middleware have also returned.
-		mu.Unlock()
-
 		next.ServeHTTP(w, r)
 	})
 }
+
+// clientIdentity returns who a request should be accounted to by the rate
+// limiter. In order of preference: the authenticated user, a known API key
+// and finally the client IP address.
+func (app *Application) clientIdentity(r *http.Request) (string, error) {
+	if user := app.contextGetUser(r); user != nil {
+		return "user:" + strconv.FormatInt(user.ID, 10), nil
+	}
+
+	//only keys we know about, otherwise anyone could get a fresh bucket by
+	//making up a new key on each request.
+	if key := r.Header.Get("X-API-Key"); key != "" && validator.In(key, app.config.LimiterAPIKeys...) {
+		return "key:" + key, nil
+	}
+
+	ip, err := app.clientIP.ClientIP(r)
+	if err != nil {
+		return "", err
+	}
+	return "ip:" + ip, nil
+}
+
+// setRateLimitHeaders advertises the state of the client's bucket using the
+// RateLimit header fields (draft-ietf-httpapi-ratelimit-headers).
+func setRateLimitHeaders(h http.Header, res ratelimit.Result) {
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as HTTP headers expect.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// authenticate adds the owner of the bearer token to the request context.
+// Requests without an Authorization header go on anonymously, those with an
+// invalid or expired token are rejected.
+// It runs before rateLimit, which gives users buckets of their own, so token
+// lookups are limited here, per client IP: guessing tokens would be free otherwise.
+func (app *Application) authenticate(next http.Handler) http.Handler {
+	store := app.limiterStore
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+	lookups := ratelimit.Policy{RPS: float64(app.config.LimiterTokenRPS), Burst: app.config.LimiterTokenBurst}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		//responses differ depending on who is asking
+		w.Header().Add("Vary", "Authorization")
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		parts := strings.Split(header, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			app.invalidAuthenticationTokenResponse(w)
+			return
+		}
+
+		token := parts[1]
+		v := validator.New()
+		if models.ValidateTokenPlaintext(v, token); !v.IsValid() {
+			app.invalidAuthenticationTokenResponse(w)
+			return
+		}
+
+		if app.config.LimiterEnabled {
+			ip, err := app.clientIP.ClientIP(r)
+			if err != nil {
+				app.serverErrorResponse(w, err)
+				return
+			}
+			res, err := store.Take(r.Context(), "token-lookup|ip:"+ip, lookups)
+			if err != nil {
+				//fail open, as rateLimit does
+				app.logger.PrintError(err, map[string]string{"identity": "ip:" + ip})
+			} else if !res.Allowed {
+				setRateLimitHeaders(w.Header(), res)
+				app.rateLimitExceededResponse(w, res.RetryAfter)
+				return
+			}
+		}
+
+		user, err := app.userProvider.GetForToken(models.ScopeAuthentication, token)
+		if err != nil {
+			switch {
+			case errors.Is(err, provider.ErrRecordNotFound):
+				app.invalidAuthenticationTokenResponse(w)
+			default:
+				app.serverErrorResponse(w, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, app.contextSetUser(r, user))
+	})
+}
+
+// requireAuthenticatedUser only lets authenticated users through.
+func (app *Application) requireAuthenticatedUser(next httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if app.contextGetUser(r) == nil {
+			app.authenticationRequiredResponse(w)
+			return
+		}
+		next(w, r, p)
+	}
+}
+
+// requireActivatedUser only lets authenticated users with an activated account through.
+func (app *Application) requireActivatedUser(next httprouter.Handle) httprouter.Handle {
+	return app.requireAuthenticatedUser(func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if !app.contextGetUser(r).Activated {
+			app.inactiveAccountResponse(w)
+			return
+		}
+		next(w, r, p)
+	})
+}
+
+// requirePermission only lets activated users granted the permission through.
+func (app *Application) requirePermission(code string, next httprouter.Handle) httprouter.Handle {
+	return app.requireActivatedUser(func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		allowed, err := app.userHasPermission(r, code)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		if !allowed {
+			app.notPermittedResponse(w)
+			return
+		}
+		next(w, r, p)
+	})
+}
+
+// userHasPermission tells if the user of the request was granted the permission.
+func (app *Application) userHasPermission(r *http.Request, code string) (bool, error) {
+	user := app.contextGetUser(r)
+	if user == nil {
+		return false, nil
+	}
+	permissions, err := app.permissions.GetAllForUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Include(code), nil
+}
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 	"yamda_go/cmd/api/dto"
+	"yamda_go/internal/activity"
 	"yamda_go/internal/data"
 	"yamda_go/internal/data/provider"
 	"yamda_go/internal/models"
@@ -16,7 +19,8 @@ import (
 /**
 * POST /v1/movies -> 201 CREATED with JSON content
 *
-* LOCATION header contains the uri to the newly created resource.
+* LOCATION header contains the uri to the newly created resource,
+* which the body also carries under "_links".
 **/
 func (app *Application) CreateMovieHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	//how we expect data from outside
@@ -40,19 +44,24 @@ func (app *Application) CreateMovieHandler(w http.ResponseWriter, r *http.Reques
 		Runtime: input.Runtime,
 		Genres:  input.Genres,
 	}
-	movie.Validate(v)
+	genres, err := app.genreProvider.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	movie.Validate(v, genres)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
 	//create new movie
-	if _, err := app.movieProvider.Insert(movie); err != nil {
+	if _, err = app.movieProvider.Insert(movie); err != nil {
 		app.serverErrorResponse(w, err)
 		return
 	}
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/movies/%d", movie.ID))
-	if err := app.writeJSON(w, http.StatusCreated, envelope{"movie": movie}, headers); err != nil {
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"movie": app.movieResource(movie)}, headers); err != nil {
 		app.logger.PrintError(err, nil)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -60,14 +69,29 @@ func (app *Application) CreateMovieHandler(w http.ResponseWriter, r *http.Reques
 
 /**
 * GET /v1/movies/:id -> 200 OK with JSON content
+*
+* ?fields=id,title picks the fields of the movie, ?expand= embeds related resources.
 **/
-func (app *Application) GetMovieHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+func (app *Application) GetMovieHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 	num, err := app.ParseId(p)
 	if err != nil {
 		app.badRequestResponse(w, err)
 		return
 	}
 
+	v := validator.New()
+	view := app.readMovieView(r.URL.Query(), v)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+	//the collection and the related movies come along, unless only some fields were asked for
+	for _, e := range movieFranchise {
+		if len(view.fields) == 0 && !validator.In(e, view.expand...) {
+			view.expand = append(view.expand, e)
+		}
+	}
+
 	movie, err := app.movieProvider.Get(num)
 	if err != nil {
 		switch {
@@ -79,7 +103,18 @@ func (app *Application) GetMovieHandler(w http.ResponseWriter, _ *http.Request,
 			return
 		}
 	}
-	if err := app.writeJSON(w, http.StatusOK, envelope{"movie": movie}, nil); err != nil {
+	app.activity.Record(movie.ID, activity.View)
+
+	if err = app.localizeMovies(w, r, movie); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	body, err := app.renderMovie(movie, view, false)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err := app.writeJSON(w, http.StatusOK, envelope{"movie": body}, nil); err != nil {
 		app.logger.PrintError(err, nil)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
@@ -110,7 +145,12 @@ func (app *Application) UpdateMovieHandler(w http.ResponseWriter, r *http.Reques
 		Runtime: m.Runtime,
 		Genres:  m.Genres,
 	}
-	movie.ValidateWithId(v)
+	genres, err := app.genreProvider.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	movie.ValidateWithId(v, genres)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
@@ -159,8 +199,13 @@ func (app *Application) PartialUpdateMovieHandler(w http.ResponseWriter, r *http
 		movie.Genres = i.Genres
 	}
 
+	genres, err := app.genreProvider.Catalog()
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
 	v := validator.New()
-	movie.ValidateWithId(v)
+	movie.ValidateWithId(v, genres)
 	if !v.IsValid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
@@ -210,24 +255,56 @@ func (app *Application) DeleteMovieHandler(w http.ResponseWriter, _ *http.Reques
 
 /**
 * GET /v1/movies -> 200 OK with JSON content
+*
+* LINK header contains the uris to the first, previous, next and last pages.
 **/
 func (app *Application) ListMoviesHandler(w http.ResponseWriter, r *http.Request) {
 
 	v := validator.New()
 	qs := r.URL.Query()
 
+	//free text queries are sorted by relevance unless asked otherwise
+	query := app.readString(qs, "q", "")
+	sort, safelist := "id", []string{"id", "title", "year", "runtime", "rating", "popularity", "-id", "-title", "-year", "-runtime", "-rating", "-popularity"}
+	if query != "" {
+		sort, safelist = "-relevance", append(safelist, "relevance", "-relevance")
+	}
+
 	input := data.Search{
-		Title:  app.readString(qs, "title", ""),
-		Genres: app.readString(qs, "genres", ""),
+		Query:        query,
+		Title:        app.readString(qs, "title", ""),
+		Genres:       app.readCSV(qs, "genres", nil),
+		GenresMatch:  app.readString(qs, "genres_match", data.GenresMatchAny),
+		YearMin:      app.readInt(qs, "year_min", 0, v),
+		YearMax:      app.readInt(qs, "year_max", 0, v),
+		RuntimeMin:   app.readInt(qs, "runtime_min", 0, v),
+		RuntimeMax:   app.readInt(qs, "runtime_max", 0, v),
+		CreatedAfter: app.readTime(qs, "created_after", time.Time{}, v),
+		Facets:       app.readCSV(qs, "facets", nil),
 		Filters: data.Filter{
 			Page:         app.readInt(qs, "page", 1, v),       //default page is 1
 			PageSize:     app.readInt(qs, "page_size", 20, v), //default size is 20
-			Sort:         app.readString(qs, "sort", "id"),    //default sort if by 'id' field
-			SortSafelist: []string{"id", "title", "year", "runtime", "-id", "-title", "-year", "-runtime"},
+			Sort:         app.readString(qs, "sort", sort),    //default sort if by 'id' field, e.g. sort=-year,title
+			SortSafelist: safelist,
+			Cursors:      app.cursorCodec(),
 		},
 	}
+	view := app.readMovieView(qs, v)
+	input.Fields = view.fields
+	input.Filters.After = app.readCursor(qs, "after", input.Filters.Cursors, v)
+	input.Filters.Before = app.readCursor(qs, "before", input.Filters.Cursors, v)
 
-	if input.Filters.Validate(v); !v.IsValid() {
+	//genres are filtered by their canonical name, however the client spells them
+	if len(input.Genres) > 0 {
+		genres, err := app.genreProvider.Catalog()
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		input.Genres = genres.ResolveAll(input.Genres, v)
+	}
+
+	if input.Validate(v); !v.IsValid() {
 		app.failedValidationResponse(w, v.Errors)
 		return
 	}
@@ -235,9 +312,118 @@ func (app *Application) ListMoviesHandler(w http.ResponseWriter, r *http.Request
 	movies, meta, err := app.movieProvider.GetAll(input)
 	if err != nil {
 		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.localizeMovies(w, r, movies...); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	body, err := app.renderMovies(movies, view, true)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	env := envelope{"metadata": meta, "movies": body}
+
+	if len(input.Facets) > 0 {
+		facets, err := app.movieProvider.Facets(input)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		env["facets"] = facets
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r.URL, meta); links != "" {
+		headers.Set("Link", links)
+	}
+	err = app.writeJSON(w, http.StatusOK, env, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/movies/search?q= -> 200 OK with JSON content
+*
+* Full-text search over titles, ranked by relevance. ?mode=boolean enables the
+* MariaDB boolean operators (+must -mustnot "phrase" prefix*).
+**/
+func (app *Application) SearchMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input := data.TextSearch{
+		Query: app.readString(qs, "q", ""),
+		Mode:  app.readString(qs, "mode", data.TextModeNatural),
+		Filters: data.Filter{
+			Page:         app.readInt(qs, "page", 1, v),
+			PageSize:     app.readInt(qs, "page_size", 20, v),
+			Sort:         app.readString(qs, "sort", "-relevance"), //most relevant first
+			SortSafelist: []string{"relevance", "title", "year", "-relevance", "-title", "-year"},
+		},
+	}
+
+	if input.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	matches, meta, err := app.movieProvider.Search(input)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	terms := input.Terms()
+	results := make([]envelope, 0, len(matches))
+	for _, m := range matches {
+		results = append(results, envelope{
+			"movie":      app.movieResource(m.Movie),
+			"score":      m.Score,
+			"highlights": map[string]string{"title": data.Highlight(m.Movie.Title, terms)},
+		})
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r.URL, meta); links != "" {
+		headers.Set("Link", links)
+	}
+	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": meta, "results": results}, headers)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/movies/autocomplete?prefix= -> 200 OK with JSON content
+*
+* Titles completing what the user typed so far, tolerating a few typos.
+**/
+func (app *Application) AutocompleteMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	prefix := app.readString(qs, "prefix", "")
+	limit := app.readInt(qs, "limit", 10, v)
+
+	v.Check(strings.TrimSpace(prefix) != "", "prefix", "must be provided")
+	v.Check(len(prefix) <= 100, "prefix", "must not be more than 100 bytes long")
+	v.Check(limit > 0 && limit <= 50, "limit", "must be between 1 and 50")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	suggestions, err := app.movieProvider.Suggest(prefix, limit)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
 	}
 
-	err = app.writeJSON(w, http.StatusOK, envelope{"metadata": meta, "movies": movies}, nil)
+	err = app.writeJSON(w, http.StatusOK, envelope{"suggestions": suggestions}, nil)
 	if err != nil {
 		app.serverErrorResponse(w, err)
 	}
@@ -11,6 +11,7 @@ import (
 	"strings"
 	"testing"
 	"yamda_go/internal/config"
+	"yamda_go/internal/data"
 	"yamda_go/internal/data/provider"
 	"yamda_go/internal/jsonlog"
 	provmock "yamda_go/internal/mocks/data/provider"
@@ -19,13 +20,43 @@ import (
 
 var appMoviesTest *Application = nil
 
+// testGenres knows the genres used by the tests below.
+var testGenres = provmock.GenreProviderMock{
+	CatalogMock: func() (models.GenreCatalog, error) {
+		return models.NewGenreCatalog([]*models.Genre{
+			{Slug: "drama", Name: "Drama"},
+			{Slug: "history", Name: "History", Aliases: []string{"historical"}},
+			{Slug: "romance", Name: "Romance"},
+			{Slug: "fantasy", Name: "Fantasy"},
+			{Slug: "science-fiction", Name: "Science Fiction", Aliases: []string{"sci-fi"}},
+		}), nil
+	},
+}
+
+// noCollections and noRelations know no collection and no related movie.
+var (
+	noCollections = provmock.CollectionProviderMock{
+		CollectionsForMoviesMock: func(...int64) (map[int64]*models.CollectionSummary, error) {
+			return map[int64]*models.CollectionSummary{}, nil
+		},
+	}
+	noRelations = provmock.RelationProviderMock{
+		RelatedMock: func(...int64) (map[int64][]*models.RelatedMovie, error) {
+			return map[int64][]*models.RelatedMovie{}, nil
+		},
+	}
+)
+
 func setupTestCase(p provmock.MovieProviderMock) func() {
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 	cfg, _ := config.New("./../../debug.env")
 	appMoviesTest = &Application{
-		logger:        logger,
-		config:        cfg,
-		movieProvider: p,
+		logger:             logger,
+		config:             cfg,
+		movieProvider:      p,
+		genreProvider:      testGenres,
+		collectionProvider: noCollections,
+		relationProvider:   noRelations,
 	}
 	return func() {
 		//some teardown
@@ -319,6 +350,28 @@ func TestApplication_CreateMovieHandler_GenresMustBeUnique(t *testing.T) {
 	is.Equal(expectedBody, string(body))
 }
 
+func TestApplication_CreateMovieHandler_GenresMustBeKnown(t *testing.T) {
+	is := is2.New(t)
+	teardown := setupTestCase(provmock.MovieProviderMock{})
+	defer teardown()
+
+	for content, expected := range map[string]string{
+		`{"title": "Casablanca", "runtime": "125 mins", "year": 2020, "genres": ["drama","spy"]}`:             `unknown genre \"spy\"`,
+		`{"title": "Casablanca", "runtime": "125 mins", "year": 2020, "genres": ["Sci-Fi","science fiction"]}`: "must not contain duplicate values",
+	} {
+		req := httptest.NewRequest("POST", "localhost:8081/v1/movies", strings.NewReader(content))
+		w := httptest.NewRecorder()
+		appMoviesTest.CreateMovieHandler(w, req, nil)
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+
+		is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"genres":"` + expected + `"}}`
+		is.Equal(expectedBody, string(body))
+	}
+}
+
 func TestApplication_CreateMovieHandler_Ok(t *testing.T) {
 	is := is2.New(t)
 	mock := provmock.MovieProviderMock{}
@@ -341,7 +394,7 @@ func TestApplication_CreateMovieHandler_Ok(t *testing.T) {
 	is.Equal(http.StatusCreated, resp.StatusCode)
 	is.Equal("application/json", resp.Header.Get("Content-Type"))
 	is.Equal("/v1/movies/12", resp.Header.Get("Location"))
-	expectedBody := `{"movie":{"id":12,"title":"Casablanca","runtime":"125 mins","genres":["historical","drama"],"year":2020,"version":1}}`
+	expectedBody := `{"movie":{"id":12,"title":"Casablanca","runtime":"125 mins","genres":["History","Drama"],"year":2020,"version":1,"_links":{"self":{"href":"/v1/movies/12"}}}}`
 	is.Equal(expectedBody, string(body))
 }
 
@@ -381,7 +434,7 @@ func TestApplication_GetMovieHandler_Ok(t *testing.T) {
 	is.Equal(http.StatusOK, resp.StatusCode)
 	is.Equal("application/json", resp.Header.Get("Content-Type"))
 
-	movie := `{"movie":{"id":1,"title":"The Last Samurai","runtime":"127 mins","genres":["drama"," history"],"year":2015,"version":1}}`
+	movie := `{"movie":{"id":1,"title":"The Last Samurai","runtime":"127 mins","genres":["drama"," history"],"year":2015,"version":1,"collection":null,"related":[]}}`
 	is.Equal(movie, string(body))
 }
 
@@ -527,7 +580,7 @@ func TestApplication_UpdateMovieHandler_Partially(t *testing.T) {
 	resp := w.Result()
 	body, _ := io.ReadAll(resp.Body)
 	is.Equal(http.StatusOK, resp.StatusCode)
-	expectedBody := `{"movie":{"id":1,"title":"Let's grow old together","runtime":"125 mins","genres":["drama"],"year":2000,"version":0}}`
+	expectedBody := `{"movie":{"id":1,"title":"Let's grow old together","runtime":"125 mins","genres":["Drama"],"year":2000,"version":0}}`
 	is.Equal(string(body), expectedBody)
 }
 
@@ -586,3 +639,331 @@ func TestApplication_DeleteMovieHandler_MovieNotFound(t *testing.T) {
 	expectedBody := `{"title":"resource not found","status":404,"detail":"movie with id 1 not found"}`
 	is.Equal(expectedBody, string(body))
 }
+
+/*********************************************************
+** LIST
+*********************************************************/
+
+func TestApplication_ListMoviesHandler_AfterCursorIsPassedToProvider(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		received = s
+		return []*models.Movie{}, &models.Metadata{PageSize: 20, Next: "next-token"}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	after := appMoviesTest.cursorCodec().Encode(data.Cursor{Sort: "-year", Values: []string{"1998"}, ID: 7})
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies?sort=-year&after="+after, nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(*received.Filters.After, data.Cursor{Sort: "-year", Values: []string{"1998"}, ID: 7})
+	is.True(received.Filters.Before == nil)
+	is.Equal(`{"metadata":{"pageSize":20,"next":"next-token"},"movies":[]}`, string(body))
+}
+
+func TestApplication_ListMoviesHandler_InvalidCursor(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupTestCase(provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies?before=forged.cursor", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"before":"must be a valid cursor"}}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_ListMoviesHandler_LinkHeaderKeepsFilters(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		meta := models.New(50, s.Filters.Page, s.Filters.PageSize)
+		return []*models.Movie{{ID: 3, Title: "Gothika", Runtime: 125, Genres: []string{"Horror"}, Year: 2003, Version: 1}}, &meta, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies?title=go&page=2&page_size=10&sort=-year", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	expectedLinks := `</v1/movies?page=1&page_size=10&sort=-year&title=go>; rel="first", ` +
+		`</v1/movies?page=1&page_size=10&sort=-year&title=go>; rel="prev", ` +
+		`</v1/movies?page=3&page_size=10&sort=-year&title=go>; rel="next", ` +
+		`</v1/movies?page=5&page_size=10&sort=-year&title=go>; rel="last"`
+	is.Equal(expectedLinks, resp.Header.Get("Link"))
+	is.True(strings.Contains(string(body), `"_links":{"self":{"href":"/v1/movies/3"}}`))
+}
+
+func TestApplication_ListMoviesHandler_SparseFieldset(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		received = s
+		return []*models.Movie{{ID: 3, Title: "Gothika", Year: 2003}}, &models.Metadata{}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies?fields=title,id,year", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(received.Fields, []string{"title", "id", "year"})
+	expectedBody := `{"metadata":{},"movies":[{"id":3,"title":"Gothika","year":2003,"_links":{"self":{"href":"/v1/movies/3"}}}]}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_GetMovieHandler_SparseFieldset(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.MovieProviderMock{}
+	mock.GetMovieMock = func(id int64) (*models.Movie, error) {
+		return &models.Movie{ID: 1, Title: "The Last Samurai", Runtime: 127, Genres: []string{"drama"}, Year: 2015, Version: 1}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/1?fields=title,runtime", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "1"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(`{"movie":{"title":"The Last Samurai","runtime":"127 mins"}}`, string(body))
+}
+
+func TestApplication_GetMovieHandler_UnknownFieldsAndExpansions(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupTestCase(provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/1?fields=title,budget&expand=studio", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "1"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"expand":"unknown resource \"studio\"","fields":"unknown field \"budget\""}}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_ListMoviesHandler_QuerySortsByRelevance(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		received = s
+		return []*models.Movie{}, &models.Metadata{}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies?q=godfather", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	is.Equal(http.StatusOK, w.Result().StatusCode)
+	is.Equal(received.Query, "godfather")
+	is.Equal(received.Filters.Sort, "-relevance")
+
+	//relevance means nothing without a query
+	req = httptest.NewRequest("GET", "http://localhost:8081/v1/movies?sort=-relevance", nil)
+	w = httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestApplication_ListMoviesHandler_Facets(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		return []*models.Movie{}, &models.Metadata{}, nil
+	}
+	mock.MovieFacetsMock = func(s data.Search) (models.Facets, error) {
+		received = s
+		return models.Facets{"genres": {{Value: "Drama", Count: 132}, {Value: "Comedy", Count: 98}}}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies?genres=Drama&facets=genres", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(received.Genres, []string{"Drama"}) //the provider leaves it out of the genre counts
+	is.Equal(`{"facets":{"genres":[{"value":"Drama","count":132},{"value":"Comedy","count":98}]},"metadata":{},"movies":[]}`, string(body))
+
+	req = httptest.NewRequest("GET", "http://localhost:8081/v1/movies?facets=genres,studio", nil)
+	w = httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	body, _ = io.ReadAll(w.Result().Body)
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+	is.True(strings.Contains(string(body), `"facets":"unknown facet \"studio\""`))
+}
+
+func TestApplication_ListMoviesHandler_GenresAreResolved(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		received = s
+		return []*models.Movie{}, &models.Metadata{}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies?genres=SciFi,%20HISTORICAL,drama", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	is.Equal(http.StatusOK, w.Result().StatusCode)
+	is.Equal(received.Genres, []string{"Science Fiction", "History", "Drama"})
+
+	req = httptest.NewRequest("GET", "http://localhost:8081/v1/movies?genres=Drama,Western", nil)
+	w = httptest.NewRecorder()
+	appMoviesTest.ListMoviesHandler(w, req)
+
+	body, _ := io.ReadAll(w.Result().Body)
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+	is.True(strings.Contains(string(body), `"genres":"unknown genre \"Western\""`))
+}
+
+/*********************************************************
+** SEARCH
+*********************************************************/
+
+func TestApplication_SearchMoviesHandler_RanksAndHighlights(t *testing.T) {
+	is := is2.New(t)
+
+	var received data.TextSearch
+	mock := provmock.MovieProviderMock{}
+	mock.SearchMoviesMock = func(s data.TextSearch) ([]*models.MovieMatch, *models.Metadata, error) {
+		received = s
+		meta := models.New(1, s.Filters.Page, s.Filters.PageSize)
+		movie := &models.Movie{ID: 9, Title: "The Godfather", Runtime: 175, Genres: []string{"Crime"}, Year: 1972, Version: 1}
+		return []*models.MovieMatch{{Movie: movie, Score: 1.5}}, &meta, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/search?q=godfather", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.SearchMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(received.Mode, data.TextModeNatural)
+	is.Equal(received.Filters.Sort, "-relevance")
+	is.True(strings.Contains(string(body), `"score":1.5`))
+	is.True(strings.Contains(string(body), `"highlights":{"title":"The \u003cmark\u003eGodfather\u003c/mark\u003e"}`))
+}
+
+func TestApplication_SearchMoviesHandler_QueryIsRequired(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupTestCase(provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/search?mode=fuzzy", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.SearchMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"mode":"must be either natural or boolean","q":"must be provided"}}`
+	is.Equal(expectedBody, string(body))
+}
+
+/*********************************************************
+** AUTOCOMPLETE
+*********************************************************/
+
+func TestApplication_AutocompleteMoviesHandler(t *testing.T) {
+	is := is2.New(t)
+
+	var prefix string
+	var limit int
+	mock := provmock.MovieProviderMock{}
+	mock.SuggestMoviesMock = func(p string, l int) ([]models.Suggestion, error) {
+		prefix, limit = p, l
+		return []models.Suggestion{{ID: 9, Title: "The Godfather"}}, nil
+	}
+	teardown := setupTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/autocomplete?prefix=godf", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.AutocompleteMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(prefix, "godf")
+	is.Equal(limit, 10)
+	is.Equal(`{"suggestions":[{"id":9,"title":"The Godfather"}]}`, string(body))
+}
+
+func TestApplication_AutocompleteMoviesHandler_InvalidInput(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupTestCase(provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "http://localhost:8081/v1/movies/autocomplete?limit=500", nil)
+	w := httptest.NewRecorder()
+	appMoviesTest.AutocompleteMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"limit":"must be between 1 and 50","prefix":"must be provided"}}`
+	is.Equal(expectedBody, string(body))
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/data"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/people -> 200 OK with JSON content
+*
+* ?name= finds the people whose name contains it, whatever the case.
+**/
+func (app *Application) ListPeopleHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+
+	input := data.PeopleSearch{
+		Name: strings.TrimSpace(app.readString(qs, "name", "")),
+		Filters: data.Filter{
+			Page:         app.readInt(qs, "page", 1, v),
+			PageSize:     app.readInt(qs, "page_size", 20, v),
+			Sort:         app.readString(qs, "sort", "name"),
+			SortSafelist: []string{"id", "name", "birth_year", "-id", "-name", "-birth_year"},
+		},
+	}
+	if input.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	people, meta, err := app.personProvider.GetAll(input)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r.URL, meta); links != "" {
+		headers.Set("Link", links)
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"metadata": meta, "people": people}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/people -> 201 CREATED with JSON content
+**/
+func (app *Application) CreatePersonHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Name      string `json:"name"`
+		BirthYear int32  `json:"birthYear"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	person := &models.Person{
+		Name:      strings.TrimSpace(input.Name),
+		BirthYear: input.BirthYear,
+	}
+	v := validator.New()
+	if person.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if _, err := app.personProvider.Insert(person); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/people/%d", person.ID))
+	if err := app.writeJSON(w, http.StatusCreated, envelope{"person": person}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/people/:id -> 200 OK with JSON content
+**/
+func (app *Application) GetPersonHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	person, ok := app.readPerson(w, p)
+	if !ok {
+		return
+	}
+	if err := app.writeJSON(w, http.StatusOK, envelope{"person": person}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PATCH /v1/people/:id -> 200 OK with JSON content
+*
+* The version, when given, must match the current one.
+**/
+func (app *Application) UpdatePersonHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	person, ok := app.readPerson(w, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Name      *string `json:"name"`
+		BirthYear *int32  `json:"birthYear"`
+		Version   *int    `json:"version"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if input.Version != nil && *input.Version != person.Version {
+		app.resourceEditConflictResponse(w)
+		return
+	}
+	if input.Name != nil {
+		person.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.BirthYear != nil {
+		person.BirthYear = *input.BirthYear
+	}
+
+	v := validator.New()
+	if person.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.personProvider.Update(person); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"person": person}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/people/:id -> 200 OK
+*
+* The credits of the person go along with them.
+**/
+func (app *Application) DeletePersonHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if err = app.personProvider.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("person with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+/**
+* GET /v1/people/:id/filmography -> 200 OK with JSON content
+*
+* Every credit of the person, the most recent movies first.
+**/
+func (app *Application) FilmographyHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	credits, err := app.personProvider.Filmography(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("person with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"filmography": credits}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// readPerson loads the person of the :id parameter, writing the error response
+// when it can't.
+func (app *Application) readPerson(w http.ResponseWriter, p httprouter.Params) (*models.Person, bool) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	person, err := app.personProvider.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("person with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+	return person, true
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appPeopleTest *Application = nil
+
+func setupPeopleTestCase(p provmock.PersonProviderMock, m provmock.MovieProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appPeopleTest = &Application{
+		logger:         logger,
+		config:         cfg,
+		personProvider: p,
+		movieProvider:  m,
+	}
+	return func() {
+		//some teardown
+		appPeopleTest = nil
+	}
+}
+
+func TestApplication_CreatePersonHandler_Ok(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.PersonProviderMock{}
+	mock.CreatePersonMock = func(p *models.Person) (*models.Person, error) {
+		p.ID, p.Version = 3, 1
+		return p, nil
+	}
+	teardown := setupPeopleTestCase(mock, provmock.MovieProviderMock{})
+	defer teardown()
+
+	content := `{"name": " Sofia Coppola ", "birthYear": 1971}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/people", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.CreatePersonHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	is.Equal("/v1/people/3", resp.Header.Get("Location"))
+	is.Equal(`{"person":{"id":3,"name":"Sofia Coppola","birthYear":1971,"version":1}}`, string(body))
+}
+
+func TestApplication_CreatePersonHandler_InvalidInput(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupPeopleTestCase(provmock.PersonProviderMock{}, provmock.MovieProviderMock{})
+	defer teardown()
+
+	content := `{"name": "  ", "birthYear": 1700}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/people", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.CreatePersonHandler(w, req, nil)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"birthYear":"must be greater than 1800","name":"must be provided"}}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_ListPeopleHandler_NameIsPassedToProvider(t *testing.T) {
+	is := is2.New(t)
+
+	var got data.PeopleSearch
+	mock := provmock.PersonProviderMock{}
+	mock.GetAllPeopleMock = func(s data.PeopleSearch) ([]*models.Person, *models.Metadata, error) {
+		got = s
+		meta := models.New(1, 1, 20)
+		return []*models.Person{{ID: 1, Name: "Sofia Coppola", Version: 1}}, &meta, nil
+	}
+	teardown := setupPeopleTestCase(mock, provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/people?name=coppola&sort=-birth_year", nil)
+	w := httptest.NewRecorder()
+	appPeopleTest.ListPeopleHandler(w, req)
+
+	resp := w.Result()
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal("coppola", got.Name)
+	is.Equal("-birth_year", got.Filters.Sort)
+}
+
+func TestApplication_FilmographyHandler_PersonNotFound(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.PersonProviderMock{}
+	mock.FilmographyMock = func(id int64) ([]*models.Credit, error) {
+		return nil, provider.ErrRecordNotFound
+	}
+	teardown := setupPeopleTestCase(mock, provmock.MovieProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/people/9/filmography", nil)
+	w := httptest.NewRecorder()
+	appPeopleTest.FilmographyHandler(w, req, httprouter.Params{{Key: "id", Value: "9"}})
+
+	resp := w.Result()
+	is.Equal(http.StatusNotFound, resp.StatusCode)
+}
+
+func TestApplication_SetCreditsHandler_BillingFollowsOrder(t *testing.T) {
+	is := is2.New(t)
+
+	var saved []*models.Credit
+	mock := provmock.PersonProviderMock{}
+	mock.SetCreditsMock = func(movieID int64, credits []*models.Credit) error {
+		saved = credits
+		return nil
+	}
+	mock.CreditsMock = func(ids ...int64) (map[int64][]*models.Credit, error) {
+		return map[int64][]*models.Credit{ids[0]: saved}, nil
+	}
+	teardown := setupPeopleTestCase(mock, provmock.MovieProviderMock{})
+	defer teardown()
+
+	content := `{"credits": [{"personId": 1, "role": "Director"}, {"personId": 2, "role": "actor", "character": "Charlotte"}]}`
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/credits", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.SetCreditsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusOK, resp.StatusCode)
+	expectedBody := `{"credits":[{"id":0,"personId":1,"role":"director","billing":1},{"id":0,"personId":2,"role":"actor","character":"Charlotte","billing":2}]}`
+	is.Equal(expectedBody, string(body))
+}
+
+func TestApplication_SetCreditsHandler_InvalidCredits(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupPeopleTestCase(provmock.PersonProviderMock{}, provmock.MovieProviderMock{})
+	defer teardown()
+
+	content := `{"credits": [{"personId": 1, "role": "gaffer"}, {"personId": 2, "role": "director", "character": "Himself"}]}`
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/credits", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.SetCreditsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	is.True(strings.Contains(string(body), `"role":"must be one of director, writer, actor, producer, composer, cinematographer, editor"`))
+	is.True(strings.Contains(string(body), `"character":"only actors play a character"`))
+}
+
+func TestApplication_SetCreditsHandler_DuplicateCredits(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupPeopleTestCase(provmock.PersonProviderMock{}, provmock.MovieProviderMock{})
+	defer teardown()
+
+	//the database compares characters regardless of their case
+	content := `{"credits": [{"personId": 2, "role": "actor", "character": "Charlotte"}, {"personId": 2, "role": "Actor", "character": "charlotte"}]}`
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/credits", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.SetCreditsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	is.True(strings.Contains(string(body), `"credits":"must not contain the same credit twice"`))
+}
+
+func TestApplication_SetCreditsHandler_UnknownPerson(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.PersonProviderMock{}
+	mock.SetCreditsMock = func(movieID int64, credits []*models.Credit) error {
+		return fmt.Errorf("%w: %d", provider.ErrPersonNotFound, 42)
+	}
+	teardown := setupPeopleTestCase(mock, provmock.MovieProviderMock{})
+	defer teardown()
+
+	content := `{"credits": [{"personId": 42, "role": "writer"}]}`
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/credits", strings.NewReader(content))
+	w := httptest.NewRecorder()
+	appPeopleTest.SetCreditsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+	is.True(strings.Contains(string(body), `"personId":"person not found: 42"`))
+}
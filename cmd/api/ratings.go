@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"yamda_go/internal/activity"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* PUT /v1/movies/:id/rating -> 201 CREATED or 200 OK with JSON content
+*
+* {"score": 8}
+* Each user has one score per movie, sending a new one replaces it.
+**/
+func (app *Application) RateMovieHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Score int `json:"score"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	rating := &models.Rating{MovieID: id, Score: input.Score}
+	v := validator.New()
+	if rating.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	created, err := app.ratingProvider.Set(user.ID, rating)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	app.activity.Record(id, activity.Rating)
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	if err = app.writeJSON(w, status, envelope{"rating": rating}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/movies/:id/rating -> 200 OK
+**/
+func (app *Application) DeleteRatingHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	user := app.contextGetUser(r)
+	if err = app.ratingProvider.Delete(user.ID, id); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("rating of movie with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appRatingsTest *Application = nil
+
+func setupRatingsTestCase(p provmock.RatingProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appRatingsTest = &Application{
+		logger:         logger,
+		config:         cfg,
+		ratingProvider: p,
+	}
+	return func() {
+		//some teardown
+		appRatingsTest = nil
+	}
+}
+
+func TestApplication_RateMovieHandler_AuthenticationRequired(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupRatingsTestCase(provmock.RatingProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/rating", strings.NewReader(`{"score": 8}`))
+	w := httptest.NewRecorder()
+	appRatingsTest.requireAuthenticatedUser(appRatingsTest.RateMovieHandler)(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	is.Equal(http.StatusUnauthorized, resp.StatusCode)
+	is.Equal("Bearer", resp.Header.Get("WWW-Authenticate"))
+}
+
+func TestApplication_RateMovieHandler_ScoreOutOfRange(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupRatingsTestCase(provmock.RatingProviderMock{})
+	defer teardown()
+
+	for _, score := range []string{"0", "11"} {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/rating", strings.NewReader(`{"score": `+score+`}`))
+		req = appRatingsTest.contextSetUser(req, &models.User{ID: 3})
+		w := httptest.NewRecorder()
+		appRatingsTest.RateMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		is.Equal(http.StatusUnprocessableEntity, resp.StatusCode)
+		is.Equal(`{"title":"input validations failed","status":422,"detail":"","errors":{"score":"must be between 1 and 10"}}`, string(body))
+	}
+}
+
+func TestApplication_RateMovieHandler_CreatedThenUpdated(t *testing.T) {
+	is := is2.New(t)
+
+	votes := map[int64]int{}
+	mock := provmock.RatingProviderMock{}
+	mock.SetRatingMock = func(userID int64, r *models.Rating) (bool, error) {
+		_, seen := votes[userID]
+		votes[userID] = r.Score
+		return !seen, nil
+	}
+	teardown := setupRatingsTestCase(mock)
+	defer teardown()
+
+	for _, status := range []int{http.StatusCreated, http.StatusOK} {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/rating", strings.NewReader(`{"score": 8}`))
+		req = appRatingsTest.contextSetUser(req, &models.User{ID: 3})
+		w := httptest.NewRecorder()
+		appRatingsTest.RateMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+		resp := w.Result()
+		body, _ := io.ReadAll(resp.Body)
+		is.Equal(status, resp.StatusCode)
+		is.Equal(`{"rating":{"movieId":5,"score":8}}`, string(body))
+	}
+}
+
+func TestApplication_DeleteRatingHandler_NotRated(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.RatingProviderMock{}
+	mock.DeleteRatingMock = func(userID, movieID int64) error {
+		return provider.ErrRecordNotFound
+	}
+	teardown := setupRatingsTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("DELETE", "localhost:8081/v1/movies/5/rating", nil)
+	req = appRatingsTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appRatingsTest.DeleteRatingHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	is.Equal(http.StatusNotFound, w.Result().StatusCode)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"yamda_go/internal/data"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// contentSeeds is how many of the movies a user rated best recommendations
+// like them are looked for.
+const contentSeeds = 5
+
+/**
+* GET /v1/users/me/recommendations -> 200 OK with JSON content
+*
+* Up to ?limit= (default 20) movies the user hasn't rated. They come from the
+* collaborative model first, then from movies alike the ones the user rated
+* best and finally from the best rated movies, so that users who rated few
+* movies, or none, still get some.
+**/
+func (app *Application) RecommendationsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	v := validator.New()
+	limit := app.readInt(r.URL.Query(), "limit", 20, v)
+	v.Check(limit >= 1 && limit <= 50, "limit", "must be between 1 and 50")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	rated, err := app.ratingProvider.GetAllForUser(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	recs := &recommendations{limit: limit, seen: make(map[int64]bool)}
+	for id := range rated {
+		recs.seen[id] = true
+	}
+	for _, step := range []func(*recommendations, map[int64]int) error{app.collaborative, app.contentBased, app.popular} {
+		if recs.full() {
+			break
+		}
+		if err = step(recs, rated); err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"recommendations": recs.list}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// recommendations collects movies from the sources, each movie once.
+type recommendations struct {
+	limit int
+	seen  map[int64]bool //rated or recommended already
+	list  []*models.Recommendation
+}
+
+func (recs *recommendations) full() bool {
+	return len(recs.list) >= recs.limit
+}
+
+func (recs *recommendations) add(rec *models.Recommendation) {
+	if recs.full() || recs.seen[rec.ID] {
+		return
+	}
+	recs.seen[rec.ID] = true
+	recs.list = append(recs.list, rec)
+}
+
+// collaborative recommends from the trained model, when there is one.
+func (app *Application) collaborative(recs *recommendations, rated map[int64]int) error {
+	hits := app.recommender.Recommend(rated, recs.limit)
+	if len(hits) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(hits))
+	for i, h := range hits {
+		ids[i] = h.ID
+	}
+	movies, err := app.moviesByID(ids)
+	if err != nil {
+		return err
+	}
+	for _, h := range hits {
+		//movies deleted since the model was trained are skipped
+		if m, ok := movies[h.ID]; ok {
+			recs.add(&models.Recommendation{Movie: m, Source: models.RecommendedCollaborative, PredictedScore: math.Round(h.Score*10) / 10})
+		}
+	}
+	return nil
+}
+
+// contentBased recommends movies alike the ones the user rated best.
+func (app *Application) contentBased(recs *recommendations, rated map[int64]int) error {
+	seeds := make([]int64, 0, len(rated))
+	for id := range rated {
+		seeds = append(seeds, id)
+	}
+	sort.Slice(seeds, func(i, j int) bool {
+		if rated[seeds[i]] != rated[seeds[j]] {
+			return rated[seeds[i]] > rated[seeds[j]]
+		}
+		return seeds[i] < seeds[j]
+	})
+	if len(seeds) > contentSeeds {
+		seeds = seeds[:contentSeeds]
+	}
+
+	var candidates []*models.SimilarMovie
+	for _, id := range seeds {
+		similar, err := app.similarMovies.Similar(id, recs.limit)
+		if errors.Is(err, provider.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		candidates = append(candidates, similar...)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+	for _, c := range candidates {
+		recs.add(&models.Recommendation{Movie: c.Movie, Source: models.RecommendedContent})
+	}
+	return nil
+}
+
+// popular recommends the best rated movies.
+func (app *Application) popular(recs *recommendations, _ map[int64]int) error {
+	//enough to fill the list even if the user rated the best ones already
+	size := recs.limit + len(recs.seen)
+	if size > 100 {
+		size = 100
+	}
+	movies, _, err := app.movieProvider.GetAll(data.Search{
+		GenresMatch: data.GenresMatchAny,
+		Filters:     data.Filter{Page: 1, PageSize: size, Sort: "-rating", SortSafelist: []string{"-rating"}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range movies {
+		recs.add(&models.Recommendation{Movie: m, Source: models.RecommendedPopular})
+	}
+	return nil
+}
+
+// moviesByID loads the movies, by id.
+func (app *Application) moviesByID(ids []int64) (map[int64]*models.Movie, error) {
+	movies, _, err := app.movieProvider.GetAll(data.Search{
+		IDs:         ids,
+		GenresMatch: data.GenresMatchAny,
+		Filters:     data.Filter{Page: 1, PageSize: len(ids), Sort: "id", SortSafelist: []string{"id"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*models.Movie, len(movies))
+	for _, m := range movies {
+		byID[m.ID] = m
+	}
+	return byID, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/recommend"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appRecommendationsTest *Application = nil
+
+func setupRecommendationsTestCase(ratings provmock.RatingProviderMock, movies provmock.MovieProviderMock, similar provmock.SimilarMovieProviderMock, m *recommend.Model) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appRecommendationsTest = &Application{
+		logger:         logger,
+		config:         cfg,
+		ratingProvider: ratings,
+		movieProvider:  movies,
+		similarMovies:  similar,
+		recommender:    recommend.NewRecommenderWith(m),
+	}
+	return func() {
+		//some teardown
+		appRecommendationsTest = nil
+	}
+}
+
+// recommendationsMovies returns movies 1 to 5 by id, or the best rated 5, 4, 3, 2, 1.
+func recommendationsMovies() provmock.MovieProviderMock {
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		var movies []*models.Movie
+		if len(s.IDs) > 0 {
+			for _, id := range s.IDs {
+				movies = append(movies, &models.Movie{ID: id, Title: "movie"})
+			}
+			return movies, &models.Metadata{}, nil
+		}
+		for id := int64(5); id >= 1; id-- {
+			movies = append(movies, &models.Movie{ID: id, Title: "movie"})
+		}
+		return movies, &models.Metadata{}, nil
+	}
+	return mock
+}
+
+func getRecommendations(t *testing.T, url string) []models.Recommendation {
+	is := is2.New(t)
+	req := httptest.NewRequest("GET", url, nil)
+	req = appRecommendationsTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appRecommendationsTest.RecommendationsHandler(w, req, httprouter.Params{})
+
+	resp := w.Result()
+	is.Equal(http.StatusOK, resp.StatusCode)
+	var body struct {
+		Recommendations []models.Recommendation `json:"recommendations"`
+	}
+	is.NoErr(json.NewDecoder(resp.Body).Decode(&body))
+	return body.Recommendations
+}
+
+func TestApplication_RecommendationsHandler(t *testing.T) {
+	is := is2.New(t)
+
+	ratings := provmock.RatingProviderMock{}
+	ratings.GetRatingsForUserMock = func(id int64) (map[int64]int, error) {
+		is.Equal(id, int64(3))
+		return map[int64]int{1: 9, 2: 4}, nil
+	}
+	similar := provmock.SimilarMovieProviderMock{}
+	similar.SimilarMock = func(id int64, limit int) ([]*models.SimilarMovie, error) {
+		switch id {
+		case 1:
+			return []*models.SimilarMovie{
+				{Movie: &models.Movie{ID: 2}, Similarity: 0.9},
+				{Movie: &models.Movie{ID: 4}, Similarity: 0.5},
+			}, nil
+		default:
+			return []*models.SimilarMovie{}, nil
+		}
+	}
+	model := &recommend.Model{Neighbors: map[int64][]recommend.Neighbor{1: {{ID: 3, Similarity: 0.8}}}}
+	teardown := setupRecommendationsTestCase(ratings, recommendationsMovies(), similar, model)
+	defer teardown()
+
+	//collaborative first, then alike the best rated movie, then the best rated
+	recs := getRecommendations(t, "localhost:8081/v1/users/me/recommendations?limit=3")
+	is.Equal(len(recs), 3)
+	is.Equal(recs[0].ID, int64(3))
+	is.Equal(recs[0].Source, models.RecommendedCollaborative)
+	is.True(recs[0].PredictedScore > 0)
+	is.Equal(recs[1].ID, int64(4))
+	is.Equal(recs[1].Source, models.RecommendedContent)
+	is.Equal(recs[2].ID, int64(5))
+	is.Equal(recs[2].Source, models.RecommendedPopular)
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/users/me/recommendations?limit=0", nil)
+	req = appRecommendationsTest.contextSetUser(req, &models.User{ID: 3})
+	w := httptest.NewRecorder()
+	appRecommendationsTest.RecommendationsHandler(w, req, httprouter.Params{})
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestApplication_RecommendationsHandler_ColdStart(t *testing.T) {
+	is := is2.New(t)
+
+	ratings := provmock.RatingProviderMock{}
+	ratings.GetRatingsForUserMock = func(int64) (map[int64]int, error) {
+		return map[int64]int{}, nil
+	}
+	//no trained model and no ratings: the best rated movies
+	teardown := setupRecommendationsTestCase(ratings, recommendationsMovies(), provmock.SimilarMovieProviderMock{}, nil)
+	defer teardown()
+
+	recs := getRecommendations(t, "localhost:8081/v1/users/me/recommendations")
+	is.Equal(len(recs), 5)
+	for i, r := range recs {
+		is.Equal(r.ID, int64(5-i))
+		is.Equal(r.Source, models.RecommendedPopular)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/movies/:id/relations -> 200 OK with JSON content
+*
+* The movies related to the movie, either way, each with what it is to the
+* movie: sequel, prequel, remake, original, spin_off or parent.
+**/
+func (app *Application) ListRelationsHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	related, err := app.relationProvider.Related(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	movies, ok := related[id]
+	if !ok {
+		movies = []*models.RelatedMovie{}
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"related": movies}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PUT /v1/movies/:id/relations/:related_id -> 200 OK with JSON content
+*
+* {"kind": "sequel_of"}, also remake_of or spin_off_of: the movie is a sequel
+* of the related one. Replaces the relation of the two movies, if any.
+**/
+func (app *Application) SetRelationHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+	relatedID, err := app.parseIdParam(p, "related_id")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Kind string `json:"kind"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	relation := &models.Relation{MovieID: id, RelatedID: relatedID, Kind: input.Kind}
+	v := validator.New()
+	if relation.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err = app.relationProvider.Set(relation); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d or %d not found", id, relatedID))
+		case errors.Is(err, provider.ErrRelationCycle):
+			v.AddError("relatedId", fmt.Sprintf("movie with id %d already follows movie with id %d", relatedID, id))
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"relation": relation}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/movies/:id/relations/:related_id -> 200 OK
+**/
+func (app *Application) DeleteRelationHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+	relatedID, err := app.parseIdParam(p, "related_id")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	if err = app.relationProvider.Delete(id, relatedID); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("relation of movie with id %d to %d not found", id, relatedID))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
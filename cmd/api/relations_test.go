@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appRelationsTest *Application = nil
+
+func setupRelationsTestCase(relations provmock.RelationProviderMock, collections provmock.CollectionProviderMock, movies provmock.MovieProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appRelationsTest = &Application{
+		logger:             logger,
+		config:             cfg,
+		movieProvider:      movies,
+		collectionProvider: collections,
+		relationProvider:   relations,
+	}
+	return func() {
+		//some teardown
+		appRelationsTest = nil
+	}
+}
+
+func TestApplication_SetRelationHandler(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.RelationProviderMock{}
+	mock.SetRelationMock = func(r *models.Relation) error {
+		if r.RelatedID == 3 {
+			return provider.ErrRelationCycle
+		}
+		is.Equal(*r, models.Relation{MovieID: 2, RelatedID: 1, Kind: models.RelationSequelOf})
+		return nil
+	}
+	teardown := setupRelationsTestCase(mock, provmock.CollectionProviderMock{}, provmock.MovieProviderMock{})
+	defer teardown()
+
+	put := func(id, relatedID, content string) (int, string) {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/"+id+"/relations/"+relatedID, strings.NewReader(content))
+		w := httptest.NewRecorder()
+		appRelationsTest.SetRelationHandler(w, req, httprouter.Params{{Key: "id", Value: id}, {Key: "related_id", Value: relatedID}})
+		body, _ := io.ReadAll(w.Result().Body)
+		return w.Result().StatusCode, strings.TrimSpace(string(body))
+	}
+
+	status, body := put("2", "1", `{"kind": "sequel_of"}`)
+	is.Equal(http.StatusOK, status)
+	is.Equal(`{"relation":{"movieId":2,"relatedId":1,"kind":"sequel_of"}}`, body)
+
+	status, body = put("2", "3", `{"kind": "sequel_of"}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	is.True(strings.Contains(body, "movie with id 3 already follows movie with id 2"))
+
+	status, _ = put("2", "2", `{"kind": "sequel_of"}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	status, _ = put("2", "1", `{"kind": "prequel_of"}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+}
+
+func TestApplication_GetMovieHandler_CollectionAndRelated(t *testing.T) {
+	is := is2.New(t)
+
+	relations := provmock.RelationProviderMock{}
+	relations.RelatedMock = func(ids ...int64) (map[int64][]*models.RelatedMovie, error) {
+		is.Equal(ids, []int64{2})
+		return map[int64][]*models.RelatedMovie{2: {
+			{ID: 1, Title: "The Godfather", Year: 1972, Role: "prequel"},
+			{ID: 3, Title: "The Godfather Part III", Year: 1990, Role: "sequel"},
+		}}, nil
+	}
+	collections := provmock.CollectionProviderMock{}
+	collections.CollectionsForMoviesMock = func(ids ...int64) (map[int64]*models.CollectionSummary, error) {
+		return map[int64]*models.CollectionSummary{2: {ID: 4, Name: "The Godfather trilogy", Position: 2, MovieCount: 3}}, nil
+	}
+	movies := provmock.MovieProviderMock{}
+	movies.GetMovieMock = func(id int64) (*models.Movie, error) {
+		return &models.Movie{ID: 2, Title: "The Godfather Part II", Year: 1974, Version: 1}, nil
+	}
+	teardown := setupRelationsTestCase(relations, collections, movies)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies/2?fields=id,title,collection,related", nil)
+	w := httptest.NewRecorder()
+	appRelationsTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "2"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	expected := `{"movie":{"id":2,"title":"The Godfather Part II",` +
+		`"collection":{"id":4,"name":"The Godfather trilogy","position":2,"movieCount":3},` +
+		`"related":[{"id":1,"title":"The Godfather","year":1972,"relation":"prequel"},{"id":3,"title":"The Godfather Part III","year":1990,"relation":"sequel"}]}}`
+	is.Equal(expected, string(body))
+}
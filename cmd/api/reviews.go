@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/data"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/movies/:id/reviews -> 200 OK with JSON content
+*
+* Approved reviews, the most helpful first. Moderators can list the other
+* states with ?status=pending or ?status=rejected.
+**/
+func (app *Application) ListReviewsHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	qs := r.URL.Query()
+	input := data.ReviewSearch{
+		MovieID: id,
+		Status:  app.readString(qs, "status", models.ReviewApproved),
+		Filters: data.Filter{
+			Page:         app.readInt(qs, "page", 1, v),
+			PageSize:     app.readInt(qs, "page_size", 20, v),
+			Sort:         app.readString(qs, "sort", "-helpful"),
+			SortSafelist: []string{"id", "helpful", "created_at", "-id", "-helpful", "-created_at"},
+		},
+	}
+	if input.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	moderator, err := app.userHasPermission(r, models.PermissionModerateReviews)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if input.Status != models.ReviewApproved && !moderator {
+		app.notPermittedResponse(w)
+		return
+	}
+
+	reviews, meta, err := app.reviewProvider.GetAll(input)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if !moderator {
+		for _, review := range reviews {
+			review.Flags = nil
+		}
+	}
+
+	headers := make(http.Header)
+	if links := app.paginationLinks(r.URL, meta); links != "" {
+		headers.Set("Link", links)
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"metadata": meta, "reviews": reviews}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/movies/:id/reviews -> 201 CREATED with JSON content
+*
+* {"body": "..."}
+* Reviews wait for a moderator before being listed. Those the pre-screen flags
+* (profanity, links) are marked for the moderators.
+**/
+func (app *Application) CreateReviewHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Body string `json:"body"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	review := &models.Review{
+		MovieID: id,
+		UserID:  app.contextGetUser(r).ID,
+		Body:    strings.TrimSpace(input.Body),
+		Status:  models.ReviewPending,
+	}
+	v := validator.New()
+	if review.Validate(v); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+	review.Flags = app.screener.Screen(review.Body)
+
+	if _, err = app.reviewProvider.Insert(review); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		case errors.Is(err, provider.ErrDuplicateReview):
+			v.AddError("review", "you already reviewed this movie")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	//the outcome of the pre-screen is for the moderators only
+	review.Flags = nil
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/movies/%d/reviews/%d", id, review.ID))
+	if err = app.writeJSON(w, http.StatusCreated, envelope{"review": review}, headers); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* GET /v1/movies/:id/reviews/:review_id -> 200 OK with JSON content
+*
+* Reviews not approved yet are only shown to their author and to moderators.
+**/
+func (app *Application) GetReviewHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	review, ok := app.readReview(w, p)
+	if !ok {
+		return
+	}
+
+	moderator, err := app.userHasPermission(r, models.PermissionModerateReviews)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	user := app.contextGetUser(r)
+	author := user != nil && user.ID == review.UserID
+	if review.Status != models.ReviewApproved && !author && !moderator {
+		app.resourceNotFoundResponse(w, fmt.Errorf("review with id %d not found", review.ID))
+		return
+	}
+	if !moderator {
+		review.Flags = nil
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"review": review}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PATCH /v1/movies/:id/reviews/:review_id -> 200 OK with JSON content
+*
+* {"status": "approved"}
+* Moderators approve or reject pending reviews. The version, when given, must
+* match the current one.
+**/
+func (app *Application) ModerateReviewHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	review, ok := app.readReview(w, p)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Status  string `json:"status"`
+		Version *int   `json:"version"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(validator.In(input.Status, models.ReviewApproved, models.ReviewRejected), "status", "must be approved or rejected")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+	if input.Version != nil && *input.Version != review.Version {
+		app.resourceEditConflictResponse(w)
+		return
+	}
+	if err := review.Transition(input.Status); err != nil {
+		app.resourceConflictResponse(w, err)
+		return
+	}
+
+	if err := app.reviewProvider.Moderate(review, app.contextGetUser(r).ID); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err := app.writeJSON(w, http.StatusOK, envelope{"review": review}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PUT /v1/movies/:id/reviews/:review_id/helpful -> 200 OK with JSON content
+* DELETE /v1/movies/:id/reviews/:review_id/helpful -> 200 OK with JSON content
+*
+* Users vote approved reviews of others as helpful, once each.
+**/
+func (app *Application) HelpfulReviewHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	review, ok := app.readReview(w, p)
+	if !ok {
+		return
+	}
+	if review.Status != models.ReviewApproved {
+		app.resourceNotFoundResponse(w, fmt.Errorf("review with id %d not found", review.ID))
+		return
+	}
+
+	user := app.contextGetUser(r)
+	if review.UserID == user.ID {
+		v := validator.New()
+		v.AddError("review", "you can't vote for your own review")
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err := app.reviewProvider.SetHelpful(review.ID, user.ID, r.Method == http.MethodPut); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	review, err := app.reviewProvider.Get(review.ID)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	review.Flags = nil
+	if err = app.writeJSON(w, http.StatusOK, envelope{"review": review}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// readReview loads the review of the :review_id parameter, which must belong
+// to the movie of the :id parameter, writing the error response when it can't.
+func (app *Application) readReview(w http.ResponseWriter, p httprouter.Params) (*models.Review, bool) {
+	movieID, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+	id, err := app.parseIdParam(p, "review_id")
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return nil, false
+	}
+
+	review, err := app.reviewProvider.Get(id)
+	if err == nil && review.MovieID != movieID {
+		err = provider.ErrRecordNotFound
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("review with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return nil, false
+	}
+	return review, true
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/moderation"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appReviewsTest *Application = nil
+
+// setupReviewsTestCase creates the app, the users in moderators being granted
+// the moderation permission.
+func setupReviewsTestCase(p provmock.ReviewProviderMock, moderators ...int64) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appReviewsTest = &Application{
+		logger:         logger,
+		config:         cfg,
+		reviewProvider: p,
+		screener:       moderation.NewScreener([]string{"crap"}),
+		permissions: provmock.PermissionProviderMock{
+			GetAllForUserMock: func(userID int64) (models.Permissions, error) {
+				for _, id := range moderators {
+					if id == userID {
+						return models.Permissions{models.PermissionModerateReviews}, nil
+					}
+				}
+				return models.Permissions{}, nil
+			},
+		},
+	}
+	return func() {
+		//some teardown
+		appReviewsTest = nil
+	}
+}
+
+var reviewParams = httprouter.Params{{Key: "id", Value: "5"}, {Key: "review_id", Value: "9"}}
+
+func TestApplication_CreateReviewHandler_InactiveUser(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupReviewsTestCase(provmock.ReviewProviderMock{})
+	defer teardown()
+
+	req := httptest.NewRequest("POST", "localhost:8081/v1/movies/5/reviews", strings.NewReader(`{"body": "A quiet and moving film."}`))
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 3, Activated: false})
+	w := httptest.NewRecorder()
+	appReviewsTest.requireActivatedUser(appReviewsTest.CreateReviewHandler)(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	is.Equal(http.StatusForbidden, w.Result().StatusCode)
+}
+
+func TestApplication_CreateReviewHandler_PendingAndScreened(t *testing.T) {
+	is := is2.New(t)
+
+	var saved models.Review
+	mock := provmock.ReviewProviderMock{}
+	mock.CreateReviewMock = func(r *models.Review) (*models.Review, error) {
+		r.ID, r.Version = 9, 1
+		saved = *r
+		return r, nil
+	}
+	teardown := setupReviewsTestCase(mock)
+	defer teardown()
+
+	content := `{"body": "Total crap, watch something else at www.other-movies.com"}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/movies/5/reviews", strings.NewReader(content))
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 3, Activated: true})
+	w := httptest.NewRecorder()
+	appReviewsTest.CreateReviewHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusCreated, resp.StatusCode)
+	is.Equal("/v1/movies/5/reviews/9", resp.Header.Get("Location"))
+	is.Equal(saved.Status, models.ReviewPending)
+	is.Equal(saved.Flags, []string{moderation.FlagProfanity, moderation.FlagLink})
+	//flags are for moderators only
+	is.Equal(`{"review":{"id":9,"movieId":5,"userId":3,"body":"Total crap, watch something else at www.other-movies.com","status":"pending","helpful":0,"version":1}}`, string(body))
+}
+
+func TestApplication_ListReviewsHandler_PendingNeedsModerator(t *testing.T) {
+	is := is2.New(t)
+
+	var got data.ReviewSearch
+	mock := provmock.ReviewProviderMock{}
+	mock.GetAllReviewsMock = func(s data.ReviewSearch) ([]*models.Review, *models.Metadata, error) {
+		got = s
+		meta := models.New(0, 1, 20)
+		return []*models.Review{}, &meta, nil
+	}
+	teardown := setupReviewsTestCase(mock, 1)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies/5/reviews?status=pending", nil)
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 3, Activated: true})
+	w := httptest.NewRecorder()
+	appReviewsTest.ListReviewsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+	is.Equal(http.StatusForbidden, w.Result().StatusCode)
+
+	req = httptest.NewRequest("GET", "localhost:8081/v1/movies/5/reviews?status=pending", nil)
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 1, Activated: true})
+	w = httptest.NewRecorder()
+	appReviewsTest.ListReviewsHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+	is.Equal(http.StatusOK, w.Result().StatusCode)
+	is.Equal(got.Status, models.ReviewPending)
+	is.Equal(got.Filters.Sort, "-helpful") //most helpful first
+}
+
+func TestApplication_ModerateReviewHandler_OnlyFromPending(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.ReviewProviderMock{}
+	mock.GetReviewMock = func(id int64) (*models.Review, error) {
+		return &models.Review{ID: id, MovieID: 5, UserID: 3, Status: models.ReviewApproved, Version: 2}, nil
+	}
+	teardown := setupReviewsTestCase(mock, 1)
+	defer teardown()
+
+	req := httptest.NewRequest("PATCH", "localhost:8081/v1/movies/5/reviews/9", strings.NewReader(`{"status": "rejected"}`))
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 1, Activated: true})
+	w := httptest.NewRecorder()
+	appReviewsTest.requirePermission(models.PermissionModerateReviews, appReviewsTest.ModerateReviewHandler)(w, req, reviewParams)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusConflict, resp.StatusCode)
+	is.True(strings.Contains(string(body), "from approved to rejected"))
+}
+
+func TestApplication_ModerateReviewHandler_NotPermitted(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupReviewsTestCase(provmock.ReviewProviderMock{}, 1)
+	defer teardown()
+
+	req := httptest.NewRequest("PATCH", "localhost:8081/v1/movies/5/reviews/9", strings.NewReader(`{"status": "approved"}`))
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 3, Activated: true})
+	w := httptest.NewRecorder()
+	appReviewsTest.requirePermission(models.PermissionModerateReviews, appReviewsTest.ModerateReviewHandler)(w, req, reviewParams)
+
+	is.Equal(http.StatusForbidden, w.Result().StatusCode)
+}
+
+func TestApplication_HelpfulReviewHandler_OwnReview(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.ReviewProviderMock{}
+	mock.GetReviewMock = func(id int64) (*models.Review, error) {
+		return &models.Review{ID: id, MovieID: 5, UserID: 3, Status: models.ReviewApproved, Version: 2}, nil
+	}
+	teardown := setupReviewsTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/5/reviews/9/helpful", nil)
+	req = appReviewsTest.contextSetUser(req, &models.User{ID: 3, Activated: true})
+	w := httptest.NewRecorder()
+	appReviewsTest.HelpfulReviewHandler(w, req, reviewParams)
+
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
@@ -2,24 +2,170 @@ package main
 
 import (
 	"net/http"
+	"strings"
+	"yamda_go/internal/models"
 
 	"github.com/julienschmidt/httprouter"
 )
 
 func (app *Application) routes() http.Handler {
 	router := httprouter.New()
-	router.HandlerFunc(http.MethodGet, "/v1/healthcheck", app.HealthCheckHandler)
-	router.Handle(http.MethodPost, "/v1/movies", app.CreateMovieHandler)
-	router.Handle(http.MethodGet, "/v1/movies/:id", app.GetMovieHandler)
-	router.Handle(http.MethodPatch, "/v1/movies", app.UpdateMovieHandler)
-	router.Handle(http.MethodDelete, "/v1/movies/:id", app.DeleteMovieHandler)
+	app.routeTable = make(routeTable)
 
-	router.Handle(http.MethodPatch, "/v1/movies/:id", app.PartialUpdateMovieHandler)
+	//register each route in the router and remember its pattern
+	handle := func(method, pattern string, h httprouter.Handle) {
+		app.routeTable.add(method, pattern)
+		router.Handle(method, pattern, h)
+	}
+	handleFunc := func(method, pattern string, h http.HandlerFunc) {
+		app.routeTable.add(method, pattern)
+		router.HandlerFunc(method, pattern, h)
+	}
 
-	router.HandlerFunc(http.MethodGet, "/v1/movies", app.ListMoviesHandler)
+	handleFunc(http.MethodGet, "/v1/healthcheck", app.HealthCheckHandler)
+	handle(http.MethodPost, "/v1/movies", app.CreateMovieHandler)
+	handle(http.MethodGet, "/v1/movies/:id", app.subroutes(http.MethodGet, "/v1/movies/", map[string]http.HandlerFunc{
+		"search":       app.SearchMoviesHandler,
+		"autocomplete": app.AutocompleteMoviesHandler,
+		"trending":     app.TrendingMoviesHandler,
+	}, app.GetMovieHandler))
+	handle(http.MethodPatch, "/v1/movies", app.UpdateMovieHandler)
+	handle(http.MethodDelete, "/v1/movies/:id", app.DeleteMovieHandler)
 
-	router.Handle(http.MethodPost, "/v1/users", app.RegisterUserHandler)
+	handle(http.MethodPatch, "/v1/movies/:id", app.PartialUpdateMovieHandler)
+
+	handleFunc(http.MethodGet, "/v1/movies", app.ListMoviesHandler)
+
+	handle(http.MethodGet, "/v1/movies/:id/similar", app.SimilarMoviesHandler)
+	handle(http.MethodGet, "/v1/movies/:id/relations", app.ListRelationsHandler)
+	handle(http.MethodPut, "/v1/movies/:id/relations/:related_id", app.SetRelationHandler)
+	handle(http.MethodDelete, "/v1/movies/:id/relations/:related_id", app.DeleteRelationHandler)
+	handle(http.MethodGet, "/v1/movies/:id/translations", app.ListTranslationsHandler)
+	handle(http.MethodPut, "/v1/movies/:id/translations/:language", app.SetTranslationHandler)
+	handle(http.MethodDelete, "/v1/movies/:id/translations/:language", app.DeleteTranslationHandler)
+	handle(http.MethodGet, "/v1/movies/:id/credits", app.ListCreditsHandler)
+	handle(http.MethodPut, "/v1/movies/:id/credits", app.SetCreditsHandler)
+	handle(http.MethodPut, "/v1/movies/:id/rating", app.requireAuthenticatedUser(app.RateMovieHandler))
+	handle(http.MethodDelete, "/v1/movies/:id/rating", app.requireAuthenticatedUser(app.DeleteRatingHandler))
+
+	handle(http.MethodGet, "/v1/movies/:id/reviews", app.ListReviewsHandler)
+	handle(http.MethodPost, "/v1/movies/:id/reviews", app.requireActivatedUser(app.CreateReviewHandler))
+	handle(http.MethodGet, "/v1/movies/:id/reviews/:review_id", app.GetReviewHandler)
+	handle(http.MethodPatch, "/v1/movies/:id/reviews/:review_id", app.requirePermission(models.PermissionModerateReviews, app.ModerateReviewHandler))
+	handle(http.MethodPut, "/v1/movies/:id/reviews/:review_id/helpful", app.requireActivatedUser(app.HelpfulReviewHandler))
+	handle(http.MethodDelete, "/v1/movies/:id/reviews/:review_id/helpful", app.requireActivatedUser(app.HelpfulReviewHandler))
+
+	handleFunc(http.MethodGet, "/v1/genres", app.ListGenresHandler)
+	handle(http.MethodPost, "/v1/genres", app.CreateGenreHandler)
+	handle(http.MethodGet, "/v1/genres/:id", app.GetGenreHandler)
+	handle(http.MethodPatch, "/v1/genres/:id", app.UpdateGenreHandler)
+	handle(http.MethodDelete, "/v1/genres/:id", app.DeleteGenreHandler)
+
+	handleFunc(http.MethodGet, "/v1/collections", app.ListCollectionsHandler)
+	handle(http.MethodPost, "/v1/collections", app.CreateCollectionHandler)
+	handle(http.MethodGet, "/v1/collections/:id", app.GetCollectionHandler)
+	handle(http.MethodPatch, "/v1/collections/:id", app.UpdateCollectionHandler)
+	handle(http.MethodDelete, "/v1/collections/:id", app.DeleteCollectionHandler)
+	handle(http.MethodPut, "/v1/collections/:id/movies", app.SetCollectionMoviesHandler)
+
+	handleFunc(http.MethodGet, "/v1/people", app.ListPeopleHandler)
+	handle(http.MethodPost, "/v1/people", app.CreatePersonHandler)
+	handle(http.MethodGet, "/v1/people/:id", app.GetPersonHandler)
+	handle(http.MethodPatch, "/v1/people/:id", app.UpdatePersonHandler)
+	handle(http.MethodDelete, "/v1/people/:id", app.DeletePersonHandler)
+	handle(http.MethodGet, "/v1/people/:id/filmography", app.FilmographyHandler)
+
+	handle(http.MethodPost, "/v1/users", app.RegisterUserHandler)
+	handle(http.MethodPut, "/v1/users/activated", app.ActivateUserHandler)
+	handle(http.MethodPost, "/v1/tokens/authentication", app.CreateAuthenticationTokenHandler)
+	handle(http.MethodPost, "/v1/tokens/activation", app.CreateActivationTokenHandler)
+
+	handle(http.MethodGet, "/v1/users/me/lists", app.requireAuthenticatedUser(app.ListMyListsHandler))
+	handle(http.MethodPost, "/v1/users/me/lists", app.requireAuthenticatedUser(app.CreateListHandler))
+	handle(http.MethodGet, "/v1/users/me/lists/:id", app.requireAuthenticatedUser(app.GetMyListHandler))
+	handle(http.MethodPatch, "/v1/users/me/lists/:id", app.requireAuthenticatedUser(app.UpdateListHandler))
+	handle(http.MethodDelete, "/v1/users/me/lists/:id", app.requireAuthenticatedUser(app.DeleteListHandler))
+	handle(http.MethodPost, "/v1/users/me/lists/:id/items", app.requireAuthenticatedUser(app.AddListItemHandler))
+	handle(http.MethodPatch, "/v1/users/me/lists/:id/items/:movie_id", app.requireAuthenticatedUser(app.UpdateListItemHandler))
+	handle(http.MethodDelete, "/v1/users/me/lists/:id/items/:movie_id", app.requireAuthenticatedUser(app.RemoveListItemHandler))
+	handle(http.MethodPut, "/v1/users/me/lists/:id/order", app.requireAuthenticatedUser(app.ReorderListHandler))
+	handle(http.MethodPost, "/v1/users/me/history", app.requireAuthenticatedUser(app.LogWatchHandler))
+	handle(http.MethodGet, "/v1/users/me/stats", app.requireAuthenticatedUser(app.WatchStatsHandler))
+	handle(http.MethodGet, "/v1/users/me/recommendations", app.requireAuthenticatedUser(app.RecommendationsHandler))
+	handleFunc(http.MethodGet, "/v1/lists", app.ListPublicListsHandler)
+	handle(http.MethodGet, "/v1/lists/:slug", app.GetSharedListHandler)
 
 	//ensure middleware is always called last
-	return app.recoverPanic(app.rateLimit(router))
+	return app.recoverPanic(app.logRequest(app.authenticate(app.rateLimit(app.shedLoad(router)))))
+}
+
+// priorityRoutes are never shed by the concurrency limiter, so that we can still
+// tell how the server is doing while it is overloaded.
+var priorityRoutes = map[string]bool{
+	"GET /v1/healthcheck": true,
+}
+
+// subroutes dispatches static paths that share their position with a parameter,
+// like /v1/movies/search next to /v1/movies/:id. httprouter refuses to register
+// both, so the static ones are served from the parameterized route instead.
+// They are still added to the route table, so middleware can tell them apart.
+func (app *Application) subroutes(method, prefix string, static map[string]http.HandlerFunc, next httprouter.Handle) httprouter.Handle {
+	for name := range static {
+		app.routeTable.add(method, prefix+name)
+	}
+	return func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		if len(p) > 0 {
+			if h, ok := static[p[len(p)-1].Value]; ok {
+				h(w, r)
+				return
+			}
+		}
+		next(w, r, p)
+	}
+}
+
+// routeTable keeps the patterns registered in the router, by method, so that
+// middleware running in front of the router can tell which route a request hits.
+type routeTable map[string][]string
+
+func (t routeTable) add(method, pattern string) {
+	t[method] = append(t[method], pattern)
+}
+
+// match returns the route ("METHOD /pattern") the given request path resolves to,
+// or an empty string if no route matches. Static segments win over parameters.
+func (t routeTable) match(method, path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	best, bestScore := "", -1
+	for _, pattern := range t[method] {
+		if score, ok := matchPattern(strings.Split(strings.Trim(pattern, "/"), "/"), segments); ok && score > bestScore {
+			best, bestScore = method+" "+pattern, score
+		}
+	}
+	return best
+}
+
+// matchPattern reports whether the path segments match the pattern segments, and
+// how many of them matched statically.
+func matchPattern(pattern, segments []string) (int, bool) {
+	score := 0
+	for i, p := range pattern {
+		if strings.HasPrefix(p, "*") {
+			return score, true
+		}
+		if i >= len(segments) {
+			return 0, false
+		}
+		switch {
+		case strings.HasPrefix(p, ":"):
+			if segments[i] == "" {
+				return 0, false
+			}
+		case p == segments[i]:
+			score++
+		default:
+			return 0, false
+		}
+	}
+	return score, len(pattern) == len(segments)
 }
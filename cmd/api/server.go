@@ -10,6 +10,8 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/ratelimit"
 )
 
 func (app *Application) serve() error {
@@ -27,19 +29,57 @@ func (app *Application) serve() error {
 
 	shutdown := make(chan error)
 
+	//background work lives as long as the server does.
+	ctx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	//forget about clients that haven't been seen for a while.
+	if store, ok := app.limiterStore.(*ratelimit.MemoryStore); ok {
+		go store.Cleanup(ctx, time.Minute, 3*time.Minute)
+	}
+
+	//catch up with the changes the writes to movies don't see, like credits.
+	if movies, ok := app.similarMovies.(*provider.SimilarMovieProvider); ok && app.config.SimilarRefreshMinutes > 0 {
+		go movies.Refresh(ctx, time.Duration(app.config.SimilarRefreshMinutes)*time.Minute, app.logger)
+	}
+
+	//swap in the recommendation model whenever it is trained again.
+	if app.recommender != nil && app.config.RecommendReloadSeconds > 0 {
+		go app.recommender.Watch(ctx, time.Duration(app.config.RecommendReloadSeconds)*time.Second, app.logger)
+	}
+
+	//save the events in batches, and keep popularity up to date with them.
+	if app.activity != nil && app.config.ActivityFlushSeconds > 0 {
+		go app.activity.Run(ctx, time.Duration(app.config.ActivityFlushSeconds)*time.Second, app.logger)
+	}
+	if activities, ok := app.activityProvider.(*provider.ActivityProvider); ok && app.config.PopularityRefreshMinutes > 0 {
+		go activities.Refresh(ctx, time.Duration(app.config.PopularityRefreshMinutes)*time.Minute, app.logger)
+	}
+
 	go func() {
 		//contains os signals to handle graceful shutdown
 		quit := make(chan os.Signal, 1) //buffered channel
 
 		//specify which signals to handle
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
 		s := <-quit //block until signal arrives
 
+		//SIGHUP only asks us to reopen the log file (logrotate compatibility).
+		for s == syscall.SIGHUP {
+			if err := app.logger.Reopen(); err != nil {
+				app.logger.PrintError(err, nil)
+			}
+			app.logger.PrintInfo("reopened log output", nil)
+			s = <-quit
+		}
+
 		app.logger.PrintInfo("caught signal", map[string]string{
 			"signal": s.String(),
 		})
 
+		stopBackground()
+
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
@@ -72,6 +112,11 @@ func (app *Application) serve() error {
 		return err
 	}
 
+	//no request records events anymore, save the last ones.
+	if err = app.activity.Flush(); err != nil {
+		app.logger.PrintError(err, nil)
+	}
+
 	// Log the "server stopped" message.
 	app.logger.PrintInfo("server stopped", map[string]string{
 		"addr": srv.Addr,
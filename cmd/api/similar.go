@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+/**
+* GET /v1/movies/:id/similar -> 200 OK with JSON content
+*
+* "More like this": movies sharing genres, cast and crew with the movie, and
+* close to it in year and runtime, the most similar first. ?limit= defaults
+* to 10.
+**/
+func (app *Application) SimilarMoviesHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	limit := app.readInt(r.URL.Query(), "limit", 10, v)
+	v.Check(limit >= 1 && limit <= 50, "limit", "must be between 1 and 50")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	movies, err := app.similarMovies.Similar(id, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"movies": movies}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
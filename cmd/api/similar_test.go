@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appSimilarTest *Application = nil
+
+func setupSimilarTestCase(p provmock.SimilarMovieProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appSimilarTest = &Application{
+		logger:        logger,
+		config:        cfg,
+		similarMovies: p,
+	}
+	return func() {
+		//some teardown
+		appSimilarTest = nil
+	}
+}
+
+func TestApplication_SimilarMoviesHandler(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.SimilarMovieProviderMock{}
+	mock.SimilarMock = func(id int64, limit int) ([]*models.SimilarMovie, error) {
+		if id != 5 {
+			return nil, provider.ErrRecordNotFound
+		}
+		is.Equal(limit, 10)
+		return []*models.SimilarMovie{{Movie: &models.Movie{ID: 6, Title: "Aliens", Year: 1986, Version: 1}, Similarity: 0.75}}, nil
+	}
+	teardown := setupSimilarTestCase(mock)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies/5/similar", nil)
+	w := httptest.NewRecorder()
+	appSimilarTest.SimilarMoviesHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(`{"movies":[{"id":6,"title":"Aliens","year":1986,"version":1,"similarity":0.75}]}`, strings.TrimSpace(string(body)))
+
+	req = httptest.NewRequest("GET", "localhost:8081/v1/movies/7/similar", nil)
+	w = httptest.NewRecorder()
+	appSimilarTest.SimilarMoviesHandler(w, req, httprouter.Params{{Key: "id", Value: "7"}})
+	is.Equal(http.StatusNotFound, w.Result().StatusCode)
+}
+
+func TestApplication_SimilarMoviesHandler_InvalidLimit(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupSimilarTestCase(provmock.SimilarMovieProviderMock{})
+	defer teardown()
+
+	for _, limit := range []string{"0", "51", "abc"} {
+		req := httptest.NewRequest("GET", "localhost:8081/v1/movies/5/similar?limit="+limit, nil)
+		w := httptest.NewRecorder()
+		appSimilarTest.SimilarMoviesHandler(w, req, httprouter.Params{{Key: "id", Value: "5"}})
+		is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+	}
+}
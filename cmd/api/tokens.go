@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// authenticationTokenTTL is how long users stay logged in.
+const authenticationTokenTTL = 24 * time.Hour
+
+/**
+* POST /v1/tokens/authentication -> 201 CREATED with JSON content
+*
+* {
+*	"email": "alice@example.com",
+*	"password": "pa55word"
+* }
+* Hands out a token to send as "Authorization: Bearer <token>".
+**/
+func (app *Application) CreateAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	models.ValidateEmail(v, input.Email)
+	models.ValidatePasswordPlaintext(v, input.Password)
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	user, err := app.userProvider.GetByEmail(input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEmailNotFound):
+			app.invalidCredentialsResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	match, err := user.Password.Matches(input.Password)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if !match {
+		app.invalidCredentialsResponse(w)
+		return
+	}
+
+	token, err := app.tokenProvider.New(user.ID, authenticationTokenTTL, models.ScopeAuthentication)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusCreated, envelope{"authentication_token": token}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* POST /v1/tokens/activation -> 202 ACCEPTED with JSON content
+*
+* {"email": "alice@example.com"}
+* Emails a new activation token, e.g. when the first one expired.
+**/
+func (app *Application) CreateActivationTokenHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Email string `json:"email"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if models.ValidateEmail(v, input.Email); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	user, err := app.userProvider.GetByEmail(input.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEmailNotFound):
+			v.AddError("email", "no matching email address found")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	if user.Activated {
+		v.AddError("email", "user has already been activated")
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	token, err := app.tokenProvider.New(user.ID, activationTokenTTL, models.ScopeActivation)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	app.sendActivationEmail(user, token)
+
+	env := envelope{"message": "an email will be sent to you containing activation instructions"}
+	if err = app.writeJSON(w, http.StatusAccepted, env, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
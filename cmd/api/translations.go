@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/locale"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// localizeMovies replaces the title of the movies by their translation to the
+// languages accepted by the request, the first of them each movie has, and
+// sets the Content-Language of the response to the languages used.
+// Movies translated to none of them keep the language of the catalogue.
+func (app *Application) localizeMovies(w http.ResponseWriter, r *http.Request, movies ...*models.Movie) error {
+	w.Header().Add("Vary", "Accept-Language")
+
+	base := app.config.CatalogLanguage
+	chain := locale.Chain(r.Header.Get("Accept-Language"), base)
+	best := map[int64]*models.Translation{}
+	if len(chain) > 0 && len(movies) > 0 {
+		ids := make([]int64, len(movies))
+		for i, m := range movies {
+			ids[i] = m.ID
+		}
+		var err error
+		if best, err = app.translationProvider.Best(chain, ids...); err != nil {
+			return err
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, m := range movies {
+		t, ok := best[m.ID]
+		if !ok {
+			used[base] = true
+			continue
+		}
+		m.OriginalTitle, m.Title, m.Overview = m.Title, t.Title, t.Overview
+		used[t.Language] = true
+	}
+	if len(movies) == 0 {
+		used[base] = true
+	}
+
+	//best languages first
+	var languages []string
+	for _, l := range append(chain, base) {
+		if used[l] && l != "" {
+			languages = append(languages, l)
+			used[l] = false
+		}
+	}
+	if len(languages) > 0 {
+		w.Header().Set("Content-Language", strings.Join(languages, ", "))
+	}
+	return nil
+}
+
+/**
+* GET /v1/movies/:id/translations -> 200 OK with JSON content
+**/
+func (app *Application) ListTranslationsHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	translations, err := app.translationProvider.GetAll(id)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	if err = app.writeJSON(w, http.StatusOK, envelope{"translations": translations}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* PUT /v1/movies/:id/translations/:language -> 200 OK with JSON content
+*
+* {"title": "A Viagem de Chihiro", "overview": "..."} for /translations/pt-BR.
+* Replaces the translation of the movie to the language, if any.
+**/
+func (app *Application) SetTranslationHandler(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	var input struct {
+		Title    string `json:"title"`
+		Overview string `json:"overview"`
+	}
+	if err = app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	translation := &models.Translation{MovieID: id, Language: p.ByName("language"), Title: input.Title, Overview: input.Overview}
+	v := validator.New()
+	translation.Validate(v)
+	v.Check(translation.Language != app.config.CatalogLanguage, "language", "must not be the language of the catalogue")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	if err = app.translationProvider.Set(translation); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("movie with id %d not found", id))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"translation": translation}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+/**
+* DELETE /v1/movies/:id/translations/:language -> 200 OK
+**/
+func (app *Application) DeleteTranslationHandler(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	id, err := app.ParseId(p)
+	if err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+	language, err := locale.Canonical(p.ByName("language"))
+	if err != nil {
+		app.badRequestResponse(w, fmt.Errorf("invalid language %q", p.ByName("language")))
+		return
+	}
+
+	if err = app.translationProvider.Delete(id, language); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			app.resourceNotFoundResponse(w, fmt.Errorf("translation of movie with id %d to %s not found", id, language))
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appTranslationsTest *Application = nil
+
+func setupTranslationsTestCase(translations provmock.TranslationProviderMock, movies provmock.MovieProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appTranslationsTest = &Application{
+		logger:              logger,
+		config:              cfg,
+		movieProvider:       movies,
+		collectionProvider:  noCollections,
+		relationProvider:    noRelations,
+		translationProvider: translations,
+	}
+	return func() {
+		//some teardown
+		appTranslationsTest = nil
+	}
+}
+
+func TestApplication_GetMovieHandler_AcceptLanguage(t *testing.T) {
+	is := is2.New(t)
+
+	translations := provmock.TranslationProviderMock{}
+	translations.BestTranslationsMock = func(languages []string, ids ...int64) (map[int64]*models.Translation, error) {
+		//English is the language of the catalogue, no need to go further
+		is.Equal(languages, []string{"pt-BR", "pt"})
+		is.Equal(ids, []int64{7})
+		return map[int64]*models.Translation{7: {MovieID: 7, Language: "pt", Title: "A Viagem de Chihiro", Overview: "Chihiro entra no mundo dos espíritos."}}, nil
+	}
+	movies := provmock.MovieProviderMock{}
+	movies.GetMovieMock = func(id int64) (*models.Movie, error) {
+		return &models.Movie{ID: 7, Title: "Spirited Away", Year: 2001, Version: 1}, nil
+	}
+	teardown := setupTranslationsTestCase(translations, movies)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies/7?fields=id,title,originalTitle,overview", nil)
+	req.Header.Set("Accept-Language", "pt-BR, en;q=0.8, es;q=0.5")
+	w := httptest.NewRecorder()
+	appTranslationsTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "7"}})
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(resp.Header.Get("Content-Language"), "pt")
+	is.Equal(resp.Header.Get("Vary"), "Accept-Language")
+	expected := `{"movie":{"id":7,"title":"A Viagem de Chihiro","originalTitle":"Spirited Away",` +
+		`"overview":"Chihiro entra no mundo dos espíritos."}}`
+	is.Equal(expected, string(body))
+
+	//without a preference movies come as they are
+	req = httptest.NewRequest("GET", "localhost:8081/v1/movies/7?fields=id,title", nil)
+	w = httptest.NewRecorder()
+	appTranslationsTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "7"}})
+
+	resp = w.Result()
+	body, _ = io.ReadAll(resp.Body)
+	is.Equal(resp.Header.Get("Content-Language"), "en")
+	is.Equal(`{"movie":{"id":7,"title":"Spirited Away"}}`, string(body))
+}
+
+func TestApplication_ListMoviesHandler_AcceptLanguage(t *testing.T) {
+	is := is2.New(t)
+
+	translations := provmock.TranslationProviderMock{}
+	translations.BestTranslationsMock = func(languages []string, ids ...int64) (map[int64]*models.Translation, error) {
+		is.Equal(languages, []string{"es-MX", "es-419", "es"})
+		is.Equal(ids, []int64{1, 2})
+		return map[int64]*models.Translation{2: {MovieID: 2, Language: "es-419", Title: "El viaje de Chihiro"}}, nil
+	}
+	movies := provmock.MovieProviderMock{}
+	movies.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		meta := models.New(2, 1, 20)
+		return []*models.Movie{{ID: 1, Title: "Paprika", Version: 1}, {ID: 2, Title: "Spirited Away", Version: 1}}, &meta, nil
+	}
+	teardown := setupTranslationsTestCase(translations, movies)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies?fields=id,title", nil)
+	req.Header.Set("Accept-Language", "es-MX")
+	w := httptest.NewRecorder()
+	appTranslationsTest.ListMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	//the untranslated movie stays in English
+	is.Equal(resp.Header.Get("Content-Language"), "es-419, en")
+	is.True(strings.Contains(string(body), `{"id":1,"title":"Paprika","_links"`))
+	is.True(strings.Contains(string(body), `{"id":2,"title":"El viaje de Chihiro","_links"`))
+}
+
+func TestApplication_SetTranslationHandler(t *testing.T) {
+	is := is2.New(t)
+
+	translations := provmock.TranslationProviderMock{}
+	translations.SetTranslationMock = func(tr *models.Translation) error {
+		is.Equal(*tr, models.Translation{MovieID: 7, Language: "pt-BR", Title: "A Viagem de Chihiro"})
+		return nil
+	}
+	teardown := setupTranslationsTestCase(translations, provmock.MovieProviderMock{})
+	defer teardown()
+
+	put := func(language, content string) (int, string) {
+		req := httptest.NewRequest("PUT", "localhost:8081/v1/movies/7/translations/"+language, strings.NewReader(content))
+		w := httptest.NewRecorder()
+		appTranslationsTest.SetTranslationHandler(w, req, httprouter.Params{{Key: "id", Value: "7"}, {Key: "language", Value: language}})
+		body, _ := io.ReadAll(w.Result().Body)
+		return w.Result().StatusCode, strings.TrimSpace(string(body))
+	}
+
+	status, body := put("pt-br", `{"title": " A Viagem de Chihiro "}`)
+	is.Equal(http.StatusOK, status)
+	is.Equal(`{"translation":{"movieId":7,"language":"pt-BR","title":"A Viagem de Chihiro","overview":""}}`, body)
+
+	status, body = put("en", `{"title": "Spirited Away"}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	is.True(strings.Contains(body, "must not be the language of the catalogue"))
+	status, _ = put("not_a_tag!", `{"title": "?"}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+	status, _ = put("pt", `{"title": ""}`)
+	is.Equal(http.StatusUnprocessableEntity, status)
+}
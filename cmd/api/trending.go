@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+)
+
+/**
+* GET /v1/movies/trending -> 200 OK with JSON content
+*
+* The movies most viewed, added to lists and rated during the last ?window=
+* (day, the default, or week), the most active first. ?limit= defaults to 20.
+* Events show up once flushed, every few seconds.
+**/
+func (app *Application) TrendingMoviesHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	qs := r.URL.Query()
+	window := app.readString(qs, "window", "day")
+	limit := app.readInt(qs, "limit", 20, v)
+	span, ok := models.TrendingWindows[window]
+	v.Check(ok, "window", "must be one of "+strings.Join(trendingWindowNames(), ", "))
+	v.Check(limit >= 1 && limit <= 100, "limit", "must be between 1 and 100")
+	if !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	trends, err := app.activityProvider.Trending(time.Now().Add(-span), limit)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	//goland:noinspection GoPreferNilSlice
+	trending := []*models.TrendingMovie{}
+	if len(trends) > 0 {
+		ids := make([]int64, len(trends))
+		for i, t := range trends {
+			ids[i] = t.MovieID
+		}
+		movies, err := app.moviesByID(ids)
+		if err != nil {
+			app.serverErrorResponse(w, err)
+			return
+		}
+		for _, t := range trends {
+			if m, ok := movies[t.MovieID]; ok {
+				trending = append(trending, &models.TrendingMovie{Movie: m, Activity: t.Activity})
+			}
+		}
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"window": window, "movies": trending}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// trendingWindowNames returns the names of the trending windows, shortest first.
+func trendingWindowNames() []string {
+	names := make([]string, 0, len(models.TrendingWindows))
+	for name := range models.TrendingWindows {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return models.TrendingWindows[names[i]] < models.TrendingWindows[names[j]]
+	})
+	return names
+}
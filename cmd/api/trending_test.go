@@ -0,0 +1,102 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"yamda_go/internal/activity"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/jsonlog"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	"github.com/julienschmidt/httprouter"
+	is2 "github.com/matryer/is"
+)
+
+var appTrendingTest *Application = nil
+
+func setupTrendingTestCase(activities provmock.ActivityProviderMock, movies provmock.MovieProviderMock) func() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	cfg, _ := config.New("./../../debug.env")
+	appTrendingTest = &Application{
+		logger:             logger,
+		config:             cfg,
+		activityProvider:   activities,
+		movieProvider:      movies,
+		activity:           activity.NewBuffer(activities.Save, 100),
+		collectionProvider: noCollections,
+		relationProvider:   noRelations,
+	}
+	return func() {
+		//some teardown
+		appTrendingTest = nil
+	}
+}
+
+func TestApplication_TrendingMoviesHandler(t *testing.T) {
+	is := is2.New(t)
+
+	activities := provmock.ActivityProviderMock{}
+	activities.TrendingMock = func(since time.Time, limit int) ([]models.Trend, error) {
+		is.True(time.Since(since) > 7*24*time.Hour-time.Minute)
+		is.Equal(limit, 20)
+		return []models.Trend{{MovieID: 7, Activity: 12}, {MovieID: 3, Activity: 4}}, nil
+	}
+	movies := provmock.MovieProviderMock{}
+	movies.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		is.Equal(s.IDs, []int64{7, 3})
+		return []*models.Movie{{ID: 3, Title: "Up", Version: 1}, {ID: 7, Title: "Heat", Version: 1}}, &models.Metadata{}, nil
+	}
+	teardown := setupTrendingTestCase(activities, movies)
+	defer teardown()
+
+	req := httptest.NewRequest("GET", "localhost:8081/v1/movies/trending?window=week", nil)
+	w := httptest.NewRecorder()
+	appTrendingTest.TrendingMoviesHandler(w, req)
+
+	resp := w.Result()
+	body, _ := io.ReadAll(resp.Body)
+	is.Equal(http.StatusOK, resp.StatusCode)
+	is.Equal(`{"movies":[{"id":7,"title":"Heat","version":1,"activity":12},{"id":3,"title":"Up","version":1,"activity":4}],"window":"week"}`, strings.TrimSpace(string(body)))
+
+	req = httptest.NewRequest("GET", "localhost:8081/v1/movies/trending?window=year", nil)
+	w = httptest.NewRecorder()
+	appTrendingTest.TrendingMoviesHandler(w, req)
+	is.Equal(http.StatusUnprocessableEntity, w.Result().StatusCode)
+}
+
+func TestApplication_GetMovieHandler_RecordsView(t *testing.T) {
+	is := is2.New(t)
+
+	var saved []models.Activity
+	activities := provmock.ActivityProviderMock{}
+	activities.SaveActivityMock = func(batch []models.Activity) error {
+		saved = append(saved, batch...)
+		return nil
+	}
+	movies := provmock.MovieProviderMock{}
+	movies.GetMovieMock = func(id int64) (*models.Movie, error) {
+		return &models.Movie{ID: id, Title: "Heat", Version: 1}, nil
+	}
+	teardown := setupTrendingTestCase(activities, movies)
+	defer teardown()
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "localhost:8081/v1/movies/7", nil)
+		w := httptest.NewRecorder()
+		appTrendingTest.GetMovieHandler(w, req, httprouter.Params{{Key: "id", Value: "7"}})
+		is.Equal(http.StatusOK, w.Result().StatusCode)
+	}
+	is.Equal(len(saved), 0) //not until flushed
+
+	is.NoErr(appTrendingTest.activity.Flush())
+	is.Equal(len(saved), 1)
+	is.Equal(saved[0].MovieID, int64(7))
+	is.Equal(saved[0].Views, 2)
+}
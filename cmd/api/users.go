@@ -2,13 +2,18 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
+	"time"
 	"yamda_go/internal/data/provider"
 	"yamda_go/internal/models"
 	"yamda_go/internal/validator"
 )
 
+// activationTokenTTL is how long users have to activate their account.
+const activationTokenTTL = 3 * 24 * time.Hour
+
 /**
 * POST /v1/users -> 201 CREATED with JSON content
 *
@@ -20,6 +25,7 @@ import (
 * Should create a new User struct containing
 * these details, validate it with the ValidateUser() helper, and then pass it to our
 * UserModel.Insert() method to create a new database record.
+* The user is emailed a token to activate the account with.
 **/
 func (app *Application) RegisterUserHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	var input struct {
@@ -68,8 +74,81 @@ func (app *Application) RegisterUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	token, err := app.tokenProvider.New(insertedUsr.ID, activationTokenTTL, models.ScopeActivation)
+	if err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+	app.sendActivationEmail(insertedUsr, token)
+
 	//send response
 	if err := app.writeJSON(w, http.StatusCreated, envelope{"user": insertedUsr}, nil); err != nil {
 		app.serverErrorResponse(w, err)
 	}
 }
+
+/**
+* PUT /v1/users/activated -> 200 OK with JSON content
+*
+* {"token": "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"}, as emailed on registration.
+* Activated users can review movies and vote reviews as helpful.
+**/
+func (app *Application) ActivateUserHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	var input struct {
+		Token string `json:"token"`
+	}
+	if err := app.readJSON(w, r, &input); err != nil {
+		app.badRequestResponse(w, err)
+		return
+	}
+
+	v := validator.New()
+	if models.ValidateTokenPlaintext(v, input.Token); !v.IsValid() {
+		app.failedValidationResponse(w, v.Errors)
+		return
+	}
+
+	user, err := app.userProvider.GetForToken(models.ScopeActivation, input.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, provider.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired activation token")
+			app.failedValidationResponse(w, v.Errors)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+
+	user.Activated = true
+	if err = app.userProvider.Update(user); err != nil {
+		switch {
+		case errors.Is(err, provider.ErrEditConflict):
+			app.resourceEditConflictResponse(w)
+		default:
+			app.serverErrorResponse(w, err)
+		}
+		return
+	}
+	//the token did its job
+	if err = app.tokenProvider.DeleteAllForUser(models.ScopeActivation, user.ID); err != nil {
+		app.serverErrorResponse(w, err)
+		return
+	}
+
+	if err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil); err != nil {
+		app.serverErrorResponse(w, err)
+	}
+}
+
+// sendActivationEmail sends the activation token to the user. Failures are only
+// logged, another token can be asked for with POST /v1/tokens/activation.
+func (app *Application) sendActivationEmail(user *models.User, token *models.Token) {
+	body := fmt.Sprintf("Hi %s,\n\n"+
+		"To activate your account, send a PUT /v1/users/activated request with:\n\n"+
+		"{\"token\": %q}\n\n"+
+		"The token expires on %s.\n", user.Name, token.Plaintext, token.Expiry.Format(time.RFC1123))
+	if err := app.mailer.Send(user.Email, "Activate your Yamda account", body); err != nil {
+		app.logger.PrintError(err, map[string]string{"email": user.Email})
+	}
+}
@@ -6,23 +6,43 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
+	"yamda_go/internal/clientip"
 	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
 	"yamda_go/internal/jsonlog"
 	provmock "yamda_go/internal/mocks/data/provider"
 	"yamda_go/internal/models"
+	"yamda_go/internal/moderation"
 )
 
 var appUsersTest *Application = nil
 
+// mailbox keeps the emails instead of sending them.
+type mailbox []string
+
+func (m *mailbox) Send(recipient, subject, body string) error {
+	*m = append(*m, body)
+	return nil
+}
+
+// newTokens hands out real tokens without storing them.
+var newTokens = provmock.TokenProviderMock{
+	NewTokenMock: models.GenerateToken,
+}
+
 func setupUsersTestCase(p provmock.UserProviderMock) func() {
 	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
 	cfg, _ := config.New("./../../debug.env")
 	appUsersTest = &Application{
-		logger:       logger,
-		config:       cfg,
-		userProvider: p,
+		logger:        logger,
+		config:        cfg,
+		userProvider:  p,
+		tokenProvider: newTokens,
+		mailer:        &mailbox{},
 	}
 	return func() {
 		//some teardown
@@ -153,3 +173,154 @@ func TestApplication_RegisterNewUser_MissingPassword(t *testing.T) {
 	expectedBody := `{"title":"input validations failed","status":422,"detail":"","errors":{"password":"must be provided"}}`
 	is.Equal(expectedBody, string(respBody))
 }
+
+func TestApplication_CreateAuthenticationTokenHandler_WrongPassword(t *testing.T) {
+	is := is2.New(t)
+
+	mock := provmock.UserProviderMock{}
+	mock.GetByEmailMock = func(email string) (*models.User, error) {
+		user := &models.User{ID: 1234, Email: email, Activated: true}
+		_ = user.Password.Set("mysuperpwhehe")
+		return user, nil
+	}
+
+	teardown := setupUsersTestCase(mock)
+	defer teardown()
+
+	body := `{"email": "jason@bourne.com", "password": "notmypassword"}`
+	req := httptest.NewRequest("POST", "localhost:8081/v1/tokens/authentication", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	appUsersTest.CreateAuthenticationTokenHandler(w, req, nil)
+
+	resp := w.Result()
+	respBody, _ := io.ReadAll(resp.Body)
+
+	is.Equal(http.StatusUnauthorized, resp.StatusCode)
+	expectedBody := `{"title":"invalid credentials","status":401,"detail":"invalid authentication credentials"}`
+	is.Equal(expectedBody, string(respBody))
+}
+
+func TestApplication_RegisterActivateAndReview(t *testing.T) {
+	is := is2.New(t)
+
+	//users and tokens kept in memory
+	var (
+		users  = map[int64]*models.User{}
+		tokens = map[string]*models.Token{}
+	)
+	userMock := provmock.UserProviderMock{
+		InsertMock: func(u *models.User) (*models.User, error) {
+			u.ID, u.Version = int64(len(users)+1), 1
+			saved := *u
+			users[u.ID] = &saved
+			return u, nil
+		},
+		GetByEmailMock: func(email string) (*models.User, error) {
+			for _, u := range users {
+				if u.Email == email {
+					found := *u
+					return &found, nil
+				}
+			}
+			return nil, provider.ErrEmailNotFound
+		},
+		GetForTokenMock: func(scope, plaintext string) (*models.User, error) {
+			token, ok := tokens[plaintext]
+			if !ok || token.Scope != scope || token.Expiry.Before(time.Now()) {
+				return nil, provider.ErrRecordNotFound
+			}
+			found := *users[token.UserID]
+			return &found, nil
+		},
+		UpdateMock: func(u *models.User) error {
+			if users[u.ID].Version != u.Version {
+				return provider.ErrEditConflict
+			}
+			u.Version++
+			saved := *u
+			users[u.ID] = &saved
+			return nil
+		},
+	}
+	tokenMock := provmock.TokenProviderMock{
+		NewTokenMock: func(userID int64, ttl time.Duration, scope string) (*models.Token, error) {
+			token, err := models.GenerateToken(userID, ttl, scope)
+			if err == nil {
+				tokens[token.Plaintext] = token
+			}
+			return token, err
+		},
+		DeleteAllForUserMock: func(scope string, userID int64) error {
+			for plaintext, token := range tokens {
+				if token.Scope == scope && token.UserID == userID {
+					delete(tokens, plaintext)
+				}
+			}
+			return nil
+		},
+	}
+	reviewMock := provmock.ReviewProviderMock{
+		CreateReviewMock: func(r *models.Review) (*models.Review, error) {
+			r.ID, r.Version = 9, 1
+			return r, nil
+		},
+	}
+
+	cfg, _ := config.New("./../../debug.env")
+	cfg.LimiterEnabled, cfg.ConcurrencyEnabled = false, false
+	resolver, _ := clientip.NewResolver(nil)
+	mails := &mailbox{}
+	app := &Application{
+		logger:         jsonlog.New(io.Discard, jsonlog.LevelInfo),
+		config:         cfg,
+		userProvider:   userMock,
+		tokenProvider:  tokenMock,
+		reviewProvider: reviewMock,
+		screener:       moderation.NewScreener(nil),
+		mailer:         mails,
+		clientIP:       resolver,
+	}
+	routes := app.routes()
+
+	send := func(method, target, bearer, content string) (int, string) {
+		req := httptest.NewRequest(method, target, strings.NewReader(content))
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		routes.ServeHTTP(w, req)
+		body, _ := io.ReadAll(w.Result().Body)
+		return w.Result().StatusCode, string(body)
+	}
+
+	status, _ := send("POST", "/v1/users", "", `{"name": "Alice Smith", "email": "alice@example.com", "password": "pa55word"}`)
+	is.Equal(status, http.StatusCreated)
+	is.Equal(len(*mails), 1)
+	activation := regexp.MustCompile(`"token": "([A-Z2-7]{26})"`).FindStringSubmatch((*mails)[0])
+	is.True(activation != nil)
+
+	status, body := send("POST", "/v1/tokens/authentication", "", `{"email": "alice@example.com", "password": "pa55word"}`)
+	is.Equal(status, http.StatusCreated)
+	bearer := regexp.MustCompile(`"token":"([A-Z2-7]{26})"`).FindStringSubmatch(body)[1]
+
+	review := `{"body": "A quiet and moving film."}`
+	status, _ = send("POST", "/v1/movies/5/reviews", bearer, review)
+	is.Equal(status, http.StatusForbidden)
+
+	//authentication tokens don't activate accounts
+	status, _ = send("PUT", "/v1/users/activated", "", `{"token": "`+bearer+`"}`)
+	is.Equal(status, http.StatusUnprocessableEntity)
+	status, body = send("PUT", "/v1/users/activated", "", `{"token": "`+activation[1]+`"}`)
+	is.Equal(status, http.StatusOK)
+	is.True(strings.Contains(body, `"activated":true`))
+	//it can't be used twice
+	status, _ = send("PUT", "/v1/users/activated", "", `{"token": "`+activation[1]+`"}`)
+	is.Equal(status, http.StatusUnprocessableEntity)
+
+	status, _ = send("POST", "/v1/movies/5/reviews", bearer, review)
+	is.Equal(status, http.StatusCreated)
+
+	//and there is nothing left to activate
+	status, _ = send("POST", "/v1/tokens/activation", "", `{"email": "alice@example.com"}`)
+	is.Equal(status, http.StatusUnprocessableEntity)
+}
@@ -0,0 +1,77 @@
+// Command recommend trains the model of the personalised recommendations
+// offline, out of the ratings of the users:
+//
+//	recommend train [-neighbors 50] [-min-support 2]
+//
+// The model is written to RECOMMEND_MODEL_PATH, where the running API picks
+// it up without a restart.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data/provider"
+	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/models"
+	"yamda_go/internal/recommend"
+)
+
+func main() {
+	logger := jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	if len(os.Args) < 2 || os.Args[1] != "train" {
+		fmt.Fprintln(os.Stderr, "usage: recommend train [-neighbors n] [-min-support n]")
+		os.Exit(2)
+	}
+	if err := train(os.Args[2:], logger); err != nil {
+		logger.PrintFatal(err, nil)
+		os.Exit(1)
+	}
+}
+
+func train(args []string, logger *jsonlog.Logger) error {
+	opts := recommend.DefaultTrainOptions
+	flags := flag.NewFlagSet("train", flag.ExitOnError)
+	flags.IntVar(&opts.Neighbors, "neighbors", opts.Neighbors, "most similar movies kept for each movie")
+	flags.IntVar(&opts.MinSupport, "min-support", opts.MinSupport, "users who rated both movies, at least")
+	_ = flags.Parse(args)
+
+	cfg, err := config.New("./debug.env")
+	if err != nil {
+		return err
+	}
+	if cfg.RecommendModelPath == "" {
+		return errors.New("RECOMMEND_MODEL_PATH is not set")
+	}
+
+	db, err := provider.OpenDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	//ratings go straight into the model, they are never all in memory
+	trainer := recommend.NewTrainer(opts)
+	err = provider.NewRatingProvider(db, cfg).ForEach(func(userID int64, r models.Rating) error {
+		trainer.Add(recommend.Rating{UserID: userID, MovieID: r.MovieID, Score: float64(r.Score)})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	model := trainer.Model()
+	if err = model.Save(cfg.RecommendModelPath); err != nil {
+		return err
+	}
+	logger.PrintInfo("trained recommendation model", map[string]string{
+		"model":   cfg.RecommendModelPath,
+		"ratings": strconv.Itoa(model.Ratings),
+		"users":   strconv.Itoa(model.Users),
+		"movies":  strconv.Itoa(len(model.Neighbors)),
+	})
+	return nil
+}
@@ -0,0 +1,171 @@
+package activity
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/models"
+)
+
+// Kind is what happened to a movie.
+type Kind int
+
+const (
+	View    Kind = iota //its details were shown
+	ListAdd             //it was added to a list
+	Rating              //it was rated
+)
+
+// FlushFunc saves a batch of activity, adding it to what was saved before.
+type FlushFunc func([]models.Activity) error
+
+type key struct {
+	movieID int64
+	hour    time.Time
+}
+
+// Buffer counts events in memory, per movie and hour, until they are flushed
+// in batches. Recording is a map increment under a lock, it never waits for
+// the database. When flushes fail and maxPending movie-hours are waiting,
+// events of other movie-hours are dropped.
+// It is safe for concurrent use, and a nil *Buffer records nothing.
+type Buffer struct {
+	flush      FlushFunc
+	maxPending int
+	now        func() time.Time
+
+	flushing sync.Mutex //flushes one batch at a time
+
+	mu      sync.Mutex
+	pending map[key]*models.Activity
+	dropped int
+}
+
+func NewBuffer(flush FlushFunc, maxPending int) *Buffer {
+	return &Buffer{
+		flush:      flush,
+		maxPending: maxPending,
+		now:        time.Now,
+		pending:    make(map[key]*models.Activity),
+	}
+}
+
+// Record counts an event of the movie.
+func (b *Buffer) Record(movieID int64, kind Kind) {
+	if b == nil {
+		return
+	}
+	k := key{movieID, b.now().UTC().Truncate(time.Hour)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	a := b.entry(k)
+	if a == nil {
+		b.dropped++
+		return
+	}
+	switch kind {
+	case View:
+		a.Views++
+	case ListAdd:
+		a.ListAdds++
+	case Rating:
+		a.Ratings++
+	}
+}
+
+// entry returns the counts of the movie-hour, nil when there is no room for it.
+func (b *Buffer) entry(k key) *models.Activity {
+	a := b.pending[k]
+	if a == nil {
+		if len(b.pending) >= b.maxPending {
+			return nil
+		}
+		a = &models.Activity{MovieID: k.movieID, Hour: k.hour}
+		b.pending[k] = a
+	}
+	return a
+}
+
+// Pending returns how many movie-hours wait to be flushed.
+func (b *Buffer) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// Flush saves the events counted so far. Events keep being recorded meanwhile,
+// into a new batch. When saving fails the batch is merged back, for the next
+// flush to try again.
+func (b *Buffer) Flush() error {
+	if b == nil {
+		return nil
+	}
+	b.flushing.Lock()
+	defer b.flushing.Unlock()
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[key]*models.Activity, len(pending))
+	b.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	batch := make([]models.Activity, 0, len(pending))
+	for _, a := range pending {
+		batch = append(batch, *a)
+	}
+	err := b.flush(batch)
+	if err != nil {
+		b.restore(pending)
+	}
+	return err
+}
+
+// restore merges a batch that couldn't be saved with the events recorded since.
+func (b *Buffer) restore(batch map[key]*models.Activity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for k, a := range batch {
+		current := b.entry(k)
+		if current == nil {
+			b.dropped += a.Views + a.ListAdds + a.Ratings
+			continue
+		}
+		current.Views += a.Views
+		current.ListAdds += a.ListAdds
+		current.Ratings += a.Ratings
+	}
+}
+
+// takeDropped returns how many events were dropped since the last call.
+func (b *Buffer) takeDropped() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	dropped := b.dropped
+	b.dropped = 0
+	return dropped
+}
+
+// Run flushes the buffer every interval. It blocks until ctx is done, the
+// last events are for the caller to flush once nothing records them anymore.
+func (b *Buffer) Run(ctx context.Context, interval time.Duration, logger *jsonlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Flush(); err != nil {
+				logger.PrintError(err, map[string]string{"pending": strconv.Itoa(b.Pending())})
+			}
+			if dropped := b.takeDropped(); dropped > 0 {
+				logger.PrintInfo("dropped activity events", map[string]string{"dropped": strconv.Itoa(dropped)})
+			}
+		}
+	}
+}
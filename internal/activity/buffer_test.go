@@ -0,0 +1,95 @@
+package activity
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+// flushed keeps the batches saved by a buffer, failing while err is set.
+type flushed struct {
+	err     error
+	batches [][]models.Activity
+}
+
+func (f *flushed) save(batch []models.Activity) error {
+	if f.err != nil {
+		return f.err
+	}
+	sort.Slice(batch, func(i, j int) bool {
+		if !batch[i].Hour.Equal(batch[j].Hour) {
+			return batch[i].Hour.Before(batch[j].Hour)
+		}
+		return batch[i].MovieID < batch[j].MovieID
+	})
+	f.batches = append(f.batches, batch)
+	return nil
+}
+
+func TestBuffer_CountsPerMovieAndHour(t *testing.T) {
+	is := is2.New(t)
+
+	f := &flushed{}
+	b := NewBuffer(f.save, 100)
+	now := time.Date(2024, 3, 31, 20, 15, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	b.Record(1, View)
+	b.Record(1, View)
+	b.Record(1, Rating)
+	b.Record(2, ListAdd)
+	now = now.Add(time.Hour)
+	b.Record(1, View)
+	is.Equal(b.Pending(), 3)
+
+	is.NoErr(b.Flush())
+	is.Equal(b.Pending(), 0)
+	hour := time.Date(2024, 3, 31, 20, 0, 0, 0, time.UTC)
+	is.Equal(f.batches, [][]models.Activity{{
+		{MovieID: 1, Hour: hour, Views: 2, Ratings: 1},
+		{MovieID: 2, Hour: hour, ListAdds: 1},
+		{MovieID: 1, Hour: hour.Add(time.Hour), Views: 1},
+	}})
+
+	//nothing to save
+	is.NoErr(b.Flush())
+	is.Equal(len(f.batches), 1)
+}
+
+func TestBuffer_KeepsEventsWhenFlushFails(t *testing.T) {
+	is := is2.New(t)
+
+	f := &flushed{err: errors.New("database is down")}
+	b := NewBuffer(f.save, 2)
+	now := time.Date(2024, 3, 31, 20, 15, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	b.Record(1, View)
+	is.True(b.Flush() != nil)
+	b.Record(1, View)
+	b.Record(2, View)
+	b.Record(3, View) //no room for a third movie
+	is.Equal(b.takeDropped(), 1)
+	is.Equal(b.Pending(), 2)
+
+	f.err = nil
+	is.NoErr(b.Flush())
+	hour := time.Date(2024, 3, 31, 20, 0, 0, 0, time.UTC)
+	is.Equal(f.batches[0], []models.Activity{
+		{MovieID: 1, Hour: hour, Views: 2},
+		{MovieID: 2, Hour: hour, Views: 1},
+	})
+	is.Equal(b.takeDropped(), 0)
+}
+
+func TestBuffer_Nil(t *testing.T) {
+	is := is2.New(t)
+
+	var b *Buffer
+	b.Record(1, View)
+	is.NoErr(b.Flush())
+}
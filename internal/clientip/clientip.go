@@ -0,0 +1,140 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Resolver finds out the address of the client that made a request. The
+// X-Forwarded-For and Forwarded (RFC 7239) headers are only believed when the
+// immediate peer is one of the trusted proxies, otherwise anyone could spoof them.
+//
+// A nil Resolver trusts no proxy and always returns the peer address.
+type Resolver struct {
+	trusted []*net.IPNet
+}
+
+// NewResolver builds a Resolver trusting the given CIDRs. Plain IP addresses
+// are accepted as well and trusted as a single host.
+func NewResolver(cidrs []string) (*Resolver, error) {
+	r := &Resolver{}
+	for _, c := range cidrs {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if !strings.Contains(c, "/") {
+			ip := net.ParseIP(c)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", c)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			r.trusted = append(r.trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %s", c, err)
+		}
+		r.trusted = append(r.trusted, n)
+	}
+	return r, nil
+}
+
+// ClientIP returns the IP address of the client behind the request.
+//
+// When the peer is a trusted proxy, the forwarding chain is walked from the
+// closest hop backwards and the first address that is not a trusted proxy is
+// the client. Forwarded takes precedence over X-Forwarded-For.
+func (r *Resolver) ClientIP(req *http.Request) (string, error) {
+	peer, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return "", err
+	}
+	if !r.isTrusted(net.ParseIP(peer)) {
+		return peer, nil
+	}
+
+	chain := forwardedFor(req.Header.Values("Forwarded"))
+	if len(chain) == 0 {
+		chain = xForwardedFor(req.Header.Values("X-Forwarded-For"))
+	}
+
+	client := peer
+	for i := len(chain) - 1; i >= 0; i-- {
+		ip := net.ParseIP(chain[i])
+		if ip == nil {
+			//obfuscated or unknown hops can't be trusted nor resolved any further.
+			break
+		}
+		client = ip.String()
+		if !r.isTrusted(ip) {
+			break
+		}
+	}
+	return client, nil
+}
+
+func (r *Resolver) isTrusted(ip net.IP) bool {
+	if r == nil || ip == nil {
+		return false
+	}
+	for _, n := range r.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// xForwardedFor flattens the X-Forwarded-For values into a single list of hops.
+func xForwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, hop := range strings.Split(v, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedFor extracts the "for" parameter of every element of the Forwarded
+// header values, e.g. `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`.
+func forwardedFor(values []string) []string {
+	var hops []string
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(key, "for") {
+					continue
+				}
+				hops = append(hops, forwardedNode(val))
+			}
+		}
+	}
+	return hops
+}
+
+// forwardedNode strips quotes, brackets and port from a Forwarded node.
+func forwardedNode(node string) string {
+	node = strings.Trim(node, `"`)
+	if strings.HasPrefix(node, "[") {
+		//IPv6, optionally followed by a port: "[2001:db8::1]:4711"
+		if end := strings.Index(node, "]"); end > 0 {
+			return node[1:end]
+		}
+		return node
+	}
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		return host
+	}
+	return node
+}
@@ -0,0 +1,77 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestResolver_UntrustedPeerIgnoresHeaders(t *testing.T) {
+	is := is2.New(t)
+
+	r, err := NewResolver([]string{"10.0.0.0/8"})
+	is.NoErr(err)
+
+	req := httptest.NewRequest("GET", "/v1/movies", nil)
+	req.RemoteAddr = "203.0.113.7:5555"
+	req.Header.Set("X-Forwarded-For", "1.2.3.4")
+
+	ip, err := r.ClientIP(req)
+	is.NoErr(err)
+	is.Equal(ip, "203.0.113.7")
+}
+
+func TestResolver_XForwardedForSkipsTrustedHops(t *testing.T) {
+	is := is2.New(t)
+
+	r, err := NewResolver([]string{"10.0.0.0/8", "192.168.1.1"})
+	is.NoErr(err)
+
+	req := httptest.NewRequest("GET", "/v1/movies", nil)
+	req.RemoteAddr = "10.0.0.2:5555"
+	req.Header.Add("X-Forwarded-For", "6.6.6.6, 198.51.100.20")
+	req.Header.Add("X-Forwarded-For", "192.168.1.1")
+
+	ip, err := r.ClientIP(req)
+	is.NoErr(err)
+	is.Equal(ip, "198.51.100.20") //6.6.6.6 could have been forged by the client
+}
+
+func TestResolver_ForwardedHeaderTakesPrecedence(t *testing.T) {
+	is := is2.New(t)
+
+	r, err := NewResolver([]string{"10.0.0.0/8"})
+	is.NoErr(err)
+
+	req := httptest.NewRequest("GET", "/v1/movies", nil)
+	req.RemoteAddr = "10.0.0.2:5555"
+	req.Header.Set("X-Forwarded-For", "198.51.100.20")
+	req.Header.Set("Forwarded", `for="[2001:db8:cafe::17]:4711";proto=https, for=10.1.1.1`)
+
+	ip, err := r.ClientIP(req)
+	is.NoErr(err)
+	is.Equal(ip, "2001:db8:cafe::17")
+}
+
+func TestResolver_NilTrustsNobody(t *testing.T) {
+	is := is2.New(t)
+
+	var r *Resolver
+	req := httptest.NewRequest("GET", "/v1/movies", nil)
+	req.RemoteAddr = "10.0.0.2:5555"
+	req.Header.Set("X-Forwarded-For", "198.51.100.20")
+
+	ip, err := r.ClientIP(req)
+	is.NoErr(err)
+	is.Equal(ip, "10.0.0.2")
+}
+
+func TestNewResolver_InvalidCIDR(t *testing.T) {
+	is := is2.New(t)
+
+	_, err := NewResolver([]string{"10.0.0.0/33"})
+	is.True(err != nil)
+	_, err = NewResolver([]string{"not-an-ip"})
+	is.True(err != nil)
+}
@@ -17,10 +17,65 @@ type Settings struct {
 	ConnMaxOpen     int    `mapstructure:"SQL_CONN_MAX_OPEN_CONN"`
 	ConnMaxIdle     int    `mapstructure:"SQL_CONN_MAX_IDLE_CONN"`
 	HttpReqTimeout  int    `mapstructure:"HTTP_REQUEST_TIMEOUT"`
+	CursorSecret    string `mapstructure:"CURSOR_SECRET"` //signs pagination cursors
+	//weighted movie ratings: every movie starts with RATING_PRIOR_VOTES votes of
+	//RATING_PRIOR_MEAN, so that a few votes can't put it on top.
+	RatingPriorVotes int     `mapstructure:"RATING_PRIOR_VOTES"`
+	RatingPriorMean  float64 `mapstructure:"RATING_PRIOR_MEAN"`
+	//reviews containing any of these words are flagged for the moderators
+	ReviewFlaggedWords []string `mapstructure:"REVIEW_FLAGGED_WORDS"`
+	//how often the feature vectors of similar movies are computed again
+	SimilarRefreshMinutes int `mapstructure:"SIMILAR_REFRESH_MINUTES"`
+	//model file written by "recommend train", reloaded when it changes
+	RecommendModelPath     string `mapstructure:"RECOMMEND_MODEL_PATH"`
+	RecommendReloadSeconds int    `mapstructure:"RECOMMEND_RELOAD_SECONDS"`
+	//views, list additions and ratings are buffered for ACTIVITY_FLUSH_SECONDS, up
+	//to ACTIVITY_MAX_PENDING movie-hours, and make the popularity of a movie,
+	//halved every POPULARITY_HALF_LIFE_HOURS.
+	ActivityFlushSeconds     int `mapstructure:"ACTIVITY_FLUSH_SECONDS"`
+	ActivityMaxPending       int `mapstructure:"ACTIVITY_MAX_PENDING"`
+	PopularityHalfLifeHours  int `mapstructure:"POPULARITY_HALF_LIFE_HOURS"`
+	PopularityRefreshMinutes int `mapstructure:"POPULARITY_REFRESH_MINUTES"`
+	//BCP 47 tag of the language movies are written in, others are translations
+	CatalogLanguage string `mapstructure:"CATALOG_LANGUAGE"`
+	//SMTP server sending the activation emails. Without SMTP_HOST they are
+	//written to the log instead.
+	SmtpHost     string `mapstructure:"SMTP_HOST"`
+	SmtpPort     int    `mapstructure:"SMTP_PORT"`
+	SmtpUsername string `mapstructure:"SMTP_USERNAME"`
+	SmtpPassword string `mapstructure:"SMTP_PASSWORD"`
+	SmtpSender   string `mapstructure:"SMTP_SENDER"`
 	//rate limiter settings
 	LimiterRPS     int  `mapstructure:"RATE_LIMITER_RPS"`
 	LimiterBurst   int  `mapstructure:"RATE_LIMITER_BURST"`
 	LimiterEnabled bool `mapstructure:"RATE_LIMITER_ENABLED"`
+	//per route policies as "METHOD /pattern=RPS:BURST,..." and API keys that
+	//get a bucket of their own instead of sharing the one of their IP.
+	LimiterPolicies string   `mapstructure:"RATE_LIMITER_POLICIES"`
+	LimiterAPIKeys  []string `mapstructure:"RATE_LIMITER_API_KEYS"`
+	//bearer tokens looked up per client IP, before users get buckets of their own
+	LimiterTokenRPS   int `mapstructure:"RATE_LIMITER_TOKEN_RPS"`
+	LimiterTokenBurst int `mapstructure:"RATE_LIMITER_TOKEN_BURST"`
+	//where buckets are kept: "memory" (default) or "redis", shared by all replicas.
+	LimiterStore     string `mapstructure:"RATE_LIMITER_STORE"`
+	LimiterRedisAddr string `mapstructure:"RATE_LIMITER_REDIS_ADDR"`
+	LimiterRedisPool int    `mapstructure:"RATE_LIMITER_REDIS_POOL"`
+	//adaptive concurrency limiter settings
+	ConcurrencyEnabled         bool `mapstructure:"CONCURRENCY_LIMITER_ENABLED"`
+	ConcurrencyInitial         int  `mapstructure:"CONCURRENCY_LIMITER_INITIAL"`
+	ConcurrencyMin             int  `mapstructure:"CONCURRENCY_LIMITER_MIN"`
+	ConcurrencyMax             int  `mapstructure:"CONCURRENCY_LIMITER_MAX"`
+	ConcurrencyTargetLatencyMs int  `mapstructure:"CONCURRENCY_LIMITER_TARGET_LATENCY_MS"`
+	//CIDRs of the proxies in front of us. Only those are believed when they
+	//send X-Forwarded-For or Forwarded headers.
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+	//log file settings. An empty LOG_FILE keeps logging to stdout.
+	LogFile        string `mapstructure:"LOG_FILE"`
+	LogMaxSizeMB   int    `mapstructure:"LOG_MAX_SIZE_MB"`
+	LogRotateHours int    `mapstructure:"LOG_ROTATE_HOURS"`
+	LogMaxAgeHours int    `mapstructure:"LOG_MAX_AGE_HOURS"`
+	LogMaxBackups  int    `mapstructure:"LOG_MAX_BACKUPS"`
+	LogCompress    bool   `mapstructure:"LOG_COMPRESS"`
 }
 
 func New(envPath string) (*Settings, error) {
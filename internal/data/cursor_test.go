@@ -0,0 +1,47 @@
+package data
+
+import (
+	"testing"
+	"yamda_go/internal/validator"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestCursorCodec_RoundTrip(t *testing.T) {
+	is := is2.New(t)
+
+	cc := CursorCodec{Secret: []byte("secret")}
+	c := Cursor{Sort: "-year", Values: []string{"1998"}, ID: 42}
+
+	decoded, err := cc.Decode(cc.Encode(c))
+	is.NoErr(err)
+	is.Equal(decoded, c)
+}
+
+func TestCursorCodec_RejectsTamperedOrForeignTokens(t *testing.T) {
+	is := is2.New(t)
+
+	cc := CursorCodec{Secret: []byte("secret")}
+	token := cc.Encode(Cursor{Sort: "id", Values: []string{"12"}, ID: 12})
+	forged := CursorCodec{Secret: []byte("other")}.Encode(Cursor{Sort: "id", Values: []string{"1"}, ID: 1})
+
+	for _, tok := range []string{"", "garbage", token + "x", "x" + token, forged} {
+		_, err := cc.Decode(tok)
+		is.Equal(err, ErrInvalidCursor)
+	}
+}
+
+func TestFilter_Validate_CursorMustMatchSort(t *testing.T) {
+	is := is2.New(t)
+
+	f := Filter{
+		Page:         1,
+		PageSize:     20,
+		Sort:         "title",
+		SortSafelist: []string{"id", "title"},
+		After:        &Cursor{Sort: "id", Values: []string{"3"}, ID: 3},
+	}
+	v := validator.New()
+	f.Validate(v)
+	is.Equal(v.Errors["after"], "does not match the sort parameter")
+}
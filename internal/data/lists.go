@@ -0,0 +1,17 @@
+package data
+
+import "yamda_go/internal/validator"
+
+// ListSearch finds the lists of a user, or the public lists of everybody when
+// UserID is 0.
+type ListSearch struct {
+	UserID  int64
+	Name    string //contained in the name
+	Filters Filter
+}
+
+// Validate checks the search criteria as well as its filter.
+func (s ListSearch) Validate(v *validator.Validator) {
+	v.Check(len(s.Name) <= 100, "name", "must not be more than 100 bytes long")
+	s.Filters.Validate(v)
+}
@@ -0,0 +1,15 @@
+package data
+
+import "yamda_go/internal/validator"
+
+// PeopleSearch finds people by name.
+type PeopleSearch struct {
+	Name    string //contained in the name
+	Filters Filter
+}
+
+// Validate checks the search criteria as well as its filter.
+func (s PeopleSearch) Validate(v *validator.Validator) {
+	v.Check(len(s.Name) <= 200, "name", "must not be more than 200 bytes long")
+	s.Filters.Validate(v)
+}
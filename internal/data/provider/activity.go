@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/models"
+)
+
+// How much each kind of event counts in the activity of a movie: adding it to
+// a list or rating it tells more than looking at it.
+const (
+	viewWeight    = 1
+	listAddWeight = 5
+	ratingWeight  = 3
+)
+
+// activityScore is the weighted activity of a row of movie_activity.
+var activityScore = fmt.Sprintf("(views * %d + list_adds * %d + ratings * %d)", viewWeight, listAddWeight, ratingWeight)
+
+// activityRetention is how long activity is kept: longer than the longest
+// trending window, and long enough for older events to have decayed to nothing.
+const activityRetention = 30 * 24 * time.Hour
+
+// activityBatchSize is the most rows saved by one statement.
+const activityBatchSize = 500
+
+type IActivityProvider interface {
+	// Save adds the counts to the activity of the movies.
+	Save([]models.Activity) error
+	// Trending returns the up to limit movies with the most weighted activity
+	// since the given time, the most active first.
+	Trending(since time.Time, limit int) ([]models.Trend, error)
+	// RefreshPopularity computes the popularity of every movie again, from
+	// its activity decayed by its age, and prunes the activity too old to count.
+	RefreshPopularity() error
+}
+
+type ActivityProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewActivityProvider(db *sql.DB, set *config.Settings) IActivityProvider {
+	return &ActivityProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *ActivityProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+func (p *ActivityProvider) Save(batch []models.Activity) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for from := 0; from < len(batch); from += activityBatchSize {
+		to := from + activityBatchSize
+		if to > len(batch) {
+			to = len(batch)
+		}
+		rows := make([]string, 0, to-from)
+		args := make([]interface{}, 0, 5*(to-from))
+		for _, a := range batch[from:to] {
+			rows = append(rows, "(?, ?, ?, ?, ?)")
+			args = append(args, a.MovieID, a.Hour.UTC(), a.Views, a.ListAdds, a.Ratings)
+		}
+		query := `
+			INSERT INTO movie_activity (movie_id, hour, views, list_adds, ratings)
+			VALUES ` + strings.Join(rows, ", ") + `
+			ON DUPLICATE KEY UPDATE
+				views = views + VALUES(views),
+				list_adds = list_adds + VALUES(list_adds),
+				ratings = ratings + VALUES(ratings)`
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *ActivityProvider) Trending(since time.Time, limit int) ([]models.Trend, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	//activity of deleted movies is left out by the join, ties go to the most popular
+	query := `
+		SELECT a.movie_id, SUM` + activityScore + ` AS activity
+		FROM movie_activity a
+		JOIN Movie m ON m.Id = a.movie_id
+		WHERE a.hour >= ?
+		GROUP BY a.movie_id
+		ORDER BY activity DESC, MAX(m.popularity) DESC, a.movie_id
+		LIMIT ?`
+	rows, err := p.db.QueryContext(ctx, query, since.UTC().Truncate(time.Hour), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	//goland:noinspection GoPreferNilSlice
+	trends := []models.Trend{}
+	for rows.Next() {
+		var t models.Trend
+		if err = rows.Scan(&t.MovieID, &t.Activity); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		trends = append(trends, t)
+	}
+	return trends, rows.Err()
+}
+
+func (p *ActivityProvider) RefreshPopularity() error {
+	//every movie is updated, which takes longer than a request
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	now := time.Now().UTC()
+	if _, err := p.db.ExecContext(ctx, "DELETE FROM movie_activity WHERE hour < ?", now.Add(-activityRetention)); err != nil {
+		return err
+	}
+
+	//activity counts half as much every half-life
+	halfLife := float64(p.configs.PopularityHalfLifeHours) * 60
+	query := `
+		UPDATE Movie m
+		LEFT JOIN (
+			SELECT movie_id, SUM(` + activityScore + ` * POW(0.5, TIMESTAMPDIFF(MINUTE, hour, ?) / ?)) AS popularity
+			FROM movie_activity
+			GROUP BY movie_id
+		) a ON a.movie_id = m.Id
+		SET m.popularity = COALESCE(a.popularity, 0)
+		WHERE m.popularity <> COALESCE(a.popularity, 0)`
+	_, err := p.db.ExecContext(ctx, query, now, halfLife)
+	return err
+}
+
+// Refresh computes the popularity again every interval. It blocks until ctx is done.
+func (p *ActivityProvider) Refresh(ctx context.Context, interval time.Duration, logger *jsonlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.RefreshPopularity(); err != nil {
+				logger.PrintError(err, map[string]string{"index": "popularity"})
+			}
+		}
+	}
+}
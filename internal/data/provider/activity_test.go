@@ -0,0 +1,59 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"time"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestActivityProvider_TrendingAndPopularity(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	activity := NewActivityProvider(testDB, envConfigs)
+
+	heat, err := movies.Insert(&models.Movie{Title: "Heat", Runtime: 170, Year: 1995, Genres: []string{"Crime"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(heat.ID)
+	up, err := movies.Insert(&models.Movie{Title: "Up", Runtime: 96, Year: 2009, Genres: []string{"Animation"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(up.ID)
+
+	//Heat was the talk of the town a few days ago, Up is today
+	now := time.Now().UTC().Truncate(time.Hour)
+	is.NoErr(activity.Save([]models.Activity{
+		{MovieID: heat.ID, Hour: now.Add(-72 * time.Hour), Views: 50, ListAdds: 5},
+		{MovieID: up.ID, Hour: now, Views: 10},
+	}))
+	//counts add up
+	is.NoErr(activity.Save([]models.Activity{{MovieID: up.ID, Hour: now, Views: 5, Ratings: 1}}))
+
+	trends, err := activity.Trending(now.Add(-24*time.Hour), 10)
+	is.NoErr(err)
+	is.Equal(trends[0], models.Trend{MovieID: up.ID, Activity: 15*viewWeight + ratingWeight})
+	for _, tr := range trends {
+		is.True(tr.MovieID != heat.ID) //out of the window
+	}
+
+	trends, err = activity.Trending(now.Add(-7*24*time.Hour), 10)
+	is.NoErr(err)
+	is.Equal(trends[0], models.Trend{MovieID: heat.ID, Activity: 50*viewWeight + 5*listAddWeight})
+
+	is.NoErr(activity.RefreshPopularity())
+	found, _, err := movies.GetAll(data.Search{
+		IDs:         []int64{heat.ID, up.ID},
+		GenresMatch: data.GenresMatchAny,
+		Filters:     data.Filter{Page: 1, PageSize: 10, Sort: "-popularity", SortSafelist: []string{"-popularity"}},
+	})
+	is.NoErr(err)
+	is.Equal(len(found), 2)
+	is.Equal(found[0].ID, heat.ID) //decayed, still ahead
+	is.True(found[0].Popularity > found[1].Popularity)
+	is.True(found[1].Popularity > 0)
+}
@@ -0,0 +1,285 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrDuplicateCollection = errors.New("duplicate collection")
+	ErrMovieInCollection   = errors.New("movie already in another collection")
+)
+
+type ICollectionProvider interface {
+	// Get returns the collection along with its movies, in order.
+	Get(int64) (*models.Collection, error)
+	// GetAll returns every collection without its movies, by name.
+	GetAll() ([]*models.Collection, error)
+	Insert(*models.Collection) (*models.Collection, error)
+	Update(*models.Collection) error
+	Delete(int64) error
+	// SetMovies replaces the movies of the collection, in order. It returns
+	// ErrRecordNotFound for an unknown movie and ErrMovieInCollection for a
+	// movie of another collection.
+	SetMovies(collectionID int64, movieIDs []int64) error
+	// ForMovies returns the collection of each movie, for those in one.
+	ForMovies(movieIDs ...int64) (map[int64]*models.CollectionSummary, error)
+}
+
+type CollectionProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewCollectionProvider(db *sql.DB, set *config.Settings) ICollectionProvider {
+	return &CollectionProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *CollectionProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+const collectionColumns = `c.id, c.created_at, c.name, c.description, c.version,
+      (SELECT COUNT(*) FROM collection_movies cm WHERE cm.collection_id = c.id)`
+
+// scanCollection reads a row of collectionColumns.
+func scanCollection(row interface{ Scan(...interface{}) error }) (*models.Collection, error) {
+	var c models.Collection
+	if err := row.Scan(&c.ID, &c.CreatedAt, &c.Name, &c.Description, &c.Version, &c.MovieCount); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (p *CollectionProvider) Get(id int64) (*models.Collection, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	row := p.db.QueryRowContext(ctx, "SELECT "+collectionColumns+" FROM collections c WHERE c.id = ?", id)
+	c, err := scanCollection(row)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	//movies deleted meanwhile leave gaps in the stored positions
+	query := `
+      SELECT m.Id, m.title, m.year, ROW_NUMBER() OVER (ORDER BY cm.position)
+      FROM collection_movies cm JOIN Movie m ON m.Id = cm.movie_id
+      WHERE cm.collection_id = ?
+      ORDER BY cm.position;`
+	rows, err := p.db.QueryContext(ctx, query, c.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	c.Movies = []*models.CollectionMovie{}
+	for rows.Next() {
+		var m models.CollectionMovie
+		if err = rows.Scan(&m.ID, &m.Title, &m.Year, &m.Position); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		c.Movies = append(c.Movies, &m)
+	}
+	return c, rows.Err()
+}
+
+func (p *CollectionProvider) GetAll() ([]*models.Collection, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, "SELECT "+collectionColumns+" FROM collections c ORDER BY c.name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	collections := []*models.Collection{}
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		collections = append(collections, c)
+	}
+	return collections, rows.Err()
+}
+
+func (p *CollectionProvider) Insert(c *models.Collection) (*models.Collection, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+		INSERT INTO collections (name, description)
+		VALUES (?, ?)
+		RETURNING id, created_at, version`
+	err := p.db.QueryRowContext(ctx, query, c.Name, c.Description).Scan(&c.ID, &c.CreatedAt, &c.Version)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDupEntry {
+		return nil, ErrDuplicateCollection
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.Movies = []*models.CollectionMovie{}
+	return c, nil
+}
+
+// Update saves the collection if nobody changed it meanwhile, and bumps its version.
+func (p *CollectionProvider) Update(c *models.Collection) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := "UPDATE collections SET name = ?, description = ?, version = version + 1 WHERE id = ? AND version = ?"
+	res, err := p.db.ExecContext(ctx, query, c.Name, c.Description, c.ID, c.Version)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDupEntry {
+		return ErrDuplicateCollection
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	c.Version++
+	return nil
+}
+
+// Delete removes the collection, its movies stay in the catalogue.
+func (p *CollectionProvider) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM collections WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *CollectionProvider) SetMovies(collectionID int64, movieIDs []int64) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM collections WHERE id = ? FOR UPDATE", collectionID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM collection_movies WHERE collection_id = ?", collectionID); err != nil {
+		return err
+	}
+	if len(movieIDs) > 0 {
+		rows := make([]string, len(movieIDs))
+		args := make([]interface{}, 0, 3*len(movieIDs))
+		for i, movieID := range movieIDs {
+			rows[i] = "(?, ?, ?)"
+			args = append(args, collectionID, movieID, i+1)
+		}
+		query := "INSERT INTO collection_movies (collection_id, movie_id, position) VALUES " + strings.Join(rows, ", ")
+		_, err = tx.ExecContext(ctx, query, args...)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case errDupEntry:
+				return ErrMovieInCollection
+			case errNoReferencedRow:
+				return ErrRecordNotFound
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *CollectionProvider) ForMovies(movieIDs ...int64) (map[int64]*models.CollectionSummary, error) {
+	summaries := make(map[int64]*models.CollectionSummary)
+	if len(movieIDs) == 0 {
+		return summaries, nil
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	placeholders := make([]string, len(movieIDs))
+	args := make([]interface{}, len(movieIDs))
+	for i, id := range movieIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	//positions are numbered again, like in Get
+	query := fmt.Sprintf(`
+      SELECT movie_id, id, name, position, movies
+      FROM (
+        SELECT cm.movie_id, c.id, c.name,
+               ROW_NUMBER() OVER (PARTITION BY c.id ORDER BY cm.position) AS position,
+               COUNT(*) OVER (PARTITION BY c.id) AS movies
+        FROM collection_movies cm JOIN collections c ON c.id = cm.collection_id
+        WHERE c.id IN (SELECT collection_id FROM collection_movies WHERE movie_id IN (%s))
+      ) members
+      WHERE movie_id IN (%[1]s);`, strings.Join(placeholders, ", "))
+	rows, err := p.db.QueryContext(ctx, query, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			movieID int64
+			s       models.CollectionSummary
+		)
+		if err = rows.Scan(&movieID, &s.ID, &s.Name, &s.Position, &s.MovieCount); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		summaries[movieID] = &s
+	}
+	return summaries, rows.Err()
+}
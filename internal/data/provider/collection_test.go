@@ -0,0 +1,57 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestCollectionProvider_SetMovies(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	collections := NewCollectionProvider(testDB, envConfigs)
+
+	var ids []int64
+	for _, m := range []*models.Movie{
+		{Title: "The Godfather", Runtime: 175, Year: 1972, Genres: []string{"Crime"}, Version: 1},
+		{Title: "The Godfather Part II", Runtime: 202, Year: 1974, Genres: []string{"Crime"}, Version: 1},
+		{Title: "The Godfather Part III", Runtime: 162, Year: 1990, Genres: []string{"Crime"}, Version: 1},
+	} {
+		m, err := movies.Insert(m)
+		is.NoErr(err)
+		defer movies.Delete(m.ID)
+		ids = append(ids, m.ID)
+	}
+
+	trilogy, err := collections.Insert(&models.Collection{Name: "The Godfather trilogy"})
+	is.NoErr(err)
+	defer collections.Delete(trilogy.ID)
+	_, err = collections.Insert(&models.Collection{Name: "The Godfather trilogy"})
+	is.Equal(err, ErrDuplicateCollection)
+
+	is.NoErr(collections.SetMovies(trilogy.ID, []int64{ids[0], ids[1], ids[2]}))
+	is.Equal(collections.SetMovies(trilogy.ID, []int64{ids[0], ids[2] + 1000}), ErrRecordNotFound)
+
+	other, err := collections.Insert(&models.Collection{Name: "Corleone family"})
+	is.NoErr(err)
+	defer collections.Delete(other.ID)
+	is.Equal(collections.SetMovies(other.ID, []int64{ids[0]}), ErrMovieInCollection)
+
+	//the part II is deleted, the others move up
+	is.NoErr(movies.Delete(ids[1]))
+	c, err := collections.Get(trilogy.ID)
+	is.NoErr(err)
+	is.Equal(c.MovieCount, 2)
+	is.Equal(c.Movies[1].ID, ids[2])
+	is.Equal(c.Movies[1].Position, 2)
+
+	summaries, err := collections.ForMovies(ids...)
+	is.NoErr(err)
+	is.Equal(len(summaries), 2)
+	is.Equal(*summaries[ids[2]], models.CollectionSummary{ID: trilogy.ID, Name: "The Godfather trilogy", Position: 2, MovieCount: 2})
+}
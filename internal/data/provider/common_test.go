@@ -1,13 +1,13 @@
 package provider
 
 import (
-	"os"
+	"database/sql"
 	"yamda_go/internal/config"
-	"yamda_go/internal/jsonlog"
 )
 
 // stuff common to unit tests
 var (
 	envConfigs, _ = config.New("./../../../debug.env")
-	logger        = jsonlog.New(os.Stdout, jsonlog.LevelInfo)
+	//connects on first use, the tests needing no database never do
+	testDB, _ = sql.Open(envConfigs.DriverName, envConfigs.ConnString)
 )
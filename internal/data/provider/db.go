@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"database/sql"
+	"time"
+	"yamda_go/internal/config"
+)
+
+// OpenDB opens the pool of connections to the database shared by all the providers.
+func OpenDB(set *config.Settings) (*sql.DB, error) {
+	db, err := sql.Open(set.DriverName, set.ConnString)
+	if err != nil {
+		return nil, err
+	}
+
+	//validate connection to database is open correctly
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	db.SetConnMaxLifetime(time.Minute * time.Duration(set.ConnMaxLifetime))
+	db.SetMaxOpenConns(set.ConnMaxOpen)
+	db.SetMaxIdleConns(set.ConnMaxIdle)
+	return db, nil
+}
@@ -0,0 +1,203 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrDuplicateGenre = errors.New("duplicate genre")
+	ErrGenreInUse     = errors.New("genre in use")
+)
+
+// MariaDB error numbers
+const (
+	errDupEntry      = 1062 //unique key violated
+	errRowIsReferred = 1451 //foreign key violated on delete
+)
+
+type IGenreProvider interface {
+	Get(int64) (*models.Genre, error)
+	GetAll() ([]*models.Genre, error)
+	Insert(*models.Genre) (*models.Genre, error)
+	Update(*models.Genre) error
+	Delete(int64) error
+	Catalog() (models.GenreCatalog, error)
+}
+
+type GenreProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewGenreProvider(db *sql.DB, set *config.Settings) IGenreProvider {
+	return &GenreProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+// genreQuery selects the genres with their aliases comma separated.
+const genreQuery = `
+      SELECT g.id, g.slug, g.name, g.version,
+             COALESCE(GROUP_CONCAT(a.alias ORDER BY a.alias SEPARATOR ','), '')
+      FROM genres g
+      LEFT JOIN genre_aliases a ON a.genre_id = g.id
+      WHERE %s
+      GROUP BY g.id, g.slug, g.name, g.version
+      ORDER BY g.name;`
+
+func (p *GenreProvider) Get(id int64) (*models.Genre, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	genres, err := p.query("g.id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(genres) == 0 {
+		return nil, ErrRecordNotFound
+	}
+	return genres[0], nil
+}
+
+func (p *GenreProvider) GetAll() ([]*models.Genre, error) {
+	return p.query("TRUE")
+}
+
+func (p *GenreProvider) query(where string, args ...interface{}) ([]*models.Genre, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, fmt.Sprintf(genreQuery, where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []*models.Genre{}
+	for rows.Next() {
+		var (
+			g       models.Genre
+			aliases string
+		)
+		if err = rows.Scan(&g.ID, &g.Slug, &g.Name, &g.Version, &aliases); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		g.Aliases = []string{}
+		if aliases != "" {
+			g.Aliases = strings.Split(aliases, ",")
+		}
+		genres = append(genres, &g)
+	}
+	return genres, rows.Err()
+}
+
+func (p *GenreProvider) Insert(g *models.Genre) (*models.Genre, error) {
+	err := p.inTx(func(tx *sql.Tx) error {
+		query := `
+		INSERT INTO genres (slug, name)
+		VALUES (?, ?)
+		RETURNING id, version`
+		if err := tx.QueryRow(query, g.Slug, g.Name).Scan(&g.ID, &g.Version); err != nil {
+			return err
+		}
+		return setAliases(tx, g)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Update saves the genre if nobody changed it meanwhile, and bumps its version.
+func (p *GenreProvider) Update(g *models.Genre) error {
+	return p.inTx(func(tx *sql.Tx) error {
+		query := "UPDATE genres SET slug = ?, name = ?, version = version + 1 WHERE id = ? AND version = ?;"
+		res, err := tx.Exec(query, g.Slug, g.Name, g.ID, g.Version)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil {
+			return err
+		} else if affected != 1 {
+			return ErrEditConflict
+		}
+		g.Version++
+		return setAliases(tx, g)
+	})
+}
+
+// Delete removes a genre no movie belongs to.
+func (p *GenreProvider) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+	res, err := p.db.Exec("DELETE FROM genres WHERE id = ?", id)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errRowIsReferred {
+		return ErrGenreInUse
+	}
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Catalog loads every genre to resolve the names used by clients.
+func (p *GenreProvider) Catalog() (models.GenreCatalog, error) {
+	genres, err := p.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return models.NewGenreCatalog(genres), nil
+}
+
+// inTx runs fn in a transaction, committed when fn succeeds. Unique keys
+// violations are reported as ErrDuplicateGenre.
+func (p *GenreProvider) inTx(fn func(tx *sql.Tx) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errDupEntry {
+			return ErrDuplicateGenre
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// setAliases replaces the aliases of the genre.
+func setAliases(tx *sql.Tx, g *models.Genre) error {
+	if _, err := tx.Exec("DELETE FROM genre_aliases WHERE genre_id = ?", g.ID); err != nil {
+		return err
+	}
+	for _, a := range g.Aliases {
+		if _, err := tx.Exec("INSERT INTO genre_aliases (alias, genre_id) VALUES (?, ?)", strings.TrimSpace(a), g.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestGenreProvider_InsertUpdateDelete_Ok(t *testing.T) {
+	is := is2.New(t)
+
+	prov := NewGenreProvider(testDB, envConfigs)
+
+	g, err := prov.Insert(&models.Genre{Slug: "film-noir", Name: "Film Noir", Aliases: []string{"noir"}})
+	is.NoErr(err)
+	is.True(g.ID > 0)
+	is.Equal(1, g.Version)
+
+	_, err = prov.Insert(&models.Genre{Slug: "film-noir", Name: "Noir again"})
+	is.Equal(err, ErrDuplicateGenre)
+
+	g.Aliases = []string{"noir", "neo-noir"}
+	is.NoErr(prov.Update(g))
+	is.Equal(2, g.Version)
+
+	stored, err := prov.Get(g.ID)
+	is.NoErr(err)
+	is.Equal(stored.Aliases, []string{"neo-noir", "noir"})
+
+	catalog, err := prov.Catalog()
+	is.NoErr(err)
+	name, ok := catalog.Resolve("Neo Noir")
+	is.True(ok)
+	is.Equal(name, "Film Noir")
+
+	is.NoErr(prov.Delete(g.ID))
+	is.Equal(prov.Delete(g.ID), ErrRecordNotFound)
+}
+
+func TestGenreProvider_Delete_InUse(t *testing.T) {
+	is := is2.New(t)
+
+	genres := NewGenreProvider(testDB, envConfigs)
+	movies := NewMovieProvider(testDB, envConfigs)
+
+	g, err := genres.Insert(&models.Genre{Slug: "mockumentary", Name: "Mockumentary", Aliases: []string{}})
+	is.NoErr(err)
+	m, err := movies.Insert(&models.Movie{Title: "This Is Spinal Tap", Runtime: 82, Year: 1984, Genres: []string{"Mockumentary"}, Version: 1})
+	is.NoErr(err)
+
+	is.Equal(genres.Delete(g.ID), ErrGenreInUse)
+
+	is.NoErr(movies.Delete(m.ID))
+	is.NoErr(genres.Delete(g.ID))
+}
@@ -0,0 +1,191 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+)
+
+type IHistoryProvider interface {
+	// Insert logs that the user watched the movie. When Rewatch is nil, it
+	// tells whether they watched it before. It returns ErrRecordNotFound for
+	// an unknown movie.
+	Insert(*models.Watch) error
+	// Stats sums up the watch history of the user.
+	Stats(userID int64) (*models.WatchStats, error)
+}
+
+type HistoryProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewHistoryProvider(db *sql.DB, set *config.Settings) IHistoryProvider {
+	return &HistoryProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *HistoryProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+func (p *HistoryProvider) Insert(w *models.Watch) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, "SELECT runtime FROM Movie WHERE Id = ?", w.MovieID).Scan(&w.Runtime)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+	if w.Rewatch == nil {
+		var before bool
+		query := "SELECT EXISTS(SELECT 1 FROM watch_history WHERE user_id = ? AND movie_id = ? AND watched_on <= ?)"
+		if err = tx.QueryRowContext(ctx, query, w.UserID, w.MovieID, w.WatchedOn).Scan(&before); err != nil {
+			return err
+		}
+		w.Rewatch = &before
+	}
+
+	query := `
+		INSERT INTO watch_history (user_id, movie_id, watched_on, rewatch, runtime)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query, w.UserID, w.MovieID, w.WatchedOn, *w.Rewatch, w.Runtime).Scan(&w.ID, &w.CreatedAt)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Stats runs its queries in a read only transaction, so that they all see the
+// same history.
+func (p *HistoryProvider) Stats(userID int64) (*models.WatchStats, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	stats := &models.WatchStats{
+		TopGenres: []models.GenreCount{},
+		PerYear:   []models.PeriodCount{},
+		PerMonth:  []models.PeriodCount{},
+		Streaks:   []models.Streak{},
+	}
+	query := `
+      SELECT COUNT(*), COUNT(DISTINCT movie_id), COALESCE(SUM(rewatch), 0), COALESCE(SUM(runtime), 0)
+      FROM watch_history
+      WHERE user_id = ?;`
+	err = tx.QueryRowContext(ctx, query, userID).Scan(&stats.Watched, &stats.Movies, &stats.Rewatches, &stats.Runtime)
+	if err != nil {
+		return nil, err
+	}
+	if stats.Watched == 0 {
+		return stats, nil
+	}
+
+	query = `
+      SELECT g.name, COUNT(*)
+      FROM watch_history h
+        JOIN movie_genres mg ON mg.movie_id = h.movie_id
+        JOIN genres g ON g.id = mg.genre_id
+      WHERE h.user_id = ?
+      GROUP BY g.id, g.name
+      ORDER BY COUNT(*) DESC, g.name
+      LIMIT ?;`
+	err = scanRows(ctx, tx, query, []interface{}{userID, models.StatsTopGenres}, func(rows *sql.Rows) error {
+		var c models.GenreCount
+		if err := rows.Scan(&c.Genre, &c.Count); err != nil {
+			return err
+		}
+		stats.TopGenres = append(stats.TopGenres, c)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//years are the sum of their months
+	query = `
+      SELECT YEAR(watched_on), MONTH(watched_on), COUNT(*)
+      FROM watch_history
+      WHERE user_id = ?
+      GROUP BY YEAR(watched_on), MONTH(watched_on)
+      ORDER BY 1, 2;`
+	err = scanRows(ctx, tx, query, []interface{}{userID}, func(rows *sql.Rows) error {
+		var year, month, count int
+		if err := rows.Scan(&year, &month, &count); err != nil {
+			return err
+		}
+		stats.PerMonth = append(stats.PerMonth, models.PeriodCount{Period: fmt.Sprintf("%04d-%02d", year, month), Count: count})
+		if n := len(stats.PerYear); n > 0 && stats.PerYear[n-1].Period == fmt.Sprintf("%04d", year) {
+			stats.PerYear[n-1].Count += count
+		} else {
+			stats.PerYear = append(stats.PerYear, models.PeriodCount{Period: fmt.Sprintf("%04d", year), Count: count})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	//days in a row are as far from each other as from their rank, so subtracting
+	//the rank gives the same day for the whole streak
+	query = `
+      SELECT MIN(day), MAX(day), COUNT(*)
+      FROM (
+        SELECT day, DATE_SUB(day, INTERVAL ROW_NUMBER() OVER (ORDER BY day) DAY) AS streak
+        FROM (SELECT DISTINCT watched_on AS day FROM watch_history WHERE user_id = ?) days
+      ) ranked
+      GROUP BY streak
+      ORDER BY COUNT(*) DESC, MIN(day) DESC
+      LIMIT ?;`
+	err = scanRows(ctx, tx, query, []interface{}{userID, models.StatsStreaks}, func(rows *sql.Rows) error {
+		var s models.Streak
+		if err := rows.Scan(&s.From, &s.To, &s.Days); err != nil {
+			return err
+		}
+		stats.Streaks = append(stats.Streaks, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, tx.Commit()
+}
+
+// scanRows runs the query and hands each row to scan.
+func scanRows(ctx context.Context, tx *sql.Tx, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,60 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestHistoryProvider_Stats(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	users := NewUserProvider(testDB, envConfigs)
+	history := NewHistoryProvider(testDB, envConfigs)
+
+	u := &models.User{Name: "Viewer", Email: "viewer@example.com"}
+	is.NoErr(u.Password.Set("pa55word"))
+	u, err := users.Insert(u)
+	is.NoErr(err)
+	defer users.Delete(u.ID)
+
+	heat, err := movies.Insert(&models.Movie{Title: "Heat", Runtime: 170, Year: 1995, Genres: []string{"Crime"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(heat.ID)
+	up, err := movies.Insert(&models.Movie{Title: "Up", Runtime: 96, Year: 2009, Genres: []string{"Animation", "Family"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(up.ID)
+
+	stats, err := history.Stats(u.ID)
+	is.NoErr(err)
+	is.Equal(stats.Watched, 0)
+
+	//three days in a row across the new year, then one more
+	for _, w := range []*models.Watch{
+		{MovieID: heat.ID, WatchedOn: "2023-12-31"},
+		{MovieID: up.ID, WatchedOn: "2024-01-01"},
+		{MovieID: heat.ID, WatchedOn: "2024-01-02"},
+		{MovieID: up.ID, WatchedOn: "2024-01-20"},
+	} {
+		w.UserID = u.ID
+		is.NoErr(history.Insert(w))
+	}
+	is.Equal(history.Insert(&models.Watch{UserID: u.ID, MovieID: up.ID + 1000, WatchedOn: "2024-01-20"}), ErrRecordNotFound)
+
+	stats, err = history.Stats(u.ID)
+	is.NoErr(err)
+	is.Equal(stats.Watched, 4)
+	is.Equal(stats.Movies, 2)
+	is.Equal(stats.Rewatches, 2)
+	is.Equal(stats.Runtime, models.Runtime(2*170+2*96))
+	is.Equal(stats.TopGenres[0], models.GenreCount{Genre: "Animation", Count: 2})
+	is.Equal(stats.PerYear, []models.PeriodCount{{Period: "2023", Count: 1}, {Period: "2024", Count: 3}})
+	is.Equal(stats.PerMonth, []models.PeriodCount{{Period: "2023-12", Count: 1}, {Period: "2024-01", Count: 3}})
+	is.Equal(stats.Streaks[0], models.Streak{From: "2023-12-31", To: "2024-01-02", Days: 3})
+	is.Equal(len(stats.Streaks), 2)
+}
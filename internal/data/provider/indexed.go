@@ -0,0 +1,210 @@
+package provider
+
+import (
+	"sort"
+	"strings"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+	"yamda_go/internal/search"
+)
+
+// maxIndexHits caps how many movies a free text query can match, the least
+// relevant ones are dropped.
+const maxIndexHits = 1000
+
+// movieIndexWeights tells how much a match in each field counts.
+var movieIndexWeights = map[string]float64{"title": 2, "genres": 1}
+
+// IndexedMovieProvider wraps a movie provider with an in-process search index
+// over titles and genres and a title completer, both kept in sync on every
+// write. It answers the searches having a free text query and the title
+// suggestions, whatever the storage behind the wrapped provider.
+type IndexedMovieProvider struct {
+	IMovieProvider
+	index     *search.Index
+	completer *search.Completer
+}
+
+// NewIndexedMovieProvider wraps p. The indexes start empty, see Rebuild.
+func NewIndexedMovieProvider(p IMovieProvider) *IndexedMovieProvider {
+	return &IndexedMovieProvider{
+		IMovieProvider: p,
+		index:          search.NewIndex(movieIndexWeights),
+		completer:      search.NewCompleter(),
+	}
+}
+
+// Rebuild indexes every movie of the wrapped provider, one page at a time.
+func (p *IndexedMovieProvider) Rebuild() error {
+	params := data.Search{
+		GenresMatch: data.GenresMatchAny,
+		Fields:      []string{"id", "title", "genres"},
+		Filters:     data.Filter{Page: 1, PageSize: 100, Sort: "id", SortSafelist: []string{"id"}},
+	}
+	for {
+		movies, meta, err := p.IMovieProvider.GetAll(params)
+		if err != nil {
+			return err
+		}
+		for _, m := range movies {
+			p.put(m)
+		}
+		if meta.CurrentPage >= meta.LastPage {
+			return nil
+		}
+		params.Filters.Page++
+	}
+}
+
+func (p *IndexedMovieProvider) put(m *models.Movie) {
+	p.index.Put(m.ID, map[string]string{
+		"title":  m.Title,
+		"genres": strings.Join(m.Genres, " "),
+	})
+	p.completer.Put(m.ID, m.Title)
+}
+
+// Get counts every movie looked at, popular titles are suggested first.
+func (p *IndexedMovieProvider) Get(id int64) (*models.Movie, error) {
+	m, err := p.IMovieProvider.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	p.completer.Touch(id)
+	return m, nil
+}
+
+func (p *IndexedMovieProvider) Insert(m *models.Movie) (*models.Movie, error) {
+	inserted, err := p.IMovieProvider.Insert(m)
+	if err != nil {
+		return nil, err
+	}
+	p.put(inserted)
+	return inserted, nil
+}
+
+func (p *IndexedMovieProvider) Update(m models.Movie) error {
+	if err := p.IMovieProvider.Update(m); err != nil {
+		return err
+	}
+	p.put(&m)
+	return nil
+}
+
+func (p *IndexedMovieProvider) Delete(id int64) error {
+	if err := p.IMovieProvider.Delete(id); err != nil {
+		return err
+	}
+	p.index.Remove(id)
+	p.completer.Remove(id)
+	return nil
+}
+
+// Suggest completes the prefix from memory, tolerating typos.
+func (p *IndexedMovieProvider) Suggest(prefix string, limit int) ([]models.Suggestion, error) {
+	suggestions := []models.Suggestion{}
+	for _, s := range p.completer.Complete(prefix, limit) {
+		suggestions = append(suggestions, models.Suggestion{ID: s.ID, Title: s.Title})
+	}
+	return suggestions, nil
+}
+
+// GetAll ranks the movies matching params.Query with the index, then lets the
+// wrapped provider apply the other criteria to them. Sorting, where "relevance"
+// is the score of the index, and paging are done here.
+func (p *IndexedMovieProvider) GetAll(params data.Search) ([]*models.Movie, *models.Metadata, error) {
+	if params.Query == "" {
+		return p.IMovieProvider.GetAll(params)
+	}
+
+	hits := p.index.Search(params.Query, maxIndexHits)
+	if len(hits) == 0 {
+		return []*models.Movie{}, &models.Metadata{}, nil
+	}
+	scores := make(map[int64]float64, len(hits))
+	inner := params
+	inner.Query, inner.IDs = "", []int64{}
+	for _, h := range hits {
+		scores[h.ID] = h.Score
+		inner.IDs = append(inner.IDs, h.ID)
+	}
+
+	f := params.Filters
+	fields := f.GetSortFields()
+	if len(inner.Fields) > 0 {
+		//the sort fields are needed here, whatever the caller asked for
+		inner.Fields = append(inner.Fields[:len(inner.Fields):len(inner.Fields)], "title", "year", "runtime", "rating", "popularity")
+	}
+	inner.Filters = data.Filter{Page: 1, PageSize: len(hits), Sort: "id", SortSafelist: []string{"id"}}
+
+	movies, _, err := p.IMovieProvider.GetAll(inner)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sort.SliceStable(movies, func(i, j int) bool {
+		for _, sf := range fields {
+			c := compareMovies(movies[i], movies[j], sf.Column, scores)
+			if c == 0 {
+				continue
+			}
+			return (c < 0) == (sf.Direction == "ASC")
+		}
+		return movies[i].ID < movies[j].ID
+	})
+
+	meta := models.New(len(movies), f.Page, f.GetPageSize())
+	from := f.GetPageOffset()
+	if from > len(movies) {
+		from = len(movies)
+	}
+	to := from + f.GetPageSize()
+	if to > len(movies) {
+		to = len(movies)
+	}
+	return movies[from:to], &meta, nil
+}
+
+// Facets counts the movies matching params.Query, when there is one, in the
+// wrapped provider.
+func (p *IndexedMovieProvider) Facets(params data.Search) (models.Facets, error) {
+	if params.Query == "" {
+		return p.IMovieProvider.Facets(params)
+	}
+	hits := p.index.Search(params.Query, maxIndexHits)
+	params.Query, params.IDs = "", []int64{}
+	for _, h := range hits {
+		params.IDs = append(params.IDs, h.ID)
+	}
+	return p.IMovieProvider.Facets(params)
+}
+
+// compareMovies compares a column of two movies, returning -1, 0 or 1.
+func compareMovies(a, b *models.Movie, column string, scores map[int64]float64) int {
+	switch column {
+	case "relevance":
+		return compare(scores[a.ID], scores[b.ID])
+	case "title":
+		return strings.Compare(search.Fold(a.Title), search.Fold(b.Title))
+	case "year":
+		return compare(float64(a.Year), float64(b.Year))
+	case "runtime":
+		return compare(float64(a.Runtime), float64(b.Runtime))
+	case "rating":
+		return compare(a.WeightedRating, b.WeightedRating)
+	case "popularity":
+		return compare(a.Popularity, b.Popularity)
+	default:
+		return compare(float64(a.ID), float64(b.ID))
+	}
+}
+
+func compare(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/data"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+// newIndexedMock wraps a mock serving the movies like the database would,
+// honouring only the IDs restriction.
+func newIndexedMock(movies ...*models.Movie) (*IndexedMovieProvider, *[]data.Search) {
+	var calls []data.Search
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		calls = append(calls, s)
+		var out []*models.Movie
+		for _, m := range movies {
+			for _, id := range s.IDs {
+				if id == m.ID {
+					out = append(out, m)
+				}
+			}
+			if s.IDs == nil {
+				out = append(out, m)
+			}
+		}
+		meta := models.New(len(out), 1, len(movies))
+		return out, &meta, nil
+	}
+	mock.DeleteMovieMock = func(int64) error { return nil }
+	return NewIndexedMovieProvider(mock), &calls
+}
+
+func TestIndexedMovieProvider_GetAll_RanksByRelevance(t *testing.T) {
+	is := is2.New(t)
+
+	p, calls := newIndexedMock(
+		&models.Movie{ID: 1, Title: "The Godfather", Year: 1972, Genres: []string{"Crime"}},
+		&models.Movie{ID: 2, Title: "The Godfather Part II", Year: 1974, Genres: []string{"Crime"}},
+		&models.Movie{ID: 3, Title: "Fight Club", Year: 1999, Genres: []string{"Drama"}},
+	)
+	is.NoErr(p.Rebuild())
+
+	params := data.Search{
+		Query:   "godfather",
+		Filters: data.Filter{Page: 1, PageSize: 1, Sort: "-relevance", SortSafelist: []string{"-relevance", "year"}},
+	}
+	movies, meta, err := p.GetAll(params)
+	is.NoErr(err)
+	is.Equal(len(movies), 1)
+	is.Equal(movies[0].ID, int64(1))
+	is.Equal(meta.TotalRecords, 2)
+	is.Equal((*calls)[len(*calls)-1].IDs, []int64{1, 2})
+
+	params.Filters.Sort = "year"
+	params.Filters.Page = 2
+	movies, _, err = p.GetAll(params)
+	is.NoErr(err)
+	is.Equal(movies[0].ID, int64(2))
+}
+
+func TestIndexedMovieProvider_DeleteRemovesFromIndex(t *testing.T) {
+	is := is2.New(t)
+
+	p, calls := newIndexedMock(&models.Movie{ID: 3, Title: "Fight Club", Genres: []string{"Drama"}})
+	is.NoErr(p.Rebuild())
+	is.NoErr(p.Delete(3))
+
+	n := len(*calls)
+	movies, _, err := p.GetAll(data.Search{Query: "fight", Filters: data.Filter{Page: 1, PageSize: 20, Sort: "-relevance", SortSafelist: []string{"-relevance"}}})
+	is.NoErr(err)
+	is.Equal(len(movies), 0)
+	is.Equal(len(*calls), n) //nothing matched, the database isn't asked
+}
+
+func TestIndexedMovieProvider_SuggestFollowsWrites(t *testing.T) {
+	is := is2.New(t)
+
+	p, _ := newIndexedMock(&models.Movie{ID: 1, Title: "The Godfather"})
+	is.NoErr(p.Rebuild())
+
+	suggestions, err := p.Suggest("godfahter", 5)
+	is.NoErr(err)
+	is.Equal(suggestions, []models.Suggestion{{ID: 1, Title: "The Godfather"}})
+
+	is.NoErr(p.Delete(1))
+	suggestions, err = p.Suggest("godf", 5)
+	is.NoErr(err)
+	is.Equal(len(suggestions), 0)
+}
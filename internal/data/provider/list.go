@@ -0,0 +1,388 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var (
+	ErrDuplicateListItem = errors.New("movie already in the list")
+	ErrListFull          = errors.New("list is full")
+	ErrInvalidOrder      = errors.New("order must contain every movie of the list once")
+)
+
+type IListProvider interface {
+	// Get returns the list along with its items, in order.
+	Get(int64) (*models.List, error)
+	// GetBySlug returns the list shared under the slug, along with its items.
+	GetBySlug(string) (*models.List, error)
+	// GetAll returns lists without their items.
+	GetAll(data.ListSearch) ([]*models.List, *models.Metadata, error)
+	// Insert saves a new list under a fresh share slug.
+	Insert(*models.List) (*models.List, error)
+	Update(*models.List) error
+	Delete(int64) error
+	// AddItem puts the movie at the position of the item in the list, moving
+	// down the ones after it, or at the end when the position is 0. It returns
+	// ErrRecordNotFound for an unknown movie.
+	AddItem(listID int64, item *models.ListItem) error
+	// UpdateNote changes the note of an item.
+	UpdateNote(listID int64, item *models.ListItem) error
+	RemoveItem(listID, movieID int64) error
+	// Reorder sorts the items of the list as the given movies, at once.
+	Reorder(listID int64, movieIDs []int64) error
+}
+
+type ListProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewListProvider(db *sql.DB, set *config.Settings) IListProvider {
+	return &ListProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *ListProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+const listColumns = `l.id, l.created_at, l.user_id, l.name, l.description, l.visibility, l.slug, l.version,
+      (SELECT COUNT(*) FROM list_items i WHERE i.list_id = l.id)`
+
+// scanList reads a row of listColumns.
+func scanList(row interface{ Scan(...interface{}) error }, dest ...interface{}) (*models.List, error) {
+	var l models.List
+	dest = append(dest, &l.ID, &l.CreatedAt, &l.UserID, &l.Name, &l.Description, &l.Visibility, &l.Slug, &l.Version, &l.ItemCount)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+func (p *ListProvider) Get(id int64) (*models.List, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	return p.get("l.id = ?", id)
+}
+
+func (p *ListProvider) GetBySlug(slug string) (*models.List, error) {
+	if slug == "" {
+		return nil, ErrRecordNotFound
+	}
+	return p.get("l.slug = ?", slug)
+}
+
+func (p *ListProvider) get(where string, arg interface{}) (*models.List, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	row := p.db.QueryRowContext(ctx, "SELECT "+listColumns+" FROM lists l WHERE "+where, arg)
+	l, err := scanList(row)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	//movies deleted meanwhile leave gaps in the stored positions, so they are
+	//numbered again on the way out
+	query := `
+      SELECT i.movie_id, m.title, m.year, ROW_NUMBER() OVER (ORDER BY i.position, i.added_at), i.note, i.added_at
+      FROM list_items i JOIN Movie m ON m.Id = i.movie_id
+      WHERE i.list_id = ?
+      ORDER BY i.position, i.added_at;`
+	rows, err := p.db.QueryContext(ctx, query, l.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	l.Items = []*models.ListItem{}
+	for rows.Next() {
+		var item models.ListItem
+		if err = rows.Scan(&item.MovieID, &item.Title, &item.Year, &item.Position, &item.Note, &item.AddedAt); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		l.Items = append(l.Items, &item)
+	}
+	return l, rows.Err()
+}
+
+func (p *ListProvider) GetAll(params data.ListSearch) ([]*models.List, *models.Metadata, error) {
+	f := params.Filters
+	where, args := "l.user_id = ?", []interface{}{params.UserID}
+	if params.UserID == 0 {
+		where, args = "l.visibility = ?", []interface{}{models.ListPublic}
+	}
+	query := fmt.Sprintf(`
+      SELECT COUNT(*) OVER(), %s
+      FROM lists l
+      WHERE %s AND LOWER(l.name) LIKE LOWER(?)
+      ORDER BY %s
+      LIMIT %d OFFSET %d;`, listColumns, where, sortClause(f.GetSortFields(), false), f.GetPageSize(), f.GetPageOffset())
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, append(args, "%"+params.Name+"%")...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	lists := []*models.List{}
+	totalRecords := 0
+	for rows.Next() {
+		l, err := scanList(rows, &totalRecords)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		lists = append(lists, l)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	meta := models.New(totalRecords, f.Page, f.GetPageSize())
+	return lists, &meta, nil
+}
+
+func (p *ListProvider) Insert(l *models.List) (*models.List, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+		INSERT INTO lists (user_id, name, description, visibility, slug)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at, version`
+	//slugs are random, a clash is unlikely but not impossible
+	for attempt := 0; ; attempt++ {
+		slug, err := models.GenerateSlug()
+		if err != nil {
+			return nil, err
+		}
+		err = p.db.QueryRowContext(ctx, query, l.UserID, l.Name, l.Description, l.Visibility, slug).
+			Scan(&l.ID, &l.CreatedAt, &l.Version)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errDupEntry && attempt < 3 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.Slug = slug
+		l.Items = []*models.ListItem{}
+		return l, nil
+	}
+}
+
+// Update saves the list if nobody changed it meanwhile, and bumps its version.
+func (p *ListProvider) Update(l *models.List) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := "UPDATE lists SET name = ?, description = ?, visibility = ?, slug = ?, version = version + 1 WHERE id = ? AND version = ?"
+	res, err := p.db.ExecContext(ctx, query, l.Name, l.Description, l.Visibility, l.Slug, l.ID, l.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	l.Version++
+	return nil
+}
+
+// Delete removes the list along with its items.
+func (p *ListProvider) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM lists WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *ListProvider) AddItem(listID int64, item *models.ListItem) error {
+	return p.edit(listID, func(ctx context.Context, tx *sql.Tx) error {
+		var count, last int
+		query := "SELECT COUNT(*), COALESCE(MAX(position), 0) FROM list_items WHERE list_id = ?"
+		if err := tx.QueryRowContext(ctx, query, listID).Scan(&count, &last); err != nil {
+			return err
+		}
+		if count >= models.MaxListItems {
+			return ErrListFull
+		}
+
+		//the stored position of the nth item, which the new one takes
+		position := last + 1
+		if item.Position > 0 && item.Position <= count {
+			query = "SELECT position FROM list_items WHERE list_id = ? ORDER BY position, added_at LIMIT 1 OFFSET ?"
+			if err := tx.QueryRowContext(ctx, query, listID, item.Position-1).Scan(&position); err != nil {
+				return err
+			}
+			query = "UPDATE list_items SET position = position + 1 WHERE list_id = ? AND position >= ?"
+			if _, err := tx.ExecContext(ctx, query, listID, position); err != nil {
+				return err
+			}
+		} else {
+			item.Position = count + 1
+		}
+
+		query = `
+			INSERT INTO list_items (list_id, movie_id, position, note)
+			VALUES (?, ?, ?, ?)
+			RETURNING added_at`
+		err := tx.QueryRowContext(ctx, query, listID, item.MovieID, position, item.Note).Scan(&item.AddedAt)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) {
+			switch mysqlErr.Number {
+			case errDupEntry:
+				return ErrDuplicateListItem
+			case errNoReferencedRow:
+				return ErrRecordNotFound
+			}
+		}
+		return err
+	})
+}
+
+func (p *ListProvider) UpdateNote(listID int64, item *models.ListItem) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	//affected rows don't count unchanged ones, so check the item exists first
+	var exists bool
+	query := "SELECT EXISTS(SELECT 1 FROM list_items WHERE list_id = ? AND movie_id = ?)"
+	if err := p.db.QueryRowContext(ctx, query, listID, item.MovieID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRecordNotFound
+	}
+	_, err := p.db.ExecContext(ctx, "UPDATE list_items SET note = ? WHERE list_id = ? AND movie_id = ?", item.Note, listID, item.MovieID)
+	return err
+}
+
+func (p *ListProvider) RemoveItem(listID, movieID int64) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM list_items WHERE list_id = ? AND movie_id = ?", listID, movieID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *ListProvider) Reorder(listID int64, movieIDs []int64) error {
+	return p.edit(listID, func(ctx context.Context, tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, "SELECT movie_id FROM list_items WHERE list_id = ?", listID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		current := make(map[int64]bool)
+		for rows.Next() {
+			var id int64
+			if err = rows.Scan(&id); err != nil {
+				return err
+			}
+			current[id] = true
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if len(current) != len(movieIDs) {
+			return ErrInvalidOrder
+		}
+		for _, id := range movieIDs {
+			if !current[id] {
+				return ErrInvalidOrder
+			}
+		}
+		if len(movieIDs) == 0 {
+			return nil
+		}
+
+		//FIELD tells the position of the movie in the new order, starting at 1
+		placeholders := make([]string, len(movieIDs))
+		args := make([]interface{}, 0, len(movieIDs)+1)
+		for i, id := range movieIDs {
+			placeholders[i] = "?"
+			args = append(args, id)
+		}
+		query := fmt.Sprintf("UPDATE list_items SET position = FIELD(movie_id, %s) WHERE list_id = ?", strings.Join(placeholders, ", "))
+		_, err = tx.ExecContext(ctx, query, append(args, listID)...)
+		return err
+	})
+}
+
+// edit runs fn with the list locked, so that concurrent changes to its items
+// don't mix up their positions.
+func (p *ListProvider) edit(listID int64, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT id FROM lists WHERE id = ? FOR UPDATE", listID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	if err = fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,72 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestListProvider_ItemsKeepTheirOrder(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	users := NewUserProvider(testDB, envConfigs)
+	lists := NewListProvider(testDB, envConfigs)
+
+	u := &models.User{Name: "Collector", Email: "collector@example.com"}
+	is.NoErr(u.Password.Set("pa55word"))
+	u, err := users.Insert(u)
+	is.NoErr(err)
+	defer users.Delete(u.ID)
+
+	var ids []int64
+	for _, title := range []string{"Ran", "Ikiru", "Kagemusha"} {
+		m, err := movies.Insert(&models.Movie{Title: title, Runtime: 140, Year: 1980, Genres: []string{"Drama"}, Version: 1})
+		is.NoErr(err)
+		defer movies.Delete(m.ID)
+		ids = append(ids, m.ID)
+	}
+
+	l, err := lists.Insert(&models.List{UserID: u.ID, Name: "Kurosawa", Visibility: models.ListUnlisted})
+	is.NoErr(err)
+	is.Equal(len(l.Slug), 26)
+
+	is.NoErr(lists.AddItem(l.ID, &models.ListItem{MovieID: ids[0]}))
+	is.NoErr(lists.AddItem(l.ID, &models.ListItem{MovieID: ids[1]}))
+	//goes first, pushing the others down
+	is.NoErr(lists.AddItem(l.ID, &models.ListItem{MovieID: ids[2], Position: 1, Note: "start here"}))
+	is.Equal(lists.AddItem(l.ID, &models.ListItem{MovieID: ids[0]}), ErrDuplicateListItem)
+	is.Equal(lists.AddItem(l.ID, &models.ListItem{MovieID: ids[2] + 1000}), ErrRecordNotFound)
+
+	shared, err := lists.GetBySlug(l.Slug)
+	is.NoErr(err)
+	is.Equal(titles(shared), []string{"Kagemusha", "Ran", "Ikiru"})
+	is.Equal(shared.Items[0].Note, "start here")
+
+	is.Equal(lists.Reorder(l.ID, []int64{ids[0], ids[1]}), ErrInvalidOrder)
+	is.NoErr(lists.Reorder(l.ID, []int64{ids[1], ids[0], ids[2]}))
+
+	//deleting a movie takes it out of every list
+	is.NoErr(movies.Delete(ids[0]))
+	stored, err := lists.Get(l.ID)
+	is.NoErr(err)
+	is.Equal(titles(stored), []string{"Ikiru", "Kagemusha"})
+	is.Equal(stored.Items[1].Position, 2)
+	is.Equal(stored.ItemCount, 2)
+
+	is.NoErr(lists.Delete(l.ID))
+	_, err = lists.Get(l.ID)
+	is.Equal(err, ErrRecordNotFound)
+}
+
+func titles(l *models.List) []string {
+	var titles []string
+	for _, item := range l.Items {
+		titles = append(titles, item.Title)
+	}
+	return titles
+}
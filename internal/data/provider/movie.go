@@ -6,11 +6,11 @@ import (
 	"errors"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
+	"strconv"
 	"strings"
 	"time"
 	"yamda_go/internal/config"
 	"yamda_go/internal/data"
-	"yamda_go/internal/jsonlog"
 	"yamda_go/internal/models"
 )
 
@@ -22,6 +22,9 @@ var (
 type IMovieProvider interface {
 	Get(int64) (*models.Movie, error)
 	GetAll(data.Search) ([]*models.Movie, *models.Metadata, error)
+	Search(data.TextSearch) ([]*models.MovieMatch, *models.Metadata, error)
+	Suggest(prefix string, limit int) ([]models.Suggestion, error)
+	Facets(data.Search) (models.Facets, error)
 	Insert(*models.Movie) (*models.Movie, error)
 	Update(models.Movie) error
 	Delete(int64) error
@@ -32,21 +35,7 @@ type MovieProvider struct {
 	configs *config.Settings
 }
 
-func NewMovieProvider(set *config.Settings, log *jsonlog.Logger) IMovieProvider {
-	db, err := sql.Open(set.DriverName, set.ConnString)
-	if err != nil {
-		log.PrintFatal(err, nil)
-	}
-
-	//validate connection to database is open correctly
-	if err = db.Ping(); err != nil {
-		log.PrintFatal(err, nil)
-	}
-
-	db.SetConnMaxLifetime(time.Minute * time.Duration(set.ConnMaxLifetime))
-	db.SetMaxOpenConns(set.ConnMaxOpen)
-	db.SetMaxIdleConns(set.ConnMaxIdle)
-
+func NewMovieProvider(db *sql.DB, set *config.Settings) IMovieProvider {
 	return &MovieProvider{
 		db:      db,
 		configs: set,
@@ -61,7 +50,7 @@ func (p *MovieProvider) Get(id int64) (*models.Movie, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
 	defer cancel()
 
-	query := "SELECT sleep(10), Id, created_at, title, year, runtime, genres, version FROM Movie WHERE Id=?"
+	query := "SELECT sleep(10), Id, created_at, title, year, runtime, " + movieGenres + ", version, rating_sum, rating_count, rating, popularity FROM Movie WHERE Id=?"
 	stmt, err := p.db.Prepare(query)
 	if err != nil {
 		switch {
@@ -89,9 +78,13 @@ func (p *MovieProvider) Get(id int64) (*models.Movie, error) {
 		Runtime  int32
 		Genres   string
 		Version  int
+		Sum      int
+		Votes    int
+		Rating   float64
+		Pop      float64
 	}{}
 
-	if err = row.Scan(&tmp.sleep, &tmp.ID, &tmp.CreateAt, &tmp.Title, &tmp.Year, &tmp.Runtime, &tmp.Genres, &tmp.Version); err != nil {
+	if err = row.Scan(&tmp.sleep, &tmp.ID, &tmp.CreateAt, &tmp.Title, &tmp.Year, &tmp.Runtime, &tmp.Genres, &tmp.Version, &tmp.Sum, &tmp.Votes, &tmp.Rating, &tmp.Pop); err != nil {
 		return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
 	}
 
@@ -100,44 +93,91 @@ func (p *MovieProvider) Get(id int64) (*models.Movie, error) {
 		ID:        tmp.ID,
 		Title:     tmp.Title,
 		Runtime:   models.Runtime(tmp.Runtime),
-		Genres:    strings.Split(tmp.Genres, ","),
 		Year:      tmp.Year,
 		Version:   tmp.Version,
 		CreatedAt: tmp.CreateAt,
+		//ratings
+		AverageRating:  models.AverageRating(tmp.Sum, tmp.Votes),
+		Votes:          tmp.Votes,
+		WeightedRating: tmp.Rating,
+		Popularity:     tmp.Pop,
+	}
+	if tmp.Genres != "" {
+		m.Genres = strings.Split(tmp.Genres, ",")
 	}
 
 	return &m, nil
 }
 
 func (p *MovieProvider) Insert(m *models.Movie) (*models.Movie, error) {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO Movie (title, runtime, genres, year, version)
-		VALUES (?, ?, ?, ?, ?)
+		INSERT INTO Movie (title, runtime, year, version)
+		VALUES (?, ?, ?, ?)
 		RETURNING ID, created_at, version`
-	stmtIns, err := p.db.Prepare(query)
+	args := []interface{}{m.Title, m.Runtime, m.Year, m.Version}
+	err = tx.QueryRow(query, args...).Scan(&m.ID, &m.CreatedAt, &m.Version)
 	if err != nil {
 		return nil, err
 	}
-	defer stmtIns.Close()
-	args := []interface{}{m.Title, 157, strings.Join(m.Genres, ", "), m.Year, m.Version}
-	err = stmtIns.QueryRow(args...).Scan(&m.ID, &m.CreatedAt, &m.Version)
-	if err != nil {
+	if err = setMovieGenres(tx, m); err != nil {
 		return nil, err
 	}
-	return m, nil
+	return m, tx.Commit()
 }
 
 func (p *MovieProvider) Update(m models.Movie) error {
-	query := "UPDATE Movie  SET title=?, runtime=?, genres=?, year=?, version=? WHERE id=? AND version=?;"
-	stmtIns, err := p.db.Prepare(query)
+	tx, err := p.db.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmtIns.Close()
-	_, err = stmtIns.Exec(m.Title, m.Runtime, strings.Join(m.Genres, ", "), m.Year, m.Version+1, m.ID, m.Version)
+	defer tx.Rollback()
+
+	query := "UPDATE Movie  SET title=?, runtime=?, year=?, version=? WHERE id=? AND version=?;"
+	res, err := tx.Exec(query, m.Title, m.Runtime, m.Year, m.Version+1, m.ID, m.Version)
 	if err != nil {
 		return err
 	}
+	//someone else edited the movie meanwhile, its genres are left alone as well
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	if err = setMovieGenres(tx, &m); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// movieGenres selects the genre names of a movie, comma separated and in the
+// order they were given.
+const movieGenres = `COALESCE((
+        SELECT GROUP_CONCAT(g.name ORDER BY mg.position SEPARATOR ',')
+        FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+        WHERE mg.movie_id = Movie.Id), '') AS genres`
+
+// setMovieGenres links the movie to its genres, which must be canonical names
+// (see models.GenreCatalog). Unknown names are ignored.
+func setMovieGenres(tx *sql.Tx, m *models.Movie) error {
+	if _, err := tx.Exec("DELETE FROM movie_genres WHERE movie_id = ?", m.ID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO movie_genres (movie_id, genre_id, position)
+		SELECT ?, id, ? FROM genres WHERE name = ?`
+	for i, g := range m.Genres {
+		if _, err := tx.Exec(query, m.ID, i+1, g); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -161,25 +201,54 @@ func (p *MovieProvider) Delete(id int64) error {
 }
 
 func (p *MovieProvider) GetAll(params data.Search) ([]*models.Movie, *models.Metadata, error) {
+	f := params.Filters
+	fields := f.GetSortFields()
+
+	where, args := movieSearchClauses(params)
+
+	count, order := "COUNT(*) OVER()", sortClause(fields, false)
+	limit, offset := f.GetPageSize(), f.GetPageOffset()
+
+	// With a cursor we seek straight to the rows after (or before) it instead of
+	// skipping OFFSET rows, so deep pages stay fast and inserts don't shift pages.
+	// Ties on the sort columns are broken by id, which is always ascending.
+	cur, forward := f.After, true
+	if f.Before != nil {
+		cur, forward = f.Before, false
+	}
+	if cur != nil {
+		clause, clauseArgs := keysetClause(fields, cur, forward)
+		where = append(where, clause)
+		args = append(args, clauseArgs...)
+		if !forward {
+			//walk backwards and flip the page afterwards
+			order = sortClause(fields, true)
+		}
+		//no need to count every matching row, and one extra row tells if there is a next page
+		count, offset = "0", 0
+		limit++
+	}
+
 	query := `
-      SELECT COUNT(*) OVER(), Id, created_at, title, year, runtime, genres, version
+      SELECT %s, %s
       FROM Movie
-      WHERE (LOWER(title) like LOWER(?)) AND (genres LIKE ?)
-      ORDER BY %s %s, id ASC
+      WHERE %s
+      ORDER BY %s
       LIMIT %d OFFSET %d;`
 
+	columns := movieColumns(params.Fields, fields)
 	query = fmt.Sprintf(query,
-		params.Filters.GetSortColumn(),
-		params.Filters.GetSortDirection(),
-		params.Filters.GetPageSize(),
-		params.Filters.GetPageOffset())
+		count,
+		movieSelectList(columns),
+		strings.Join(where, " AND "),
+		order,
+		limit,
+		offset)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	//format params to allow a contains inside query
-	title, genres := "%"+params.Title+"%", "%"+params.Genres+"%"
-	rows, err := p.db.QueryContext(ctx, query, title, genres)
+	rows, err := p.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -199,16 +268,29 @@ func (p *MovieProvider) GetAll(params data.Search) ([]*models.Movie, *models.Met
 			Runtime   int32
 			Genres    string
 			Version   int
+			Sum       int
+			Votes     int
+			Rating    float64
+			Pop       float64
 		}{}
-		if err = rows.Scan(
-			&totalRecords,
-			&m.ID,
-			&m.CreatedAt,
-			&m.Title,
-			&m.Year,
-			&m.Runtime,
-			&m.Genres,
-			&m.Version); err != nil {
+		targets := map[string]interface{}{
+			"id":           &m.ID,
+			"created_at":   &m.CreatedAt,
+			"title":        &m.Title,
+			"year":         &m.Year,
+			"runtime":      &m.Runtime,
+			"genres":       &m.Genres,
+			"version":      &m.Version,
+			"rating_sum":   &m.Sum,
+			"rating_count": &m.Votes,
+			"rating":       &m.Rating,
+			"popularity":   &m.Pop,
+		}
+		dest := []interface{}{&totalRecords}
+		for _, c := range columns {
+			dest = append(dest, targets[c])
+		}
+		if err = rows.Scan(dest...); err != nil {
 			return nil, nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
 		}
 
@@ -217,10 +299,17 @@ func (p *MovieProvider) GetAll(params data.Search) ([]*models.Movie, *models.Met
 			ID:        m.ID,
 			Title:     m.Title,
 			Runtime:   models.Runtime(m.Runtime),
-			Genres:    strings.Split(m.Genres, ","),
 			Year:      m.Year,
 			Version:   m.Version,
 			CreatedAt: m.CreatedAt,
+			//ratings
+			AverageRating:  models.AverageRating(m.Sum, m.Votes),
+			Votes:          m.Votes,
+			WeightedRating: m.Rating,
+			Popularity:     m.Pop,
+		}
+		if m.Genres != "" {
+			movie.Genres = strings.Split(m.Genres, ",")
 		}
 		movies = append(movies, &movie)
 	}
@@ -229,6 +318,429 @@ func (p *MovieProvider) GetAll(params data.Search) ([]*models.Movie, *models.Met
 		return nil, nil, err
 	}
 
-	meta := models.New(totalRecords, params.Filters.Page, params.Filters.GetPageSize())
+	if cur != nil {
+		meta := keysetMetadata(&movies, f, forward, fields)
+		return movies, &meta, nil
+	}
+
+	meta := models.New(totalRecords, f.Page, f.GetPageSize())
+	//hand out cursors as well, so that clients can switch to keyset pagination.
+	if len(movies) > 0 {
+		if meta.CurrentPage < meta.LastPage {
+			meta.Next = f.Cursors.Encode(movieCursor(movies[len(movies)-1], f.Sort, fields))
+		}
+		if meta.CurrentPage > 1 {
+			meta.Prev = f.Cursors.Encode(movieCursor(movies[0], f.Sort, fields))
+		}
+	}
 	return movies, &meta, nil
 }
+
+// Search finds movies whose title matches the query using the FULLTEXT index,
+// in natural language or boolean mode, and returns them with their relevance.
+func (p *MovieProvider) Search(params data.TextSearch) ([]*models.MovieMatch, *models.Metadata, error) {
+	mode := "IN NATURAL LANGUAGE MODE"
+	if params.Mode == data.TextModeBoolean {
+		mode = "IN BOOLEAN MODE"
+	}
+
+	query := `
+      SELECT COUNT(*) OVER(), id, created_at, title, year, runtime, %[5]s, version,
+             rating_sum, rating_count, rating,
+             MATCH(title) AGAINST (? %[1]s) AS relevance
+      FROM Movie
+      WHERE MATCH(title) AGAINST (? %[1]s)
+      ORDER BY %[2]s
+      LIMIT %[3]d OFFSET %[4]d;`
+
+	query = fmt.Sprintf(query,
+		mode,
+		sortClause(params.Filters.GetSortFields(), false),
+		params.Filters.GetPageSize(),
+		params.Filters.GetPageOffset(),
+		movieGenres)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, params.Query, params.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	//goland:noinspection GoPreferNilSlice
+	matches := []*models.MovieMatch{}
+
+	totalRecords := 0
+	for rows.Next() {
+		var (
+			m       models.Movie
+			runtime int32
+			genres  string
+			sum     int
+			score   float64
+		)
+		if err = rows.Scan(&totalRecords, &m.ID, &m.CreatedAt, &m.Title, &m.Year, &runtime, &genres, &m.Version,
+			&sum, &m.Votes, &m.WeightedRating, &score); err != nil {
+			return nil, nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		m.Runtime = models.Runtime(runtime)
+		m.AverageRating = models.AverageRating(sum, m.Votes)
+		if genres != "" {
+			m.Genres = strings.Split(genres, ",")
+		}
+		matches = append(matches, &models.MovieMatch{Movie: &m, Score: score})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	meta := models.New(totalRecords, params.Filters.Page, params.Filters.GetPageSize())
+	return matches, &meta, nil
+}
+
+// Suggest returns the titles starting with the prefix, shortest first. There is
+// no typo tolerance here, see IndexedMovieProvider.
+func (p *MovieProvider) Suggest(prefix string, limit int) ([]models.Suggestion, error) {
+	query := `
+      SELECT id, title
+      FROM Movie
+      WHERE LOWER(title) LIKE LOWER(?)
+      ORDER BY CHAR_LENGTH(title), id
+      LIMIT ?;`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	//escape the LIKE wildcards typed by users
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix)
+	rows, err := p.db.QueryContext(ctx, query, escaped+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suggestions := []models.Suggestion{}
+	for rows.Next() {
+		var s models.Suggestion
+		if err = rows.Scan(&s.ID, &s.Title); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions, rows.Err()
+}
+
+// facetBuckets group the values of the numeric facets, in the order they are listed.
+var facetBuckets = map[string]string{
+	"decade": "CONCAT(FLOOR(year / 10) * 10, 's')",
+	"runtime_bucket": `CASE WHEN runtime < 90 THEN '<90'
+                            WHEN runtime < 120 THEN '90-119'
+                            WHEN runtime < 150 THEN '120-149'
+                            ELSE '150+' END`,
+}
+
+// facetOrder sorts the buckets of the numeric facets by the column behind them.
+var facetOrder = map[string]string{"decade": "MIN(year)", "runtime_bucket": "MIN(runtime)"}
+
+// Facets counts the movies matching the search for each value of the requested
+// facets. The criteria on a facet itself are left out of its own counts, so a
+// client can offer the other values next to the selected ones.
+func (p *MovieProvider) Facets(params data.Search) (models.Facets, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	facets := make(models.Facets)
+	for _, facet := range params.Facets {
+		where, args := movieSearchClauses(params.WithoutFacetFilter(facet))
+
+		var counts []models.FacetCount
+		var err error
+		if facet == "genres" {
+			counts, err = p.genreCounts(ctx, where, args)
+		} else {
+			counts, err = p.bucketCounts(ctx, facet, where, args)
+		}
+		if err != nil {
+			return nil, err
+		}
+		facets[facet] = counts
+	}
+	return facets, nil
+}
+
+// bucketCounts counts the movies in each bucket of a numeric facet.
+func (p *MovieProvider) bucketCounts(ctx context.Context, facet string, where []string, args []interface{}) ([]models.FacetCount, error) {
+	query := fmt.Sprintf(`
+      SELECT %s AS bucket, COUNT(*)
+      FROM Movie
+      WHERE %s
+      GROUP BY bucket
+      ORDER BY %s;`, facetBuckets[facet], strings.Join(where, " AND "), facetOrder[facet])
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.FacetCount{}
+	for rows.Next() {
+		var c models.FacetCount
+		if err = rows.Scan(&c.Value, &c.Count); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// genreCounts counts the movies of each genre, the most common first.
+func (p *MovieProvider) genreCounts(ctx context.Context, where []string, args []interface{}) ([]models.FacetCount, error) {
+	query := fmt.Sprintf(`
+      SELECT g.name, COUNT(*)
+      FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+      WHERE mg.movie_id IN (SELECT Id FROM Movie WHERE %s)
+      GROUP BY g.id, g.name
+      ORDER BY COUNT(*) DESC, g.name;`, strings.Join(where, " AND "))
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.FacetCount{}
+	for rows.Next() {
+		var c models.FacetCount
+		if err = rows.Scan(&c.Value, &c.Count); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// keysetMetadata trims the extra row fetched to detect more results, restores
+// the sort order of pages fetched backwards and builds the cursors around the page.
+func keysetMetadata(movies *[]*models.Movie, f data.Filter, forward bool, fields []data.SortField) models.Metadata {
+	page := *movies
+	hasMore := len(page) > f.GetPageSize()
+	if hasMore {
+		page = page[:f.GetPageSize()]
+	}
+	if !forward {
+		for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+			page[i], page[j] = page[j], page[i]
+		}
+	}
+	*movies = page
+
+	meta := models.Metadata{PageSize: f.GetPageSize()}
+	if len(page) == 0 {
+		return meta
+	}
+	//we came from a cursor, so there is always something on the side we came from.
+	if hasMore || !forward {
+		meta.Next = f.Cursors.Encode(movieCursor(page[len(page)-1], f.Sort, fields))
+	}
+	if hasMore || forward {
+		meta.Prev = f.Cursors.Encode(movieCursor(page[0], f.Sort, fields))
+	}
+	return meta
+}
+
+// movieRatingColumns are the columns behind the rating fields of a movie.
+var movieRatingColumns = map[string][]string{
+	"averageRating": {"rating_sum", "rating_count"},
+	"votes":         {"rating_count"},
+}
+
+// movieColumns returns the columns to select: every column when no field was
+// requested, otherwise the requested ones plus those needed for sorting and cursors.
+func movieColumns(requested []string, sort []data.SortField) []string {
+	all := []string{"id", "created_at", "title", "year", "runtime", "genres", "version", "rating_sum", "rating_count", "rating", "popularity"}
+	if len(requested) == 0 {
+		return all
+	}
+
+	wanted := map[string]bool{"id": true}
+	for _, f := range requested {
+		wanted[f] = true
+		for _, c := range movieRatingColumns[f] {
+			wanted[c] = true
+		}
+	}
+	for _, sf := range sort {
+		wanted[sf.Column] = true
+	}
+
+	var columns []string
+	//keep the table order and only known columns, they end up in the query.
+	for _, c := range all {
+		if wanted[c] {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// movieSelectList turns the columns into the list of a SELECT, genres being
+// read from the movie_genres table.
+func movieSelectList(columns []string) string {
+	list := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if c == "genres" {
+			c = movieGenres
+		}
+		list = append(list, c)
+	}
+	return strings.Join(list, ", ")
+}
+
+// movieHasGenre matches the movies having a genre, whatever name it is given.
+const movieHasGenre = `EXISTS (
+        SELECT 1 FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+        WHERE mg.movie_id = Movie.Id
+          AND (g.name = ? OR g.slug = ? OR g.id IN (SELECT genre_id FROM genre_aliases WHERE alias = ?)))`
+
+// movieHasTranslatedTitle matches the movies with a translated title containing the pattern.
+const movieHasTranslatedTitle = `EXISTS (
+        SELECT 1 FROM movie_translations mt
+        WHERE mt.movie_id = Movie.Id AND LOWER(mt.title) like LOWER(?))`
+
+// movieSearchClauses translates the search criteria into WHERE clauses and their arguments.
+func movieSearchClauses(params data.Search) ([]string, []interface{}) {
+	//format params to allow a contains inside query, in any language
+	title := "%" + params.Title + "%"
+	where := []string{"(LOWER(title) like LOWER(?) OR " + movieHasTranslatedTitle + ")"}
+	args := []interface{}{title, title}
+
+	// Genres can be given by name, slug or alias.
+	if len(params.Genres) > 0 {
+		var genres []string
+		for _, g := range params.Genres {
+			genres = append(genres, movieHasGenre)
+			g = strings.TrimSpace(g)
+			args = append(args, g, g, g)
+		}
+		sep := " OR "
+		if params.GenresMatch == data.GenresMatchAll {
+			sep = " AND "
+		}
+		where = append(where, "("+strings.Join(genres, sep)+")")
+	}
+
+	if params.IDs != nil {
+		var ids []string
+		for _, id := range params.IDs {
+			ids = append(ids, "?")
+			args = append(args, id)
+		}
+		if len(ids) == 0 {
+			ids = append(ids, "NULL") //IN () is not valid SQL, IN (NULL) matches nothing
+		}
+		where = append(where, "id IN ("+strings.Join(ids, ", ")+")")
+	}
+
+	ranges := []struct {
+		clause string
+		value  int
+	}{
+		{"year >= ?", params.YearMin},
+		{"year <= ?", params.YearMax},
+		{"runtime >= ?", params.RuntimeMin},
+		{"runtime <= ?", params.RuntimeMax},
+	}
+	for _, r := range ranges {
+		if r.value > 0 {
+			where = append(where, r.clause)
+			args = append(args, r.value)
+		}
+	}
+	if !params.CreatedAfter.IsZero() {
+		where = append(where, "created_at > ?")
+		args = append(args, params.CreatedAfter.UTC())
+	}
+	return where, args
+}
+
+// sortClause builds the ORDER BY clause for the sort fields, with id as the
+// last tie-breaker. When reversed, every direction is flipped.
+func sortClause(fields []data.SortField, reversed bool) string {
+	var parts []string
+	for _, sf := range fields {
+		parts = append(parts, sf.Column+" "+direction(sf.Direction, reversed))
+	}
+	parts = append(parts, "id "+direction("ASC", reversed))
+	return strings.Join(parts, ", ")
+}
+
+// keysetClause builds the condition selecting the rows after (forward) or
+// before the cursor, following the lexicographic order of the sort fields:
+//
+//	(a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func keysetClause(fields []data.SortField, cur *data.Cursor, forward bool) (string, []interface{}) {
+	var (
+		ors  []string
+		args []interface{}
+	)
+	for i := 0; i <= len(fields); i++ {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, fields[j].Column+" = ?")
+			args = append(args, cursorValue(cur, j))
+		}
+		column, dir, value := "id", "ASC", interface{}(cur.ID)
+		if i < len(fields) {
+			column, dir, value = fields[i].Column, fields[i].Direction, cursorValue(cur, i)
+		}
+		op := ">"
+		if (dir == "DESC") == forward {
+			op = "<"
+		}
+		ands = append(ands, column+" "+op+" ?")
+		args = append(args, value)
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func cursorValue(cur *data.Cursor, i int) interface{} {
+	if i < len(cur.Values) {
+		return cur.Values[i]
+	}
+	return nil
+}
+
+// movieCursor builds a cursor pointing at the given movie for the sort fields.
+func movieCursor(m *models.Movie, sort string, fields []data.SortField) data.Cursor {
+	c := data.Cursor{Sort: sort, ID: m.ID}
+	for _, sf := range fields {
+		switch sf.Column {
+		case "title":
+			c.Values = append(c.Values, m.Title)
+		case "year":
+			c.Values = append(c.Values, strconv.Itoa(int(m.Year)))
+		case "runtime":
+			c.Values = append(c.Values, strconv.Itoa(int(m.Runtime)))
+		case "rating":
+			c.Values = append(c.Values, strconv.FormatFloat(m.WeightedRating, 'g', -1, 64))
+		case "popularity":
+			c.Values = append(c.Values, strconv.FormatFloat(m.Popularity, 'g', -1, 64))
+		default:
+			c.Values = append(c.Values, strconv.FormatInt(m.ID, 10))
+		}
+	}
+	return c
+}
+
+// direction returns the SQL direction, flipped if reversed.
+func direction(dir string, reversed bool) string {
+	if (dir == "DESC") != reversed {
+		return "DESC"
+	}
+	return "ASC"
+}
@@ -6,6 +6,7 @@ package provider
 import (
 	"log"
 	"testing"
+	"yamda_go/internal/data"
 	"yamda_go/internal/models"
 
 	is2 "github.com/matryer/is"
@@ -22,7 +23,7 @@ func setupTestCase() func() {
 		Version: 1,
 	}
 
-	prov := NewMovieProvider(envConfigs, logger)
+	prov := NewMovieProvider(testDB, envConfigs)
 	res, err := prov.Insert(mov)
 	if err != nil {
 		log.Fatal(err)
@@ -47,7 +48,7 @@ func TestProvider_InsertMovie_Ok(t *testing.T) {
 		Version: 1,
 	}
 
-	prov := NewMovieProvider(envConfigs, logger)
+	prov := NewMovieProvider(testDB, envConfigs)
 
 	res, err := prov.Insert(movie)
 	is.NoErr(err)
@@ -71,7 +72,7 @@ func TestMovieProvider_Update_Ok(t *testing.T) {
 	teardown := setupTestCase()
 	defer teardown()
 
-	prov := NewMovieProvider(envConfigs, logger)
+	prov := NewMovieProvider(testDB, envConfigs)
 
 	//update year
 	mov.Year = 2004
@@ -91,13 +92,36 @@ func TestMovieProvider_Update_Ok(t *testing.T) {
 	is.NoErr(err)
 }
 
+func TestMovieProvider_Update_StaleVersionChangesNothing(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupTestCase()
+	defer teardown()
+
+	prov := NewMovieProvider(testDB, envConfigs)
+	before, err := prov.Get(mov.ID)
+	is.NoErr(err)
+
+	stale := *before
+	stale.Version--
+	stale.Year = 2004
+	stale.Genres = []string{"Drama"}
+	is.Equal(prov.Update(stale), ErrEditConflict)
+
+	after, err := prov.Get(mov.ID)
+	is.NoErr(err)
+	is.Equal(after.Year, before.Year)
+	is.Equal(after.Genres, before.Genres)
+	is.Equal(after.Version, before.Version)
+}
+
 func TestMovieProvider_Get_Ok(t *testing.T) {
 	is := is2.New(t)
 
 	teardown := setupTestCase()
 	defer teardown()
 
-	prov := NewMovieProvider(envConfigs, logger)
+	prov := NewMovieProvider(testDB, envConfigs)
 
 	tmp, err := prov.Get(mov.ID)
 	is.NoErr(err)
@@ -107,3 +131,98 @@ func TestMovieProvider_Get_Ok(t *testing.T) {
 	is.Equal(tmp.Year, mov.Year)
 	is.True(tmp.CreatedAt != nil)
 }
+
+func TestMovieProvider_GetAll_KeysetPagination(t *testing.T) {
+	is := is2.New(t)
+
+	prov := NewMovieProvider(testDB, envConfigs)
+
+	var ids []int64
+	for _, year := range []int32{1990, 1991, 1991, 1992} {
+		res, err := prov.Insert(&models.Movie{
+			Title:   "Keyset pagination",
+			Runtime: 100,
+			Year:    year,
+			Genres:  []string{"drama"},
+			Version: 1,
+		})
+		is.NoErr(err)
+		ids = append(ids, res.ID)
+	}
+	defer func() {
+		for _, id := range ids {
+			_ = prov.Delete(id)
+		}
+	}()
+
+	filter := data.Filter{
+		Page:         1,
+		PageSize:     2,
+		Sort:         "-year",
+		SortSafelist: []string{"-year"},
+		Cursors:      data.CursorCodec{Secret: []byte("test")},
+	}
+	search := data.Search{Title: "Keyset pagination", Filters: filter}
+
+	first, meta, err := prov.GetAll(search)
+	is.NoErr(err)
+	is.Equal(len(first), 2)
+	is.Equal(first[0].ID, ids[3])
+	is.Equal(first[1].ID, ids[1])
+	is.True(meta.Next != "")
+
+	after, err := filter.Cursors.Decode(meta.Next)
+	is.NoErr(err)
+	search.Filters.After = &after
+	second, meta, err := prov.GetAll(search)
+	is.NoErr(err)
+	is.Equal(len(second), 2)
+	is.Equal(second[0].ID, ids[2])
+	is.Equal(second[1].ID, ids[0])
+	is.Equal(meta.Next, "")
+	is.True(meta.Prev != "")
+
+	before, err := filter.Cursors.Decode(meta.Prev)
+	is.NoErr(err)
+	search.Filters.After, search.Filters.Before = nil, &before
+	back, _, err := prov.GetAll(search)
+	is.NoErr(err)
+	is.Equal(len(back), 2)
+	is.Equal(back[0].ID, ids[3])
+	is.Equal(back[1].ID, ids[1])
+}
+
+func TestMovieProvider_Facets_ExcludeOwnFilter(t *testing.T) {
+	is := is2.New(t)
+
+	prov := NewMovieProvider(testDB, envConfigs)
+
+	var ids []int64
+	for _, genres := range [][]string{{"drama"}, {"drama", "comedy"}, {"comedy"}} {
+		res, err := prov.Insert(&models.Movie{
+			Title:   "Facet counts",
+			Runtime: 100,
+			Year:    1995,
+			Genres:  genres,
+			Version: 1,
+		})
+		is.NoErr(err)
+		ids = append(ids, res.ID)
+	}
+	defer func() {
+		for _, id := range ids {
+			_ = prov.Delete(id)
+		}
+	}()
+
+	facets, err := prov.Facets(data.Search{
+		Title:       "Facet counts",
+		Genres:      []string{"drama"},
+		GenresMatch: data.GenresMatchAny,
+		Facets:      []string{"genres", "decade"},
+	})
+	is.NoErr(err)
+	//the genre filter doesn't apply to the genre counts, but it does to the others
+	is.Equal(facets["genres"], []models.FacetCount{{Value: "Comedy", Count: 2}, {Value: "Drama", Count: 2}})
+	is.Equal(facets["decade"], []models.FacetCount{{Value: "1990s", Count: 2}})
+}
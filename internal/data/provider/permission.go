@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+)
+
+type IPermissionProvider interface {
+	GetAllForUser(userID int64) (models.Permissions, error)
+	AddForUser(userID int64, codes ...string) error
+}
+
+type PermissionProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewPermissionProvider(db *sql.DB, set *config.Settings) IPermissionProvider {
+	return &PermissionProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *PermissionProvider) GetAllForUser(userID int64) (models.Permissions, error) {
+	query := `
+      SELECT p.code
+      FROM permissions p JOIN users_permissions up ON up.permission_id = p.id
+      WHERE up.user_id = ?
+      ORDER BY p.code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := models.Permissions{}
+	for rows.Next() {
+		var code string
+		if err = rows.Scan(&code); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		permissions = append(permissions, code)
+	}
+	return permissions, rows.Err()
+}
+
+// AddForUser grants the permissions to the user. Unknown codes are ignored.
+func (p *PermissionProvider) AddForUser(userID int64, codes ...string) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	placeholders := make([]string, len(codes))
+	args := []interface{}{userID}
+	for i, c := range codes {
+		placeholders[i] = "?"
+		args = append(args, c)
+	}
+	query := fmt.Sprintf(`
+      INSERT IGNORE INTO users_permissions (user_id, permission_id)
+      SELECT ?, id FROM permissions WHERE code IN (%s)`, strings.Join(placeholders, ", "))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, query, args...)
+	return err
+}
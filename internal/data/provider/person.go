@@ -0,0 +1,293 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrPersonNotFound = errors.New("person not found")
+
+// errNoReferencedRow is the MariaDB error number of a foreign key violated on insert.
+const errNoReferencedRow = 1452
+
+type IPersonProvider interface {
+	Get(int64) (*models.Person, error)
+	GetAll(data.PeopleSearch) ([]*models.Person, *models.Metadata, error)
+	Insert(*models.Person) (*models.Person, error)
+	Update(*models.Person) error
+	Delete(int64) error
+	// Credits returns the credits of each movie, in billing order.
+	Credits(movieIDs ...int64) (map[int64][]*models.Credit, error)
+	// SetCredits replaces the credits of a movie.
+	SetCredits(movieID int64, credits []*models.Credit) error
+	// Filmography returns the credits of a person, the most recent movies first.
+	Filmography(personID int64) ([]*models.Credit, error)
+}
+
+type PersonProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewPersonProvider(db *sql.DB, set *config.Settings) IPersonProvider {
+	return &PersonProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *PersonProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+func (p *PersonProvider) Get(id int64) (*models.Person, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	var (
+		person    models.Person
+		birthYear sql.NullInt32
+	)
+	query := "SELECT id, created_at, name, birth_year, version FROM people WHERE id = ?"
+	err := p.db.QueryRowContext(ctx, query, id).Scan(&person.ID, &person.CreatedAt, &person.Name, &birthYear, &person.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	person.BirthYear = birthYear.Int32
+	return &person, nil
+}
+
+func (p *PersonProvider) GetAll(params data.PeopleSearch) ([]*models.Person, *models.Metadata, error) {
+	f := params.Filters
+	query := fmt.Sprintf(`
+      SELECT COUNT(*) OVER(), id, created_at, name, birth_year, version
+      FROM people
+      WHERE LOWER(name) LIKE LOWER(?)
+      ORDER BY %s
+      LIMIT %d OFFSET %d;`, sortClause(f.GetSortFields(), false), f.GetPageSize(), f.GetPageOffset())
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, "%"+params.Name+"%")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	people := []*models.Person{}
+	totalRecords := 0
+	for rows.Next() {
+		var (
+			person    models.Person
+			birthYear sql.NullInt32
+		)
+		if err = rows.Scan(&totalRecords, &person.ID, &person.CreatedAt, &person.Name, &birthYear, &person.Version); err != nil {
+			return nil, nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		person.BirthYear = birthYear.Int32
+		people = append(people, &person)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	meta := models.New(totalRecords, f.Page, f.GetPageSize())
+	return people, &meta, nil
+}
+
+func (p *PersonProvider) Insert(person *models.Person) (*models.Person, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+		INSERT INTO people (name, birth_year)
+		VALUES (?, ?)
+		RETURNING id, created_at, version`
+	err := p.db.QueryRowContext(ctx, query, person.Name, nullYear(person.BirthYear)).
+		Scan(&person.ID, &person.CreatedAt, &person.Version)
+	if err != nil {
+		return nil, err
+	}
+	return person, nil
+}
+
+// Update saves the person if nobody changed it meanwhile, and bumps its version.
+func (p *PersonProvider) Update(person *models.Person) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := "UPDATE people SET name = ?, birth_year = ?, version = version + 1 WHERE id = ? AND version = ?;"
+	res, err := p.db.ExecContext(ctx, query, person.Name, nullYear(person.BirthYear), person.ID, person.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	person.Version++
+	return nil
+}
+
+// Delete removes the person along with their credits.
+func (p *PersonProvider) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM people WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+func (p *PersonProvider) Credits(movieIDs ...int64) (map[int64][]*models.Credit, error) {
+	credits := make(map[int64][]*models.Credit)
+	if len(movieIDs) == 0 {
+		return credits, nil
+	}
+
+	placeholders := make([]string, len(movieIDs))
+	args := make([]interface{}, len(movieIDs))
+	for i, id := range movieIDs {
+		placeholders[i], args[i] = "?", id
+		credits[id] = []*models.Credit{}
+	}
+	query := fmt.Sprintf(`
+      SELECT c.id, c.movie_id, c.person_id, p.name, c.role, c.character_name, c.billing
+      FROM movie_credits c JOIN people p ON p.id = c.person_id
+      WHERE c.movie_id IN (%s)
+      ORDER BY c.movie_id, c.billing, c.id;`, strings.Join(placeholders, ", "))
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			c       models.Credit
+			movieID int64
+		)
+		if err = rows.Scan(&c.ID, &movieID, &c.PersonID, &c.Name, &c.Role, &c.Character, &c.Billing); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		credits[movieID] = append(credits[movieID], &c)
+	}
+	return credits, rows.Err()
+}
+
+// SetCredits replaces every credit of the movie at once. It returns
+// ErrRecordNotFound for an unknown movie and ErrPersonNotFound when one of the
+// people doesn't exist.
+func (p *PersonProvider) SetCredits(movieID int64, credits []*models.Credit) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM Movie WHERE Id = ? FOR UPDATE)", movieID).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrRecordNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM movie_credits WHERE movie_id = ?", movieID); err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO movie_credits (movie_id, person_id, role, character_name, billing)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id`
+	for _, c := range credits {
+		err = tx.QueryRowContext(ctx, query, movieID, c.PersonID, c.Role, c.Character, c.Billing).Scan(&c.ID)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoReferencedRow {
+			return fmt.Errorf("%w: %d", ErrPersonNotFound, c.PersonID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (p *PersonProvider) Filmography(personID int64) ([]*models.Credit, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	var exists bool
+	if err := p.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM people WHERE id = ?)", personID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+      SELECT c.id, m.Id, m.title, m.year, c.role, c.character_name, c.billing
+      FROM movie_credits c JOIN Movie m ON m.Id = c.movie_id
+      WHERE c.person_id = ?
+      ORDER BY m.year DESC, m.title, c.billing;`
+	rows, err := p.db.QueryContext(ctx, query, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []*models.Credit{}
+	for rows.Next() {
+		var c models.Credit
+		if err = rows.Scan(&c.ID, &c.MovieID, &c.Title, &c.Year, &c.Role, &c.Character, &c.Billing); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		credits = append(credits, &c)
+	}
+	return credits, rows.Err()
+}
+
+// nullYear stores unknown years as NULL.
+func nullYear(year int32) sql.NullInt32 {
+	return sql.NullInt32{Int32: year, Valid: year != 0}
+}
@@ -0,0 +1,82 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"errors"
+	"testing"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestPersonProvider_InsertUpdateDelete_Ok(t *testing.T) {
+	is := is2.New(t)
+
+	prov := NewPersonProvider(testDB, envConfigs)
+
+	p, err := prov.Insert(&models.Person{Name: "Rob Reiner", BirthYear: 1947})
+	is.NoErr(err)
+	is.True(p.ID > 0)
+	is.Equal(1, p.Version)
+
+	p.Name = "Robert Reiner"
+	is.NoErr(prov.Update(p))
+	is.Equal(2, p.Version)
+
+	p.Version = 1
+	is.Equal(prov.Update(p), ErrEditConflict)
+
+	people, meta, err := prov.GetAll(data.PeopleSearch{
+		Name:    "robert rei",
+		Filters: data.Filter{Page: 1, PageSize: 20, Sort: "name", SortSafelist: []string{"name"}},
+	})
+	is.NoErr(err)
+	is.Equal(1, meta.TotalRecords)
+	is.Equal(people[0].Name, "Robert Reiner")
+
+	is.NoErr(prov.Delete(p.ID))
+	is.Equal(prov.Delete(p.ID), ErrRecordNotFound)
+}
+
+func TestPersonProvider_SetCredits_Ok(t *testing.T) {
+	is := is2.New(t)
+
+	people := NewPersonProvider(testDB, envConfigs)
+	movies := NewMovieProvider(testDB, envConfigs)
+
+	m, err := movies.Insert(&models.Movie{Title: "This Is Spinal Tap", Runtime: 82, Year: 1984, Genres: []string{"Drama"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(m.ID)
+	p, err := people.Insert(&models.Person{Name: "Christopher Guest"})
+	is.NoErr(err)
+	defer people.Delete(p.ID)
+
+	err = people.SetCredits(m.ID, []*models.Credit{
+		{PersonID: p.ID, Role: "writer", Billing: 2},
+		{PersonID: p.ID, Role: "actor", Character: "Nigel Tufnel", Billing: 1},
+	})
+	is.NoErr(err)
+
+	credits, err := people.Credits(m.ID)
+	is.NoErr(err)
+	is.Equal(len(credits[m.ID]), 2)
+	is.Equal(credits[m.ID][0].Character, "Nigel Tufnel")
+	is.Equal(credits[m.ID][0].Name, "Christopher Guest")
+
+	films, err := people.Filmography(p.ID)
+	is.NoErr(err)
+	is.Equal(len(films), 2)
+	is.Equal(films[0].Title, "This Is Spinal Tap")
+
+	err = people.SetCredits(m.ID, []*models.Credit{{PersonID: p.ID + 1000, Role: "director", Billing: 1}})
+	is.True(errors.Is(err, ErrPersonNotFound))
+	is.Equal(people.SetCredits(m.ID+1000, nil), ErrRecordNotFound)
+
+	//the failed replacement left the credits untouched
+	credits, err = people.Credits(m.ID)
+	is.NoErr(err)
+	is.Equal(len(credits[m.ID]), 2)
+}
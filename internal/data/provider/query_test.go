@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/data"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestKeysetClause_MultipleFields(t *testing.T) {
+	is := is2.New(t)
+
+	fields := []data.SortField{{Column: "year", Direction: "DESC"}, {Column: "title", Direction: "ASC"}}
+	cur := &data.Cursor{Sort: "-year,title", Values: []string{"1998", "Gothika"}, ID: 7}
+
+	clause, args := keysetClause(fields, cur, true)
+	is.Equal(clause, "((year < ?) OR (year = ? AND title > ?) OR (year = ? AND title = ? AND id > ?))")
+	is.Equal(args, []interface{}{"1998", "1998", "Gothika", "1998", "Gothika", int64(7)})
+
+	clause, _ = keysetClause(fields, cur, false)
+	is.Equal(clause, "((year > ?) OR (year = ? AND title < ?) OR (year = ? AND title = ? AND id < ?))")
+	is.Equal(sortClause(fields, true), "year ASC, title DESC, id DESC")
+}
+
+func TestMovieSearchClauses_GenresAndRanges(t *testing.T) {
+	is := is2.New(t)
+
+	where, args := movieSearchClauses(data.Search{
+		Title:       "go",
+		Genres:      []string{"Drama", "Comedy"},
+		GenresMatch: data.GenresMatchAll,
+		YearMin:     1990,
+		RuntimeMax:  120,
+	})
+
+	is.Equal(where, []string{
+		"(LOWER(title) like LOWER(?) OR " + movieHasTranslatedTitle + ")",
+		"(" + movieHasGenre + " AND " + movieHasGenre + ")",
+		"year >= ?",
+		"runtime <= ?",
+	})
+	is.Equal(args, []interface{}{"%go%", "%go%", "Drama", "Drama", "Drama", "Comedy", "Comedy", "Comedy", 1990, 120})
+}
+
+func TestMovieColumns_OnlyRequestedPlusSortAndID(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(len(movieColumns(nil, nil)), 11)
+	cols := movieColumns([]string{"title"}, []data.SortField{{Column: "year", Direction: "DESC"}})
+	is.Equal(cols, []string{"id", "title", "year"})
+	cols = movieColumns([]string{"title"}, []data.SortField{{Column: "popularity", Direction: "DESC"}})
+	is.Equal(cols, []string{"id", "title", "popularity"})
+}
+
+func TestMovieColumns_RatingFieldsReadTheAggregates(t *testing.T) {
+	is := is2.New(t)
+
+	cols := movieColumns([]string{"averageRating"}, []data.SortField{{Column: "rating", Direction: "DESC"}})
+	is.Equal(cols, []string{"id", "rating_sum", "rating_count", "rating"})
+}
+
+func TestMovieSelectList_ReadsGenresFromJoinTable(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(movieSelectList([]string{"id", "title"}), "id, title")
+	is.Equal(movieSelectList([]string{"id", "genres"}), "id, "+movieGenres)
+}
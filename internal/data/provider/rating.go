@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+)
+
+type IRatingProvider interface {
+	// Set saves the score of the user for the movie, telling if it is their first one.
+	Set(userID int64, r *models.Rating) (bool, error)
+	// Delete removes the score of the user for the movie.
+	Delete(userID, movieID int64) error
+	// GetAllForUser returns the scores of the user, by movie.
+	GetAllForUser(userID int64) (map[int64]int, error)
+	// ForEach hands every rating to fn, user by user and their latest first,
+	// stopping at its first error.
+	ForEach(fn func(userID int64, r models.Rating) error) error
+}
+
+type RatingProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewRatingProvider(db *sql.DB, set *config.Settings) IRatingProvider {
+	return &RatingProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+// weightedRating recomputes the Bayesian average of a movie from its
+// aggregates: as if it had RatingPriorVotes more votes of RatingPriorMean.
+// Movies without votes have none, so they come last when sorting by rating.
+const weightedRating = `
+		UPDATE Movie
+		SET rating = IF(rating_count = 0, 0, (rating_sum + ? * ?) / (rating_count + ?))
+		WHERE Id = ?`
+
+func (p *RatingProvider) Set(userID int64, r *models.Rating) (bool, error) {
+	created := false
+	err := p.vote(r.MovieID, func(tx *sql.Tx) (int, int, error) {
+		var previous int
+		err := tx.QueryRow("SELECT score FROM ratings WHERE user_id = ? AND movie_id = ?", userID, r.MovieID).Scan(&previous)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			created = true
+			_, err = tx.Exec("INSERT INTO ratings (user_id, movie_id, score) VALUES (?, ?, ?)", userID, r.MovieID, r.Score)
+			return r.Score, 1, err
+		case err != nil:
+			return 0, 0, err
+		}
+		_, err = tx.Exec("UPDATE ratings SET score = ?, updated_at = NOW() WHERE user_id = ? AND movie_id = ?", r.Score, userID, r.MovieID)
+		return r.Score - previous, 0, err
+	})
+	return created, err
+}
+
+func (p *RatingProvider) Delete(userID, movieID int64) error {
+	return p.vote(movieID, func(tx *sql.Tx) (int, int, error) {
+		var previous int
+		err := tx.QueryRow("SELECT score FROM ratings WHERE user_id = ? AND movie_id = ?", userID, movieID).Scan(&previous)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return 0, 0, ErrRecordNotFound
+		case err != nil:
+			return 0, 0, err
+		}
+		_, err = tx.Exec("DELETE FROM ratings WHERE user_id = ? AND movie_id = ?", userID, movieID)
+		return -previous, -1, err
+	})
+}
+
+func (p *RatingProvider) GetAllForUser(userID int64) (map[int64]int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, "SELECT movie_id, score FROM ratings WHERE user_id = ?", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make(map[int64]int)
+	for rows.Next() {
+		var (
+			movieID int64
+			score   int
+		)
+		if err = rows.Scan(&movieID, &score); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		scores[movieID] = score
+	}
+	return scores, rows.Err()
+}
+
+// ForEach streams the ratings instead of loading them at once, it isn't bound
+// to the timeout of requests. Each user's latest ratings come first, those are
+// the ones the training keeps when a user rated too many movies.
+func (p *RatingProvider) ForEach(fn func(userID int64, r models.Rating) error) error {
+	rows, err := p.db.Query("SELECT user_id, movie_id, score FROM ratings ORDER BY user_id, updated_at DESC, movie_id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			userID int64
+			r      models.Rating
+		)
+		if err = rows.Scan(&userID, &r.MovieID, &r.Score); err != nil {
+			return fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		if err = fn(userID, r); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// vote runs fn with the movie locked, so that concurrent votes don't lose
+// updates, then applies the changes fn returns to the sum of the scores and
+// to the number of votes of the movie.
+func (p *RatingProvider) vote(movieID int64, fn func(tx *sql.Tx) (sum, votes int, err error)) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRowContext(ctx, "SELECT Id FROM Movie WHERE Id = ? FOR UPDATE", movieID).Scan(&id)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	sum, votes, err := fn(tx)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE Movie SET rating_sum = rating_sum + ?, rating_count = rating_count + ? WHERE Id = ?"
+	if _, err = tx.ExecContext(ctx, query, sum, votes, movieID); err != nil {
+		return err
+	}
+	m, c := p.configs.RatingPriorVotes, p.configs.RatingPriorMean
+	if _, err = tx.ExecContext(ctx, weightedRating, m, c, m, movieID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
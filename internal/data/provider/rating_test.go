@@ -0,0 +1,61 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestRatingProvider_AggregatesFollowVotes(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	users := NewUserProvider(testDB, envConfigs)
+	ratings := NewRatingProvider(testDB, envConfigs)
+
+	m, err := movies.Insert(&models.Movie{Title: "Paterson", Runtime: 118, Year: 2016, Genres: []string{"Drama"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(m.ID)
+
+	var voters []int64
+	for _, email := range []string{"ron@example.com", "jim@example.com"} {
+		u := &models.User{Name: "Voter", Email: email}
+		is.NoErr(u.Password.Set("pa55word"))
+		u, err = users.Insert(u)
+		is.NoErr(err)
+		defer users.Delete(u.ID)
+		voters = append(voters, u.ID)
+	}
+
+	created, err := ratings.Set(voters[0], &models.Rating{MovieID: m.ID, Score: 10})
+	is.NoErr(err)
+	is.True(created)
+	created, err = ratings.Set(voters[1], &models.Rating{MovieID: m.ID, Score: 6})
+	is.NoErr(err)
+	is.True(created)
+	//changing a vote doesn't count twice
+	created, err = ratings.Set(voters[1], &models.Rating{MovieID: m.ID, Score: 7})
+	is.NoErr(err)
+	is.True(!created)
+
+	stored, err := movies.Get(m.ID)
+	is.NoErr(err)
+	is.Equal(stored.Votes, 2)
+	is.Equal(stored.AverageRating, 8.5)
+	prior := float64(envConfigs.RatingPriorVotes)
+	is.Equal(stored.WeightedRating, (17+prior*envConfigs.RatingPriorMean)/(2+prior))
+
+	is.NoErr(ratings.Delete(voters[0], m.ID))
+	is.Equal(ratings.Delete(voters[0], m.ID), ErrRecordNotFound)
+	stored, err = movies.Get(m.ID)
+	is.NoErr(err)
+	is.Equal(stored.Votes, 1)
+	is.Equal(stored.AverageRating, 7.0)
+
+	_, err = ratings.Set(voters[0], &models.Rating{MovieID: m.ID + 1000, Score: 5})
+	is.Equal(err, ErrRecordNotFound)
+}
@@ -0,0 +1,158 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrRelationCycle = errors.New("relation would close a cycle")
+
+type IRelationProvider interface {
+	// Related returns the movies related to each movie, either way, by year.
+	Related(movieIDs ...int64) (map[int64][]*models.RelatedMovie, error)
+	// Set links the movies, replacing the kind of their relation if they
+	// already were. It returns ErrRecordNotFound for an unknown movie and
+	// ErrRelationCycle for a sequel of one of its own sequels.
+	Set(*models.Relation) error
+	// Delete removes the relation of the movie to the related one.
+	Delete(movieID, relatedID int64) error
+}
+
+type RelationProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewRelationProvider(db *sql.DB, set *config.Settings) IRelationProvider {
+	return &RelationProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *RelationProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+func (p *RelationProvider) Related(movieIDs ...int64) (map[int64][]*models.RelatedMovie, error) {
+	related := make(map[int64][]*models.RelatedMovie)
+	if len(movieIDs) == 0 {
+		return related, nil
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	placeholders := make([]string, len(movieIDs))
+	args := make([]interface{}, len(movieIDs))
+	for i, id := range movieIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	in := strings.Join(placeholders, ", ")
+	//the relations of the movies, then the relations to them
+	query := `
+      SELECT r.movie_id, m.Id, m.title, m.year, r.kind, false
+      FROM movie_relations r JOIN Movie m ON m.Id = r.related_id
+      WHERE r.movie_id IN (` + in + `)
+      UNION ALL
+      SELECT r.related_id, m.Id, m.title, m.year, r.kind, true
+      FROM movie_relations r JOIN Movie m ON m.Id = r.movie_id
+      WHERE r.related_id IN (` + in + `)
+      ORDER BY 4, 2;`
+	rows, err := p.db.QueryContext(ctx, query, append(args, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			movieID  int64
+			kind     string
+			reversed bool
+			m        models.RelatedMovie
+		)
+		if err = rows.Scan(&movieID, &m.ID, &m.Title, &m.Year, &kind, &reversed); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		m.Role = models.RelationRole(kind, reversed)
+		related[movieID] = append(related[movieID], &m)
+	}
+	return related, rows.Err()
+}
+
+func (p *RelationProvider) Set(r *models.Relation) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if r.Kind == models.RelationSequelOf {
+		//sequels are checked and added one at a time, so that two of them
+		//can't close a cycle together
+		rows, err := tx.QueryContext(ctx, "SELECT movie_id, related_id FROM movie_relations WHERE kind = ? FOR UPDATE", models.RelationSequelOf)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		links := make(map[int64][]int64)
+		for rows.Next() {
+			var from, to int64
+			if err = rows.Scan(&from, &to); err != nil {
+				return fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+			}
+			links[from] = append(links[from], to)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+		if models.ClosesCycle(links, r.MovieID, r.RelatedID) {
+			return ErrRelationCycle
+		}
+	}
+
+	query := `
+		INSERT INTO movie_relations (movie_id, related_id, kind)
+		VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE kind = VALUES(kind)`
+	_, err = tx.ExecContext(ctx, query, r.MovieID, r.RelatedID, r.Kind)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoReferencedRow {
+		return ErrRecordNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (p *RelationProvider) Delete(movieID, relatedID int64) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM movie_relations WHERE movie_id = ? AND related_id = ?", movieID, relatedID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestRelationProvider_RejectsSequelCycles(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	relations := NewRelationProvider(testDB, envConfigs)
+
+	var ids []int64
+	for _, m := range []*models.Movie{
+		{Title: "Alien", Runtime: 117, Year: 1979, Genres: []string{"Horror"}, Version: 1},
+		{Title: "Aliens", Runtime: 137, Year: 1986, Genres: []string{"Action"}, Version: 1},
+		{Title: "Alien 3", Runtime: 114, Year: 1992, Genres: []string{"Horror"}, Version: 1},
+	} {
+		m, err := movies.Insert(m)
+		is.NoErr(err)
+		defer movies.Delete(m.ID)
+		ids = append(ids, m.ID)
+	}
+	alien, aliens, alien3 := ids[0], ids[1], ids[2]
+
+	is.NoErr(relations.Set(&models.Relation{MovieID: aliens, RelatedID: alien, Kind: models.RelationSequelOf}))
+	is.NoErr(relations.Set(&models.Relation{MovieID: alien3, RelatedID: aliens, Kind: models.RelationSequelOf}))
+	is.Equal(relations.Set(&models.Relation{MovieID: alien, RelatedID: alien3, Kind: models.RelationSequelOf}), ErrRelationCycle)
+	//other kinds don't make chains
+	is.NoErr(relations.Set(&models.Relation{MovieID: alien, RelatedID: alien3, Kind: models.RelationSpinOffOf}))
+	is.Equal(relations.Set(&models.Relation{MovieID: alien, RelatedID: alien3 + 1000, Kind: models.RelationRemakeOf}), ErrRecordNotFound)
+
+	related, err := relations.Related(aliens)
+	is.NoErr(err)
+	is.Equal(len(related[aliens]), 2)
+	is.Equal(*related[aliens][0], models.RelatedMovie{ID: alien, Title: "Alien", Year: 1979, Role: "prequel"})
+	is.Equal(*related[aliens][1], models.RelatedMovie{ID: alien3, Title: "Alien 3", Year: 1992, Role: "sequel"})
+
+	is.NoErr(relations.Delete(alien3, aliens))
+	is.Equal(relations.Delete(alien3, aliens), ErrRecordNotFound)
+}
@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+var ErrDuplicateReview = errors.New("duplicate review")
+
+type IReviewProvider interface {
+	Get(int64) (*models.Review, error)
+	GetAll(data.ReviewSearch) ([]*models.Review, *models.Metadata, error)
+	// Insert saves a new review. It returns ErrRecordNotFound for an unknown
+	// movie and ErrDuplicateReview when the user already reviewed it.
+	Insert(*models.Review) (*models.Review, error)
+	// Moderate saves the new status of the review if nobody changed it meanwhile.
+	Moderate(r *models.Review, moderatorID int64) error
+	// SetHelpful records whether the user found the review helpful, keeping
+	// the count of the review up to date.
+	SetHelpful(reviewID, userID int64, helpful bool) error
+}
+
+type ReviewProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewReviewProvider(db *sql.DB, set *config.Settings) IReviewProvider {
+	return &ReviewProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *ReviewProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+const reviewColumns = "id, created_at, movie_id, user_id, body, status, flags, helpful, COALESCE(moderated_by, 0), version"
+
+// scanReview reads a row of reviewColumns.
+func scanReview(row interface{ Scan(...interface{}) error }, dest ...interface{}) (*models.Review, error) {
+	var (
+		r     models.Review
+		flags string
+	)
+	dest = append(dest, &r.ID, &r.CreatedAt, &r.MovieID, &r.UserID, &r.Body, &r.Status, &flags, &r.Helpful, &r.ModeratedBy, &r.Version)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if flags != "" {
+		r.Flags = strings.Split(flags, ",")
+	}
+	return &r, nil
+}
+
+func (p *ReviewProvider) Get(id int64) (*models.Review, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	row := p.db.QueryRowContext(ctx, "SELECT "+reviewColumns+" FROM reviews WHERE id = ?", id)
+	r, err := scanReview(row)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (p *ReviewProvider) GetAll(params data.ReviewSearch) ([]*models.Review, *models.Metadata, error) {
+	f := params.Filters
+	query := fmt.Sprintf(`
+      SELECT COUNT(*) OVER(), %s
+      FROM reviews
+      WHERE movie_id = ? AND status = ?
+      ORDER BY %s
+      LIMIT %d OFFSET %d;`, reviewColumns, sortClause(f.GetSortFields(), false), f.GetPageSize(), f.GetPageOffset())
+
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rows, err := p.db.QueryContext(ctx, query, params.MovieID, params.Status)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	totalRecords := 0
+	for rows.Next() {
+		r, err := scanReview(rows, &totalRecords)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		reviews = append(reviews, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	meta := models.New(totalRecords, f.Page, f.GetPageSize())
+	return reviews, &meta, nil
+}
+
+func (p *ReviewProvider) Insert(r *models.Review) (*models.Review, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+		INSERT INTO reviews (movie_id, user_id, body, status, flags)
+		VALUES (?, ?, ?, ?, ?)
+		RETURNING id, created_at, helpful, version`
+	err := p.db.QueryRowContext(ctx, query, r.MovieID, r.UserID, r.Body, r.Status, strings.Join(r.Flags, ",")).
+		Scan(&r.ID, &r.CreatedAt, &r.Helpful, &r.Version)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case errDupEntry:
+			return nil, ErrDuplicateReview
+		case errNoReferencedRow:
+			return nil, ErrRecordNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (p *ReviewProvider) Moderate(r *models.Review, moderatorID int64) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := "UPDATE reviews SET status = ?, moderated_by = ?, version = version + 1 WHERE id = ? AND version = ?"
+	res, err := p.db.ExecContext(ctx, query, r.Status, moderatorID, r.ID, r.Version)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	r.ModeratedBy = moderatorID
+	r.Version++
+	return nil
+}
+
+func (p *ReviewProvider) SetHelpful(reviewID, userID int64, helpful bool) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query, delta := "INSERT IGNORE INTO review_votes (review_id, user_id) VALUES (?, ?)", 1
+	if !helpful {
+		query, delta = "DELETE FROM review_votes WHERE review_id = ? AND user_id = ?", -1
+	}
+	res, err := tx.ExecContext(ctx, query, reviewID, userID)
+	if err != nil {
+		return err
+	}
+	//voting twice, or withdrawing a vote never given, changes nothing
+	if affected, err := res.RowsAffected(); err != nil || affected == 0 {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, "UPDATE reviews SET helpful = helpful + ? WHERE id = ?", delta, reviewID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
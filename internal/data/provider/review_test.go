@@ -0,0 +1,66 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestReviewProvider_ModerateAndVote(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	users := NewUserProvider(testDB, envConfigs)
+	reviews := NewReviewProvider(testDB, envConfigs)
+
+	m, err := movies.Insert(&models.Movie{Title: "Stalker", Runtime: 161, Year: 1979, Genres: []string{"Drama"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(m.ID)
+
+	var ids []int64
+	for _, email := range []string{"author@example.com", "reader@example.com"} {
+		u := &models.User{Name: "Reader", Email: email, Activated: true}
+		is.NoErr(u.Password.Set("pa55word"))
+		u, err = users.Insert(u)
+		is.NoErr(err)
+		defer users.Delete(u.ID)
+		ids = append(ids, u.ID)
+	}
+
+	r, err := reviews.Insert(&models.Review{MovieID: m.ID, UserID: ids[0], Body: "Slow, strange and unforgettable.", Status: models.ReviewPending, Flags: []string{"link"}})
+	is.NoErr(err)
+	_, err = reviews.Insert(&models.Review{MovieID: m.ID, UserID: ids[0], Body: "Once more with feeling, please.", Status: models.ReviewPending})
+	is.Equal(err, ErrDuplicateReview)
+
+	is.NoErr(r.Transition(models.ReviewApproved))
+	is.NoErr(reviews.Moderate(r, ids[1]))
+	r.Version--
+	is.Equal(reviews.Moderate(r, ids[1]), ErrEditConflict)
+
+	//voting twice counts once
+	is.NoErr(reviews.SetHelpful(r.ID, ids[1], true))
+	is.NoErr(reviews.SetHelpful(r.ID, ids[1], true))
+	stored, err := reviews.Get(r.ID)
+	is.NoErr(err)
+	is.Equal(stored.Helpful, 1)
+	is.Equal(stored.Flags, []string{"link"})
+
+	list, meta, err := reviews.GetAll(data.ReviewSearch{
+		MovieID: m.ID,
+		Status:  models.ReviewApproved,
+		Filters: data.Filter{Page: 1, PageSize: 20, Sort: "-helpful", SortSafelist: []string{"-helpful"}},
+	})
+	is.NoErr(err)
+	is.Equal(meta.TotalRecords, 1)
+	is.Equal(list[0].ID, r.ID)
+
+	is.NoErr(reviews.SetHelpful(r.ID, ids[1], false))
+	stored, err = reviews.Get(r.ID)
+	is.NoErr(err)
+	is.Equal(stored.Helpful, 0)
+}
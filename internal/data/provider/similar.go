@@ -0,0 +1,184 @@
+package provider
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+	"yamda_go/internal/data"
+	"yamda_go/internal/jsonlog"
+	"yamda_go/internal/models"
+	"yamda_go/internal/similar"
+)
+
+// movieSimilarityWeights tell how much each feature counts in how alike two
+// movies are: sharing genres matters most, then sharing cast and crew.
+var movieSimilarityWeights = similar.Weights{Genres: 3, People: 2, Year: 1, Runtime: 0.5}
+
+type ISimilarMovieProvider interface {
+	// Similar returns up to limit movies like the given one, the most similar
+	// first. It returns ErrRecordNotFound for an unknown movie.
+	Similar(id int64, limit int) ([]*models.SimilarMovie, error)
+}
+
+// CreditsFunc returns the credits of each movie, see IPersonProvider.Credits.
+type CreditsFunc func(movieIDs ...int64) (map[int64][]*models.Credit, error)
+
+// SimilarMovieProvider wraps a movie provider with the feature vectors of every
+// movie, for finding the ones alike. Vectors are computed again on every write
+// and the whole index is rebuilt in the background from time to time, which is
+// when changes to the credits are picked up.
+type SimilarMovieProvider struct {
+	IMovieProvider
+	credits CreditsFunc
+
+	mu    sync.RWMutex
+	index *similar.Index
+}
+
+// NewSimilarMovieProvider wraps p. The index starts empty, see Rebuild.
+func NewSimilarMovieProvider(p IMovieProvider, credits CreditsFunc) *SimilarMovieProvider {
+	return &SimilarMovieProvider{
+		IMovieProvider: p,
+		credits:        credits,
+		index:          similar.NewIndex(movieSimilarityWeights),
+	}
+}
+
+func (p *SimilarMovieProvider) current() *similar.Index {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.index
+}
+
+// Rebuild computes the vectors of every movie of the wrapped provider, one page
+// at a time, into a new index that replaces the current one once complete.
+func (p *SimilarMovieProvider) Rebuild() error {
+	index := similar.NewIndex(movieSimilarityWeights)
+	params := data.Search{
+		GenresMatch: data.GenresMatchAny,
+		Fields:      []string{"id", "genres", "year", "runtime"},
+		Filters:     data.Filter{Page: 1, PageSize: 100, Sort: "id", SortSafelist: []string{"id"}},
+	}
+	for {
+		movies, meta, err := p.IMovieProvider.GetAll(params)
+		if err != nil {
+			return err
+		}
+		if err = p.put(index, movies...); err != nil {
+			return err
+		}
+		if meta.CurrentPage >= meta.LastPage {
+			break
+		}
+		params.Filters.Page++
+	}
+
+	p.mu.Lock()
+	p.index = index
+	p.mu.Unlock()
+	return nil
+}
+
+// Refresh rebuilds the index every interval. It blocks until ctx is done.
+func (p *SimilarMovieProvider) Refresh(ctx context.Context, interval time.Duration, logger *jsonlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Rebuild(); err != nil {
+				logger.PrintError(err, map[string]string{"index": "similar movies"})
+			}
+		}
+	}
+}
+
+// put computes the vectors of the movies, along with their credits.
+func (p *SimilarMovieProvider) put(index *similar.Index, movies ...*models.Movie) error {
+	if len(movies) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(movies))
+	for i, m := range movies {
+		ids[i] = m.ID
+	}
+	credits, err := p.credits(ids...)
+	if err != nil {
+		return err
+	}
+	for _, m := range movies {
+		f := similar.Features{Genres: m.Genres, Year: m.Year, Runtime: int32(m.Runtime)}
+		for _, c := range credits[m.ID] {
+			f.People = append(f.People, c.PersonID)
+		}
+		index.Put(m.ID, f)
+	}
+	return nil
+}
+
+func (p *SimilarMovieProvider) Insert(m *models.Movie) (*models.Movie, error) {
+	inserted, err := p.IMovieProvider.Insert(m)
+	if err != nil {
+		return nil, err
+	}
+	//the movie is saved, an outdated vector is fixed by the next rebuild
+	_ = p.put(p.current(), inserted)
+	return inserted, nil
+}
+
+func (p *SimilarMovieProvider) Update(m models.Movie) error {
+	if err := p.IMovieProvider.Update(m); err != nil {
+		return err
+	}
+	_ = p.put(p.current(), &m)
+	return nil
+}
+
+func (p *SimilarMovieProvider) Delete(id int64) error {
+	if err := p.IMovieProvider.Delete(id); err != nil {
+		return err
+	}
+	p.current().Remove(id)
+	return nil
+}
+
+func (p *SimilarMovieProvider) Similar(id int64, limit int) ([]*models.SimilarMovie, error) {
+	index := p.current()
+	if !index.Has(id) {
+		return nil, ErrRecordNotFound
+	}
+	hits := index.Similar(id, limit)
+	if len(hits) == 0 {
+		return []*models.SimilarMovie{}, nil
+	}
+
+	scores := make(map[int64]float64, len(hits))
+	params := data.Search{
+		GenresMatch: data.GenresMatchAny,
+		Filters:     data.Filter{Page: 1, PageSize: len(hits), Sort: "id", SortSafelist: []string{"id"}},
+	}
+	for _, h := range hits {
+		scores[h.ID] = h.Score
+		params.IDs = append(params.IDs, h.ID)
+	}
+	movies, _, err := p.IMovieProvider.GetAll(params)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.SimilarMovie, 0, len(movies))
+	for _, m := range movies {
+		result = append(result, &models.SimilarMovie{Movie: m, Similarity: math.Round(scores[m.ID]*1000) / 1000})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Similarity != result[j].Similarity {
+			return result[i].Similarity > result[j].Similarity
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/data"
+	provmock "yamda_go/internal/mocks/data/provider"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestSimilarMovieProvider_FollowsWrites(t *testing.T) {
+	is := is2.New(t)
+
+	movies := []*models.Movie{
+		{ID: 1, Title: "Alien", Year: 1979, Runtime: 117, Genres: []string{"Horror", "Science Fiction"}},
+		{ID: 2, Title: "Aliens", Year: 1986, Runtime: 137, Genres: []string{"Action", "Science Fiction"}},
+		{ID: 3, Title: "Amélie", Year: 2001, Runtime: 122, Genres: []string{"Comedy", "Romance"}},
+	}
+	mock := provmock.MovieProviderMock{}
+	mock.GetAllMoviesMock = func(s data.Search) ([]*models.Movie, *models.Metadata, error) {
+		var out []*models.Movie
+		for _, m := range movies {
+			for _, id := range s.IDs {
+				if id == m.ID {
+					out = append(out, m)
+				}
+			}
+			if s.IDs == nil {
+				out = append(out, m)
+			}
+		}
+		meta := models.New(len(out), 1, 100)
+		return out, &meta, nil
+	}
+	mock.CreateMovieMock = func(m *models.Movie) (*models.Movie, error) {
+		m.ID = 4
+		movies = append(movies, m)
+		return m, nil
+	}
+	mock.DeleteMovieMock = func(int64) error { return nil }
+	credits := func(ids ...int64) (map[int64][]*models.Credit, error) {
+		//the same director for Alien and the new one
+		return map[int64][]*models.Credit{1: {{PersonID: 7}}, 4: {{PersonID: 7}}}, nil
+	}
+
+	p := NewSimilarMovieProvider(mock, credits)
+	is.NoErr(p.Rebuild())
+
+	similar, err := p.Similar(1, 10)
+	is.NoErr(err)
+	is.Equal(len(similar), 1)
+	is.Equal(similar[0].ID, int64(2))
+
+	_, err = p.Insert(&models.Movie{Title: "Blade Runner", Year: 1982, Runtime: 117, Genres: []string{"Science Fiction"}})
+	is.NoErr(err)
+	similar, err = p.Similar(1, 10)
+	is.NoErr(err)
+	is.Equal(len(similar), 2)
+	is.Equal(similar[0].ID, int64(4)) //shares its director and runtime
+
+	is.NoErr(p.Delete(4))
+	similar, err = p.Similar(1, 10)
+	is.NoErr(err)
+	is.Equal(len(similar), 1)
+
+	_, err = p.Similar(42, 10)
+	is.Equal(err, ErrRecordNotFound)
+}
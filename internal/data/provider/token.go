@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+)
+
+type ITokenProvider interface {
+	// New generates a token for the user and stores it.
+	New(userID int64, ttl time.Duration, scope string) (*models.Token, error)
+	DeleteAllForUser(scope string, userID int64) error
+}
+
+type TokenProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewTokenProvider(db *sql.DB, set *config.Settings) ITokenProvider {
+	return &TokenProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *TokenProvider) New(userID int64, ttl time.Duration, scope string) (*models.Token, error) {
+	token, err := models.GenerateToken(userID, ttl, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	query := "INSERT INTO tokens (hash, user_id, expiry, scope) VALUES (?, ?, ?, ?)"
+	if _, err = p.db.ExecContext(ctx, query, token.Hash, token.UserID, token.Expiry, token.Scope); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (p *TokenProvider) DeleteAllForUser(scope string, userID int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	_, err := p.db.ExecContext(ctx, "DELETE FROM tokens WHERE scope = ? AND user_id = ?", scope, userID)
+	return err
+}
@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/config"
+	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type ITranslationProvider interface {
+	// GetAll returns the translations of the movie, by language.
+	GetAll(movieID int64) ([]*models.Translation, error)
+	// Best returns the translation of each movie to the first of the languages
+	// it is translated to. Movies translated to none of them are left out.
+	Best(languages []string, movieIDs ...int64) (map[int64]*models.Translation, error)
+	// Set adds the translation of a movie, replacing the one to the same
+	// language if any. It returns ErrRecordNotFound for an unknown movie.
+	Set(*models.Translation) error
+	// Delete removes the translation of the movie to the language.
+	Delete(movieID int64, language string) error
+}
+
+type TranslationProvider struct {
+	db      *sql.DB
+	configs *config.Settings
+}
+
+func NewTranslationProvider(db *sql.DB, set *config.Settings) ITranslationProvider {
+	return &TranslationProvider{
+		db:      db,
+		configs: set,
+	}
+}
+
+func (p *TranslationProvider) context() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(p.configs.HttpReqTimeout)*time.Second)
+}
+
+func (p *TranslationProvider) GetAll(movieID int64) ([]*models.Translation, error) {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+      SELECT movie_id, language, title, overview
+      FROM movie_translations
+      WHERE movie_id = ?
+      ORDER BY language;`
+	rows, err := p.db.QueryContext(ctx, query, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*models.Translation{}
+	for rows.Next() {
+		var t models.Translation
+		if err = rows.Scan(&t.MovieID, &t.Language, &t.Title, &t.Overview); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		translations = append(translations, &t)
+	}
+	return translations, rows.Err()
+}
+
+func (p *TranslationProvider) Best(languages []string, movieIDs ...int64) (map[int64]*models.Translation, error) {
+	best := make(map[int64]*models.Translation)
+	if len(languages) == 0 || len(movieIDs) == 0 {
+		return best, nil
+	}
+	ctx, cancel := p.context()
+	defer cancel()
+
+	rank := make(map[string]int, len(languages))
+	langs := make([]string, len(languages))
+	args := make([]interface{}, 0, len(movieIDs)+len(languages))
+	for i, l := range languages {
+		rank[l] = i
+		langs[i] = "?"
+		args = append(args, l)
+	}
+	ids := make([]string, len(movieIDs))
+	for i, id := range movieIDs {
+		ids[i] = "?"
+		args = append(args, id)
+	}
+	query := `
+      SELECT movie_id, language, title, overview
+      FROM movie_translations
+      WHERE language IN (` + strings.Join(langs, ", ") + `) AND movie_id IN (` + strings.Join(ids, ", ") + `);`
+	rows, err := p.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.Translation
+		if err = rows.Scan(&t.MovieID, &t.Language, &t.Title, &t.Overview); err != nil {
+			return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
+		}
+		if current, ok := best[t.MovieID]; !ok || rank[t.Language] < rank[current.Language] {
+			best[t.MovieID] = &t
+		}
+	}
+	return best, rows.Err()
+}
+
+func (p *TranslationProvider) Set(t *models.Translation) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	query := `
+		INSERT INTO movie_translations (movie_id, language, title, overview)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE title = VALUES(title), overview = VALUES(overview)`
+	_, err := p.db.ExecContext(ctx, query, t.MovieID, t.Language, t.Title, t.Overview)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errNoReferencedRow {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+func (p *TranslationProvider) Delete(movieID int64, language string) error {
+	ctx, cancel := p.context()
+	defer cancel()
+
+	res, err := p.db.ExecContext(ctx, "DELETE FROM movie_translations WHERE movie_id = ? AND language = ?", movieID, language)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected != 1 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build !skipset1
+// +build !skipset1
+
+package provider
+
+import (
+	"testing"
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestTranslationProvider_BestAndSearch(t *testing.T) {
+	is := is2.New(t)
+
+	movies := NewMovieProvider(testDB, envConfigs)
+	translations := NewTranslationProvider(testDB, envConfigs)
+
+	m, err := movies.Insert(&models.Movie{Title: "Spirited Away", Runtime: 125, Year: 2001, Genres: []string{"Animation"}, Version: 1})
+	is.NoErr(err)
+	defer movies.Delete(m.ID)
+
+	is.NoErr(translations.Set(&models.Translation{MovieID: m.ID, Language: "pt", Title: "A Viagem de Chihiro"}))
+	is.NoErr(translations.Set(&models.Translation{MovieID: m.ID, Language: "es-419", Title: "El viaje de Chihiro"}))
+	is.NoErr(translations.Set(&models.Translation{MovieID: m.ID, Language: "es-419", Title: "El viaje de Chihiro", Overview: "Chihiro se pierde en un mundo de espíritus."}))
+	is.Equal(translations.Set(&models.Translation{MovieID: m.ID + 1000, Language: "pt", Title: "?"}), ErrRecordNotFound)
+
+	all, err := translations.GetAll(m.ID)
+	is.NoErr(err)
+	is.Equal(len(all), 2)
+	is.Equal(all[0].Language, "es-419")
+	is.Equal(all[0].Overview, "Chihiro se pierde en un mundo de espíritus.")
+
+	best, err := translations.Best([]string{"es-MX", "es-419", "es", "pt"}, m.ID, m.ID+1000)
+	is.NoErr(err)
+	is.Equal(len(best), 1)
+	is.Equal(best[m.ID].Title, "El viaje de Chihiro")
+	best, err = translations.Best([]string{"pt-BR", "pt", "es"}, m.ID)
+	is.NoErr(err)
+	is.Equal(best[m.ID].Language, "pt")
+
+	//titles are searched in every language
+	found, _, err := movies.GetAll(data.Search{
+		Title:       "chihiro",
+		GenresMatch: data.GenresMatchAny,
+		Filters:     data.Filter{Page: 1, PageSize: 20, Sort: "id", SortSafelist: []string{"id"}},
+	})
+	is.NoErr(err)
+	is.Equal(len(found), 1)
+	is.Equal(found[0].Title, "Spirited Away")
+
+	is.NoErr(translations.Delete(m.ID, "pt"))
+	is.Equal(translations.Delete(m.ID, "pt"), ErrRecordNotFound)
+}
@@ -7,8 +7,9 @@ import (
 	"fmt"
 	"time"
 	"yamda_go/internal/config"
-	"yamda_go/internal/jsonlog"
 	"yamda_go/internal/models"
+
+	"github.com/go-sql-driver/mysql"
 )
 
 var (
@@ -19,6 +20,8 @@ var (
 type IUserProvider interface {
 	Insert(user *models.User) (*models.User, error)
 	GetByEmail(email string) (*models.User, error)
+	// GetForToken returns the owner of a token still valid for the scope.
+	GetForToken(scope, plaintext string) (*models.User, error)
 	Update(user *models.User) error
 	Delete(id int64) error
 }
@@ -28,21 +31,7 @@ type UserProvider struct {
 	configs *config.Settings
 }
 
-func NewUserProvider(set *config.Settings, log *jsonlog.Logger) IUserProvider {
-	db, err := sql.Open(set.DriverName, set.ConnString)
-	if err != nil {
-		log.PrintFatal(err, nil)
-	}
-
-	//validate connection to database is open correctly
-	if err = db.Ping(); err != nil {
-		log.PrintFatal(err, nil)
-	}
-
-	db.SetConnMaxLifetime(time.Minute * time.Duration(set.ConnMaxLifetime))
-	db.SetMaxOpenConns(set.ConnMaxOpen)
-	db.SetMaxIdleConns(set.ConnMaxIdle)
-
+func NewUserProvider(db *sql.DB, set *config.Settings) IUserProvider {
 	return &UserProvider{
 		db:      db,
 		configs: set,
@@ -108,6 +97,9 @@ func (u *UserProvider) GetByEmail(email string) (*models.User, error) {
 
 	var hash []byte
 	err = row.Scan(&tmp.ID, &tmp.CreatedAt, &tmp.Name, &tmp.Email, &hash, &tmp.Activated, &tmp.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEmailNotFound
+	}
 	if err != nil {
 		return nil, fmt.Errorf("error scanning data from DB into internal struct: %s", err)
 	}
@@ -127,18 +119,59 @@ func (u *UserProvider) GetByEmail(email string) (*models.User, error) {
 	}, nil
 }
 
+func (u *UserProvider) GetForToken(scope, plaintext string) (*models.User, error) {
+	query := `SELECT u.id, u.created_at, u.name, u.email, u.password_hash, u.activated, u.version
+              FROM users u JOIN tokens t ON t.user_id = u.id
+              WHERE t.hash = ? AND t.scope = ? AND t.expiry > ?`
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(u.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	var (
+		user models.User
+		hash []byte
+	)
+	err := u.db.QueryRowContext(ctx, query, models.TokenHash(plaintext), scope, time.Now().UTC()).
+		Scan(&user.ID, &user.CreatedAt, &user.Name, &user.Email, &hash, &user.Activated, &user.Version)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	if err = user.Password.SetHash(hash); err != nil {
+		return nil, fmt.Errorf("error setting password hash: %s", err)
+	}
+	return &user, nil
+}
+
+// Update saves the user, returning ErrEditConflict when it was changed since
+// it was read and ErrDuplicateEmail for the email of another user.
 func (u *UserProvider) Update(user *models.User) error {
 	query := `UPDATE users SET name = ?, email = ?, password_hash = ?, activated = ?, version = version + 1
              WHERE id = ? AND version = ?`
-	stmtIns, err := u.db.Prepare(query)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(u.configs.HttpReqTimeout)*time.Second)
+	defer cancel()
+
+	res, err := u.db.ExecContext(ctx, query, user.Name, user.Email, user.Password.GetHash(), user.Activated, user.ID, user.Version)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errDupEntry {
+		return ErrDuplicateEmail
+	}
 	if err != nil {
 		return err
 	}
-	defer stmtIns.Close()
-	_, err = stmtIns.Exec(user.Name, user.Email, user.Password.GetHash(), user.Activated, user.Version+1, user.ID, user.Version)
+	affected, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if affected != 1 {
+		return ErrEditConflict
+	}
+	user.Version++
 	return nil
 }
 
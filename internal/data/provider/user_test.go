@@ -21,7 +21,7 @@ func setupPreviouslyInsertedUser() func() {
 		Activated: false,
 	}
 
-	prov := NewUserProvider(envConfigs, logger)
+	prov := NewUserProvider(testDB, envConfigs)
 	res, err := prov.Insert(user)
 	if err != nil {
 		log.Fatal(err)
@@ -46,7 +46,7 @@ func TestProvider_InsertUser_Ok(t *testing.T) {
 	user.Password = *models.NewPassword("", []byte{})
 	_ = user.Password.Set("aab4162e5cb0c0da025002c99ef526db")
 
-	prov := NewUserProvider(envConfigs, logger)
+	prov := NewUserProvider(testDB, envConfigs)
 
 	res, err := prov.Insert(user)
 	is.NoErr(err)
@@ -78,7 +78,7 @@ func TestProvider_InsertUser_DuplicateEmail_NotOK(t *testing.T) {
 	user.Password = *models.NewPassword("", []byte{})
 	_ = user.Password.Set("aab4162e5cb0c0da025002c99ef526db")
 
-	prov := NewUserProvider(envConfigs, logger)
+	prov := NewUserProvider(testDB, envConfigs)
 
 	//insert two times
 	res, err := prov.Insert(user)
@@ -86,3 +86,24 @@ func TestProvider_InsertUser_DuplicateEmail_NotOK(t *testing.T) {
 	is.Equal(res, nil)
 	is.True(err != nil)
 }
+
+func TestProvider_UpdateUser_ActivatesAndDetectsConflicts(t *testing.T) {
+	is := is2.New(t)
+
+	teardown := setupPreviouslyInsertedUser()
+	defer teardown()
+
+	prov := NewUserProvider(testDB, envConfigs)
+	stale := *user
+
+	user.Activated = true
+	is.NoErr(prov.Update(user))
+	is.Equal(user.Version, 2)
+
+	got, err := prov.GetByEmail("slim@example.com")
+	is.NoErr(err)
+	is.True(got.Activated)
+	is.Equal(got.Version, 2)
+
+	is.Equal(prov.Update(&stale), ErrEditConflict)
+}
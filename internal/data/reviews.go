@@ -0,0 +1,19 @@
+package data
+
+import (
+	"yamda_go/internal/models"
+	"yamda_go/internal/validator"
+)
+
+// ReviewSearch lists the reviews of a movie in a moderation state.
+type ReviewSearch struct {
+	MovieID int64
+	Status  string
+	Filters Filter
+}
+
+// Validate checks the search criteria as well as its filter.
+func (s ReviewSearch) Validate(v *validator.Validator) {
+	v.Check(validator.In(s.Status, models.ReviewStatuses...), "status", "must be one of pending, approved, rejected")
+	s.Filters.Validate(v)
+}
@@ -1,21 +1,85 @@
 package data
 
 import (
+	"fmt"
 	"strings"
+	"time"
 	"yamda_go/internal/validator"
 )
 
+// MovieFacets are the facets that can be counted on a movie search.
+var MovieFacets = []string{"genres", "decade", "runtime_bucket"}
+
+// Genre matching modes of a Search.
+const (
+	GenresMatchAny = "any" //movies with at least one of the genres
+	GenresMatchAll = "all" //movies with every one of the genres
+)
+
 type Search struct {
-	Title   string
-	Genres  string
-	Filters Filter
+	Query       string  //free text, ranked by the search index
+	IDs         []int64 //restricts the results to these movies when not nil
+	Title       string
+	Genres      []string
+	GenresMatch string //GenresMatchAny or GenresMatchAll
+	//ranges, zero values mean no bound
+	YearMin      int
+	YearMax      int
+	RuntimeMin   int
+	RuntimeMax   int
+	CreatedAfter time.Time
+	Fields       []string //columns the caller needs, all of them when empty
+	Facets       []string //facets to count, see MovieFacets
+	Filters      Filter
+}
+
+//Validate checks the search criteria as well as its filter.
+func (s Search) Validate(v *validator.Validator) {
+	v.Check(len(s.Query) <= 200, "q", "must not be more than 200 bytes long")
+	v.Check(s.Query == "" || !s.Filters.UsesCursor(), "q", "must not be used together with cursors")
+	v.Check(validator.In(s.GenresMatch, GenresMatchAny, GenresMatchAll), "genres_match", "must be either any or all")
+	v.Check(s.YearMin >= 0, "year_min", "must not be negative")
+	v.Check(s.YearMax >= 0, "year_max", "must not be negative")
+	v.Check(s.YearMax == 0 || s.YearMin <= s.YearMax, "year_min", "must not be bigger than year_max")
+	v.Check(s.RuntimeMin >= 0, "runtime_min", "must not be negative")
+	v.Check(s.RuntimeMax >= 0, "runtime_max", "must not be negative")
+	v.Check(s.RuntimeMax == 0 || s.RuntimeMin <= s.RuntimeMax, "runtime_min", "must not be bigger than runtime_max")
+	for _, f := range s.Facets {
+		v.Check(validator.In(f, MovieFacets...), "facets", fmt.Sprintf("unknown facet %q", f))
+	}
+	v.Check(validator.Unique(s.Facets), "facets", "must not contain duplicate values")
+	s.Filters.Validate(v)
+}
+
+// WithoutFacetFilter returns a copy of the search without the criteria on the
+// facet, so that its counts cover every value that could be picked instead.
+func (s Search) WithoutFacetFilter(facet string) Search {
+	switch facet {
+	case "genres":
+		s.Genres = nil
+	case "decade":
+		s.YearMin, s.YearMax = 0, 0
+	case "runtime_bucket":
+		s.RuntimeMin, s.RuntimeMax = 0, 0
+	}
+	return s
+}
+
+// SortField is one of the fields results are sorted by.
+type SortField struct {
+	Column    string
+	Direction string //ASC or DESC
 }
 
 type Filter struct {
 	Page         int
 	PageSize     int
-	Sort         string   //field to be used while sorting results
+	Sort         string   //comma separated fields to be used while sorting results, e.g. "-year,title"
 	SortSafelist []string //specified by each endpoint to customize their search
+	//keyset pagination. When one of them is set, Page is ignored.
+	After   *Cursor
+	Before  *Cursor
+	Cursors CursorCodec //signs the cursors handed out in the metadata
 }
 
 //Validate performs a few checks of the fields of a given instance of Filter.
@@ -25,27 +89,41 @@ func (f Filter) Validate(v *validator.Validator) {
 	v.Check(f.PageSize > 0, "page_size", "must be bigger than 0")
 	v.Check(f.PageSize <= 100, "page", "must be smaller than 100")
 
-	// Check that the sort parameter matches a value in the safelist.
-	v.Check(validator.In(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+	// Check that each field of the sort parameter matches a value in the safelist.
+	columns := []string{}
+	for _, field := range strings.Split(f.Sort, ",") {
+		v.Check(validator.In(field, f.SortSafelist...), "sort", "invalid sort value")
+		columns = append(columns, strings.TrimPrefix(field, "-"))
+	}
+	v.Check(validator.Unique(columns), "sort", "must not contain duplicate fields")
+
+	// A cursor only makes sense for the sort it was created for.
+	v.Check(f.After == nil || f.Before == nil, "after", "must not be used together with before")
+	v.Check(f.After == nil || f.After.Sort == f.Sort, "after", "does not match the sort parameter")
+	v.Check(f.Before == nil || f.Before.Sort == f.Sort, "before", "does not match the sort parameter")
 }
 
-//GetSortColumn returns which column should be used to sort results.
-func (f Filter) GetSortColumn() string {
-	for _, s := range f.SortSafelist {
-		if f.Sort == s {
-			return strings.TrimPrefix(f.Sort, "-")
-		}
-	}
-	//should never happen since we have a validator routine for these cases.
-	panic("unsafe sort parameter: " + f.Sort)
+//UsesCursor tells if results should be paginated with cursors instead of offsets.
+func (f Filter) UsesCursor() bool {
+	return f.After != nil || f.Before != nil
 }
 
-//GetSortDirection returns which direction (ASC, DESC) should be used while sorting results.
-func (f Filter) GetSortDirection() string {
-	if strings.HasPrefix(f.Sort, "-") {
-		return "DESC"
+//GetSortFields returns which columns, and in which direction (ASC, DESC),
+//should be used while sorting results.
+func (f Filter) GetSortFields() []SortField {
+	var fields []SortField
+	for _, field := range strings.Split(f.Sort, ",") {
+		//should never happen since we have a validator routine for these cases.
+		if !validator.In(field, f.SortSafelist...) {
+			panic("unsafe sort parameter: " + f.Sort)
+		}
+		sf := SortField{Column: field, Direction: "ASC"}
+		if strings.HasPrefix(field, "-") {
+			sf = SortField{Column: strings.TrimPrefix(field, "-"), Direction: "DESC"}
+		}
+		fields = append(fields, sf)
 	}
-	return "ASC"
+	return fields
 }
 
 func (f Filter) GetPageSize() int {
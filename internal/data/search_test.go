@@ -0,0 +1,63 @@
+package data
+
+import (
+	"testing"
+	"yamda_go/internal/validator"
+
+	is2 "github.com/matryer/is"
+)
+
+var movieSafelist = []string{"id", "title", "year", "-id", "-title", "-year"}
+
+func TestFilter_GetSortFields_MultipleFields(t *testing.T) {
+	is := is2.New(t)
+
+	f := Filter{Sort: "-year,title", SortSafelist: movieSafelist}
+	is.Equal(f.GetSortFields(), []SortField{{"year", "DESC"}, {"title", "ASC"}})
+}
+
+func TestFilter_Validate_SortFields(t *testing.T) {
+	is := is2.New(t)
+
+	for sort, msg := range map[string]string{
+		"-year,title":  "",
+		"-year,rating": "invalid sort value",
+		"year,-year":   "must not contain duplicate fields",
+		"year,":        "invalid sort value",
+	} {
+		v := validator.New()
+		Filter{Page: 1, PageSize: 20, Sort: sort, SortSafelist: movieSafelist}.Validate(v)
+		is.Equal(v.Errors["sort"], msg)
+	}
+}
+
+func TestSearch_Validate_Ranges(t *testing.T) {
+	is := is2.New(t)
+
+	s := Search{
+		GenresMatch: "some",
+		YearMin:     2000,
+		YearMax:     1990,
+		RuntimeMin:  90,
+		RuntimeMax:  120,
+		Filters:     Filter{Page: 1, PageSize: 20, Sort: "id", SortSafelist: movieSafelist},
+	}
+	v := validator.New()
+	s.Validate(v)
+
+	is.Equal(v.Errors, map[string]string{
+		"genres_match": "must be either any or all",
+		"year_min":     "must not be bigger than year_max",
+	})
+}
+
+func TestSearch_WithoutFacetFilter(t *testing.T) {
+	is := is2.New(t)
+
+	s := Search{Genres: []string{"Drama"}, YearMin: 1990, YearMax: 1999, RuntimeMax: 120}
+	is.Equal(s.WithoutFacetFilter("genres").Genres, []string(nil))
+	is.Equal(s.WithoutFacetFilter("genres").YearMin, 1990)
+	is.Equal(s.WithoutFacetFilter("decade").YearMax, 0)
+	is.Equal(s.WithoutFacetFilter("runtime_bucket").RuntimeMax, 0)
+	is.Equal(s.WithoutFacetFilter("runtime_bucket").Genres, []string{"Drama"})
+}
@@ -0,0 +1,62 @@
+package data
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"yamda_go/internal/validator"
+)
+
+// Full-text search modes of a TextSearch, matching the MariaDB ones.
+const (
+	TextModeNatural = "natural"
+	TextModeBoolean = "boolean"
+)
+
+// TextSearch is a relevance ranked full-text search.
+type TextSearch struct {
+	Query   string
+	Mode    string //TextModeNatural or TextModeBoolean
+	Filters Filter
+}
+
+//Validate performs a few checks of the fields of a given instance of TextSearch.
+func (s TextSearch) Validate(v *validator.Validator) {
+	v.Check(strings.TrimSpace(s.Query) != "", "q", "must be provided")
+	v.Check(len(s.Query) <= 200, "q", "must not be more than 200 bytes long")
+	v.Check(validator.In(s.Mode, TextModeNatural, TextModeBoolean), "mode", "must be either natural or boolean")
+	s.Filters.Validate(v)
+}
+
+// Terms returns the words of the query, without the operators of the boolean mode.
+func (s TextSearch) Terms() []string {
+	return strings.FieldsFunc(s.Query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// Highlight escapes text for HTML and wraps every word starting with one of the
+// terms in <mark> tags, e.g. "The <mark>Godfather</mark>".
+func Highlight(text string, terms []string) string {
+	var alternatives []string
+	for _, t := range terms {
+		alternatives = append(alternatives, regexp.QuoteMeta(t))
+	}
+	if len(alternatives) == 0 {
+		return html.EscapeString(text)
+	}
+	//\b and \w only know ASCII letters, the words of "Amélie" are told apart by hand
+	rx := regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{M}\p{N}])((?:` + strings.Join(alternatives, "|") + `)[\p{L}\p{M}\p{N}]*)`)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range rx.FindAllStringSubmatchIndex(text, -1) {
+		start, end := loc[2], loc[3] //the word, without the character before it
+		b.WriteString(html.EscapeString(text[last:start]))
+		b.WriteString("<mark>" + html.EscapeString(text[start:end]) + "</mark>")
+		last = end
+	}
+	b.WriteString(html.EscapeString(text[last:]))
+	return b.String()
+}
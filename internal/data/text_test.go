@@ -0,0 +1,26 @@
+package data
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestTextSearch_Terms_IgnoresBooleanOperators(t *testing.T) {
+	is := is2.New(t)
+
+	s := TextSearch{Query: `+star -trek "new hope" wars*`, Mode: TextModeBoolean}
+	is.Equal(s.Terms(), []string{"star", "trek", "new", "hope", "wars"})
+}
+
+func TestHighlight(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(Highlight("Star Wars: A New Hope", []string{"wars", "hop"}), "Star <mark>Wars</mark>: A New <mark>Hope</mark>")
+	is.Equal(Highlight("Tom & Jerry", []string{"jerry"}), "Tom &amp; <mark>Jerry</mark>")
+	is.Equal(Highlight("Tom & Jerry", nil), "Tom &amp; Jerry")
+	//words are made of any letter, not just ASCII ones
+	is.Equal(Highlight("Le Fabuleux Destin d'Amélie Poulain", []string{"am"}), "Le Fabuleux Destin d&#39;<mark>Amélie</mark> Poulain")
+	is.Equal(Highlight("Ørsted and the Émigrés", []string{"ørsted", "émigré"}), "<mark>Ørsted</mark> and the <mark>Émigrés</mark>")
+	is.Equal(Highlight("Amélie", []string{"élie"}), "Amélie")
+}
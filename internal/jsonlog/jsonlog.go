@@ -103,3 +103,15 @@ func (l *Logger) print(level Level, msg string, props map[string]string) (int, e
 func (l *Logger) Write(message []byte) (n int, err error) {
 	return l.print(LevelError, string(message), nil)
 }
+
+// Reopen asks the output to reopen its underlying file, if it supports it. The
+// logger's mutex is held so no write can interleave with the reopening.
+func (l *Logger) Reopen() error {
+	r, ok := l.out.(interface{ Reopen() error })
+	if !ok {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return r.Reopen()
+}
@@ -0,0 +1,277 @@
+package jsonlog
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is appended to the name of a rotated segment. It sorts
+// lexically in the same order as chronologically.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// RotatingFile is an io.WriteCloser that writes to a file on disk and rotates it
+// once it grows past MaxSize bytes or has been open for longer than RotateEvery.
+// Rotated segments are renamed with a timestamp suffix, optionally gzipped, and
+// only the newest MaxBackups of them, modified within MaxAge, are kept.
+//
+// RotatingFile does not synchronise Write calls by itself. It is meant to be
+// used as the output of a Logger, which already serialises writes under its mutex.
+type RotatingFile struct {
+	Path        string
+	MaxSize     int64         // bytes, 0 disables size based rotation
+	RotateEvery time.Duration // 0 disables time based rotation
+	MaxAge      time.Duration // 0 keeps rotated segments whatever their age
+	MaxBackups  int           // 0 keeps every rotated segment
+	Compress    bool
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+
+	//compression and pruning of old segments happens in the background
+	//so that a rotation never blocks the writers for long.
+	mill     chan struct{}
+	millDone chan struct{}
+	millOnce sync.Once
+}
+
+// NewRotatingFile opens (or creates) the file at path and returns a RotatingFile
+// writing to it. Segments left over by a previous run are pruned right away.
+func NewRotatingFile(path string, maxSize int64, rotateEvery, maxAge time.Duration, maxBackups int, compress bool) (*RotatingFile, error) {
+	r := &RotatingFile{
+		Path:        path,
+		MaxSize:     maxSize,
+		RotateEvery: rotateEvery,
+		MaxAge:      maxAge,
+		MaxBackups:  maxBackups,
+		Compress:    compress,
+		now:         time.Now,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	r.kickMill()
+	return r, nil
+}
+
+// Write appends p to the current segment, rotating it beforehand if needed.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Reopen closes the current file and opens the configured path again. This is
+// what external tools like logrotate expect after they moved the file away and
+// sent a SIGHUP.
+func (r *RotatingFile) Reopen() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Close closes the current segment and waits for any pending compression.
+func (r *RotatingFile) Close() error {
+	err := r.closeFile()
+	if r.mill != nil {
+		close(r.mill)
+		<-r.millDone
+		r.mill = nil
+	}
+	return err
+}
+
+func (r *RotatingFile) shouldRotate(n int64) bool {
+	//never rotate an empty file, otherwise a single big write would loop forever
+	if r.size == 0 {
+		return false
+	}
+	if r.MaxSize > 0 && r.size+n > r.MaxSize {
+		return true
+	}
+	if r.RotateEvery > 0 && r.now().Sub(r.openedAt) >= r.RotateEvery {
+		return true
+	}
+	return false
+}
+
+func (r *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return fmt.Errorf("can't create directory for log file: %s", err)
+	}
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("can't open log file: %s", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("can't stat log file: %s", err)
+	}
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = r.now()
+	return nil
+}
+
+func (r *RotatingFile) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *RotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+	backup := r.Path + "." + r.now().UTC().Format(backupTimeFormat)
+	if err := os.Rename(r.Path, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("can't rename log file: %s", err)
+	}
+	if err := r.open(); err != nil {
+		return err
+	}
+	r.kickMill()
+	return nil
+}
+
+// kickMill asks the mill to go through the backups, starting it if needed.
+func (r *RotatingFile) kickMill() {
+	r.startMill()
+	//non-blocking: one pending signal is enough for the mill to catch up.
+	select {
+	case r.mill <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RotatingFile) startMill() {
+	r.millOnce.Do(func() {
+		r.mill = make(chan struct{}, 1)
+		r.millDone = make(chan struct{})
+		go func() {
+			defer close(r.millDone)
+			for range r.mill {
+				r.millRun()
+			}
+		}()
+	})
+}
+
+// millRun compresses every uncompressed backup and removes the ones beyond
+// MaxBackups or last modified longer than MaxAge ago.
+func (r *RotatingFile) millRun() {
+	backups, err := r.backups()
+	if err != nil {
+		return
+	}
+
+	if r.MaxAge > 0 {
+		kept := backups[:0]
+		for _, b := range backups {
+			if info, err := os.Stat(b); err == nil && r.now().Sub(info.ModTime()) > r.MaxAge {
+				_ = os.Remove(b)
+				continue
+			}
+			kept = append(kept, b)
+		}
+		backups = kept
+	}
+
+	if r.MaxBackups > 0 && len(backups) > r.MaxBackups {
+		for _, b := range backups[r.MaxBackups:] {
+			_ = os.Remove(b)
+		}
+		backups = backups[:r.MaxBackups]
+	}
+
+	if !r.Compress {
+		return
+	}
+	for _, b := range backups {
+		if strings.HasSuffix(b, compressSuffix) {
+			continue
+		}
+		_ = compressFile(b)
+	}
+}
+
+// backups returns the rotated segments, newest first.
+func (r *RotatingFile) backups() ([]string, error) {
+	dir, base := filepath.Dir(r.Path), filepath.Base(r.Path)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), base+".") {
+			continue
+		}
+		ts := strings.TrimSuffix(strings.TrimPrefix(e.Name(), base+"."), compressSuffix)
+		if _, err := time.Parse(backupTimeFormat, ts); err != nil {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	for i := range names {
+		names[i] = filepath.Join(dir, names[i])
+	}
+	return names, nil
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err = io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(name + compressSuffix)
+		return err
+	}
+	if err = gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(name + compressSuffix)
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(name)
+}
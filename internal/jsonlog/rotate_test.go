@@ -0,0 +1,114 @@
+package jsonlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestRotatingFile_RotatesBySizeAndKeepsBackups(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	r, err := NewRotatingFile(path, 10, 0, 0, 2, true)
+	is.NoErr(err)
+
+	clock := time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC)
+	r.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for i := 0; i < 4; i++ {
+		_, err = r.Write([]byte("0123456789"))
+		is.NoErr(err)
+	}
+	is.NoErr(r.Close())
+
+	backups, err := r.backups()
+	is.NoErr(err)
+	is.Equal(len(backups), 2)
+	for _, b := range backups {
+		is.True(strings.HasSuffix(b, ".gz"))
+	}
+
+	content, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.Equal(string(content), "0123456789")
+}
+
+func TestRotatingFile_RotatesByAge(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	r, err := NewRotatingFile(path, 0, time.Hour, 0, 0, false)
+	is.NoErr(err)
+
+	clock := time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return clock }
+	r.openedAt = clock
+
+	_, err = r.Write([]byte("first\n"))
+	is.NoErr(err)
+	clock = clock.Add(2 * time.Hour)
+	_, err = r.Write([]byte("second\n"))
+	is.NoErr(err)
+	is.NoErr(r.Close())
+
+	backups, err := r.backups()
+	is.NoErr(err)
+	is.Equal(len(backups), 1)
+
+	content, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.Equal(string(content), "second\n")
+}
+
+func TestRotatingFile_PrunesBackupsOlderThanMaxAge(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	old := path + "." + time.Now().Add(-72*time.Hour).UTC().Format(backupTimeFormat) + ".gz"
+	recent := path + "." + time.Now().Add(-time.Hour).UTC().Format(backupTimeFormat) + ".gz"
+	for _, b := range []string{old, recent} {
+		is.NoErr(os.WriteFile(b, []byte("segment"), 0644))
+	}
+	//the age goes by the modification time, not by the name
+	is.NoErr(os.Chtimes(old, time.Now().Add(-72*time.Hour), time.Now().Add(-72*time.Hour)))
+	is.NoErr(os.Chtimes(recent, time.Now().Add(-72*time.Hour), time.Now().Add(-time.Hour)))
+
+	//left over by a previous run, they are pruned on startup
+	r, err := NewRotatingFile(path, 0, 0, 48*time.Hour, 0, false)
+	is.NoErr(err)
+	is.NoErr(r.Close())
+
+	backups, err := r.backups()
+	is.NoErr(err)
+	is.Equal(backups, []string{recent})
+}
+
+func TestLogger_ReopenAfterFileWasMoved(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "api.log")
+	r, err := NewRotatingFile(path, 0, 0, 0, 0, false)
+	is.NoErr(err)
+	defer r.Close()
+
+	logger := New(r, LevelInfo)
+	logger.PrintInfo("before", nil)
+
+	//simulate logrotate moving the file away
+	is.NoErr(os.Rename(path, path+".1"))
+	is.NoErr(logger.Reopen())
+	logger.PrintInfo("after", nil)
+
+	content, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.True(strings.Contains(string(content), `"message":"after"`))
+	is.True(!strings.Contains(string(content), `"message":"before"`))
+}
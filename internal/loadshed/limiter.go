@@ -0,0 +1,102 @@
+package loadshed
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Limiter caps how many requests are processed at the same time. The cap
+// adapts to the observed latency using AIMD (additive increase, multiplicative
+// decrease): while requests complete within the target latency the limit grows
+// by roughly one per round trip, as soon as they are slower (or fail because
+// of overload) it is cut by the backoff factor, once per round trip: the
+// requests that started before the last cut don't cut the limit again.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    float64
+	min      float64
+	max      float64
+	inFlight int
+	target   time.Duration
+	backoff  float64
+	cutAt    time.Time
+	now      func() time.Time //replaced by the tests
+}
+
+// NewLimiter creates a Limiter starting at initial concurrent requests, never
+// going below min nor above max.
+func NewLimiter(initial, min, max int, target time.Duration) *Limiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return &Limiter{
+		limit:   math.Max(float64(min), math.Min(float64(initial), float64(max))),
+		min:     float64(min),
+		max:     float64(max),
+		target:  target,
+		backoff: 0.9,
+		now:     time.Now,
+	}
+}
+
+// Acquire reserves a slot for a request. It returns false when the limit has
+// been reached and the request should be shed.
+func (l *Limiter) Acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+// Release frees the slot taken by Acquire and feeds the latency of the request
+// back into the limit. overloaded reports a failure caused by load (e.g. a
+// timeout), which is treated like a slow request.
+func (l *Limiter) Release(latency time.Duration, overloaded bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	used := l.inFlight
+	l.inFlight--
+	switch {
+	case overloaded || latency > l.target:
+		//a burst of slow requests is the same congestion, not one per request
+		now := l.now()
+		if started := now.Add(-latency); !started.Before(l.cutAt) {
+			l.limit = math.Max(l.min, l.limit*l.backoff)
+			l.cutAt = now
+		}
+	case used*2 >= int(l.limit):
+		//only grow while the limit is actually being used, otherwise it would
+		//grow without bounds during quiet periods.
+		l.limit = math.Min(l.max, l.limit+1/l.limit)
+	}
+}
+
+// Abandon frees the slot taken by Acquire for a request the client gave up on.
+// Its latency tells nothing about the load, so the limit is left as it is.
+func (l *Limiter) Abandon() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+}
+
+// Limit returns the current number of requests allowed at the same time.
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// InFlight returns how many requests are being processed right now.
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
@@ -0,0 +1,81 @@
+package loadshed
+
+import (
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestLimiter_ShedsAboveLimit(t *testing.T) {
+	is := is2.New(t)
+
+	l := NewLimiter(2, 1, 10, 100*time.Millisecond)
+	is.True(l.Acquire())
+	is.True(l.Acquire())
+	is.True(!l.Acquire())
+	is.Equal(l.InFlight(), 2)
+
+	l.Release(time.Millisecond, false)
+	is.True(l.Acquire())
+}
+
+func TestLimiter_GrowsWhileFastAndBacksOffWhenSlow(t *testing.T) {
+	is := is2.New(t)
+
+	l := NewLimiter(4, 2, 8, 100*time.Millisecond)
+
+	for i := 0; i < 100; i++ {
+		for j := 0; j < l.Limit(); j++ {
+			is.True(l.Acquire())
+		}
+		for j := l.InFlight(); j > 0; j-- {
+			l.Release(time.Millisecond, false)
+		}
+	}
+	is.Equal(l.Limit(), 8) //capped at max
+
+	now := time.Now()
+	l.now = func() time.Time { return now }
+	for i := 0; i < 100; i++ {
+		is.True(l.Acquire())
+		now = now.Add(time.Second)
+		l.Release(time.Second, false)
+	}
+	is.Equal(l.Limit(), 2) //floored at min
+}
+
+func TestLimiter_BacksOffOncePerRoundTrip(t *testing.T) {
+	is := is2.New(t)
+
+	now := time.Now()
+	l := NewLimiter(100, 1, 100, 100*time.Millisecond)
+	l.now = func() time.Time { return now }
+
+	//50 requests started together and all slow: the limit is cut once
+	for i := 0; i < 50; i++ {
+		is.True(l.Acquire())
+	}
+	now = now.Add(time.Second)
+	for i := 0; i < 50; i++ {
+		l.Release(time.Second, false)
+	}
+	is.Equal(l.Limit(), 90)
+
+	//requests started after the cut may cut it again
+	is.True(l.Acquire())
+	now = now.Add(500 * time.Millisecond)
+	l.Release(500*time.Millisecond, true)
+	is.Equal(l.Limit(), 81)
+}
+
+func TestLimiter_DoesNotGrowWhenIdle(t *testing.T) {
+	is := is2.New(t)
+
+	l := NewLimiter(10, 1, 100, 100*time.Millisecond)
+	for i := 0; i < 100; i++ {
+		is.True(l.Acquire())
+		l.Release(time.Millisecond, false)
+	}
+	is.Equal(l.Limit(), 10)
+}
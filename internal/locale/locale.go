@@ -0,0 +1,61 @@
+// Package locale picks the language of the responses among the ones clients
+// accept, with BCP 47 tags.
+package locale
+
+import (
+	"errors"
+
+	"golang.org/x/text/language"
+)
+
+// maxPreferences bounds the languages of an Accept-Language header looked at.
+const maxPreferences = 10
+
+var ErrUndetermined = errors.New("language must be determined")
+
+// multiple is what "*" stands for in an Accept-Language header.
+var multiple = language.MustParse("mul")
+
+// Canonical returns the canonical form of a language tag, e.g. "pt-BR" for
+// "pt-br". "und" and "mul" are no language in particular and rejected.
+func Canonical(tag string) (string, error) {
+	t, err := language.Parse(tag)
+	if err != nil {
+		return "", err
+	}
+	if t.IsRoot() || t == multiple {
+		return "", ErrUndetermined
+	}
+	return t.String(), nil
+}
+
+// Chain returns the languages to look for a translation in, best first, from
+// an Accept-Language header: each accepted language followed by the more
+// general ones, e.g. "es-MX", "es-419" then "es". It stops at base, the
+// language of the original text, which is then better than any translation.
+// A malformed header accepts no language in particular.
+func Chain(acceptLanguage, base string) []string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return nil
+	}
+	if len(tags) > maxPreferences {
+		tags = tags[:maxPreferences]
+	}
+
+	var chain []string
+	seen := make(map[string]bool)
+	for _, t := range tags {
+		for ; !t.IsRoot() && t != multiple; t = t.Parent() {
+			tag := t.String()
+			if tag == base {
+				return chain
+			}
+			if !seen[tag] {
+				seen[tag] = true
+				chain = append(chain, tag)
+			}
+		}
+	}
+	return chain
+}
@@ -0,0 +1,35 @@
+package locale
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestChain(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(Chain("pt-br,pt;q=0.9,de;q=0.5", "en"), []string{"pt-BR", "pt", "de"})
+	is.Equal(Chain("es-MX", "en"), []string{"es-MX", "es-419", "es"})
+	//weights first, then the order of the header
+	is.Equal(Chain("de;q=0.5, fr", "en"), []string{"fr", "de"})
+	//the original text is better than a translation to a language accepted less
+	is.Equal(Chain("fr, en;q=0.8, de;q=0.5", "en"), []string{"fr"})
+	is.Equal(Chain("en-GB", "en"), []string{"en-GB", "en-001"})
+	is.Equal(Chain("*", "en"), nil)
+	is.Equal(Chain("", "en"), nil)
+	is.Equal(Chain("not a language!", "en"), nil)
+}
+
+func TestCanonical(t *testing.T) {
+	is := is2.New(t)
+
+	tag, err := Canonical("pt-br")
+	is.NoErr(err)
+	is.Equal(tag, "pt-BR")
+
+	_, err = Canonical("und")
+	is.Equal(err, ErrUndetermined)
+	_, err = Canonical("pt_BR!")
+	is.True(err != nil)
+}
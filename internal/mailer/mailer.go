@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"yamda_go/internal/jsonlog"
+)
+
+// Mailer sends plain text emails.
+type Mailer interface {
+	Send(recipient, subject, body string) error
+}
+
+// SMTP sends emails through an SMTP server, authenticating when given a username.
+type SMTP struct {
+	addr   string
+	auth   smtp.Auth
+	sender string
+}
+
+func NewSMTP(host string, port int, username, password, sender string) *SMTP {
+	m := &SMTP{addr: fmt.Sprintf("%s:%d", host, port), sender: sender}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return m
+}
+
+func (m *SMTP) Send(recipient, subject, body string) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", m.sender)
+	fmt.Fprintf(&msg, "To: %s\r\n", recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(m.addr, m.auth, senderAddress(m.sender), []string{recipient}, []byte(msg.String()))
+}
+
+// senderAddress is the address of a sender like "Yamda <no-reply@yamda.dev>".
+func senderAddress(sender string) string {
+	if i, j := strings.LastIndex(sender, "<"), strings.LastIndex(sender, ">"); i >= 0 && j > i {
+		return sender[i+1 : j]
+	}
+	return sender
+}
+
+// Log writes the emails to the log instead of sending them, for development.
+type Log struct {
+	logger *jsonlog.Logger
+}
+
+func NewLog(logger *jsonlog.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (m *Log) Send(recipient, subject, body string) error {
+	m.logger.PrintInfo("email not sent, no SMTP server configured", map[string]string{
+		"to":      recipient,
+		"subject": subject,
+		"body":    body,
+	})
+	return nil
+}
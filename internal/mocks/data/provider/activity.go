@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"time"
+	"yamda_go/internal/models"
+)
+
+type ActivityProviderMock struct {
+	SaveActivityMock      func([]models.Activity) error
+	TrendingMock          func(time.Time, int) ([]models.Trend, error)
+	RefreshPopularityMock func() error
+}
+
+func (m ActivityProviderMock) Save(batch []models.Activity) error {
+	return m.SaveActivityMock(batch)
+}
+
+func (m ActivityProviderMock) Trending(since time.Time, limit int) ([]models.Trend, error) {
+	return m.TrendingMock(since, limit)
+}
+
+func (m ActivityProviderMock) RefreshPopularity() error {
+	return m.RefreshPopularityMock()
+}
@@ -0,0 +1,41 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type CollectionProviderMock struct {
+	GetCollectionMock        func(int64) (*models.Collection, error)
+	GetAllCollectionsMock    func() ([]*models.Collection, error)
+	CreateCollectionMock     func(*models.Collection) (*models.Collection, error)
+	UpdateCollectionMock     func(*models.Collection) error
+	DeleteCollectionMock     func(int64) error
+	SetCollectionMoviesMock  func(int64, []int64) error
+	CollectionsForMoviesMock func(...int64) (map[int64]*models.CollectionSummary, error)
+}
+
+func (m CollectionProviderMock) Get(id int64) (*models.Collection, error) {
+	return m.GetCollectionMock(id)
+}
+
+func (m CollectionProviderMock) GetAll() ([]*models.Collection, error) {
+	return m.GetAllCollectionsMock()
+}
+
+func (m CollectionProviderMock) Insert(c *models.Collection) (*models.Collection, error) {
+	return m.CreateCollectionMock(c)
+}
+
+func (m CollectionProviderMock) Update(c *models.Collection) error {
+	return m.UpdateCollectionMock(c)
+}
+
+func (m CollectionProviderMock) Delete(id int64) error {
+	return m.DeleteCollectionMock(id)
+}
+
+func (m CollectionProviderMock) SetMovies(collectionID int64, movieIDs []int64) error {
+	return m.SetCollectionMoviesMock(collectionID, movieIDs)
+}
+
+func (m CollectionProviderMock) ForMovies(movieIDs ...int64) (map[int64]*models.CollectionSummary, error) {
+	return m.CollectionsForMoviesMock(movieIDs...)
+}
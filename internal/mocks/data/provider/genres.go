@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"yamda_go/internal/models"
+)
+
+type GenreProviderMock struct {
+	GetGenreMock     func(int64) (*models.Genre, error)
+	GetAllGenresMock func() ([]*models.Genre, error)
+	CreateGenreMock  func(*models.Genre) (*models.Genre, error)
+	UpdateGenreMock  func(*models.Genre) error
+	DeleteGenreMock  func(int64) error
+	CatalogMock      func() (models.GenreCatalog, error)
+}
+
+func (m GenreProviderMock) Get(id int64) (*models.Genre, error) {
+	return m.GetGenreMock(id)
+}
+
+func (m GenreProviderMock) GetAll() ([]*models.Genre, error) {
+	return m.GetAllGenresMock()
+}
+
+func (m GenreProviderMock) Insert(genre *models.Genre) (*models.Genre, error) {
+	return m.CreateGenreMock(genre)
+}
+
+func (m GenreProviderMock) Update(genre *models.Genre) error {
+	return m.UpdateGenreMock(genre)
+}
+
+func (m GenreProviderMock) Delete(id int64) error {
+	return m.DeleteGenreMock(id)
+}
+
+func (m GenreProviderMock) Catalog() (models.GenreCatalog, error) {
+	return m.CatalogMock()
+}
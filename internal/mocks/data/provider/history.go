@@ -0,0 +1,16 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type HistoryProviderMock struct {
+	InsertWatchMock func(*models.Watch) error
+	StatsMock       func(int64) (*models.WatchStats, error)
+}
+
+func (m HistoryProviderMock) Insert(w *models.Watch) error {
+	return m.InsertWatchMock(w)
+}
+
+func (m HistoryProviderMock) Stats(userID int64) (*models.WatchStats, error) {
+	return m.StatsMock(userID)
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+)
+
+type ListProviderMock struct {
+	GetListMock       func(int64) (*models.List, error)
+	GetListBySlugMock func(string) (*models.List, error)
+	GetAllListsMock   func(data.ListSearch) ([]*models.List, *models.Metadata, error)
+	CreateListMock    func(*models.List) (*models.List, error)
+	UpdateListMock    func(*models.List) error
+	DeleteListMock    func(int64) error
+	AddItemMock       func(int64, *models.ListItem) error
+	UpdateNoteMock    func(int64, *models.ListItem) error
+	RemoveItemMock    func(int64, int64) error
+	ReorderMock       func(int64, []int64) error
+}
+
+func (m ListProviderMock) Get(id int64) (*models.List, error) {
+	return m.GetListMock(id)
+}
+
+func (m ListProviderMock) GetBySlug(slug string) (*models.List, error) {
+	return m.GetListBySlugMock(slug)
+}
+
+func (m ListProviderMock) GetAll(params data.ListSearch) ([]*models.List, *models.Metadata, error) {
+	return m.GetAllListsMock(params)
+}
+
+func (m ListProviderMock) Insert(l *models.List) (*models.List, error) {
+	return m.CreateListMock(l)
+}
+
+func (m ListProviderMock) Update(l *models.List) error {
+	return m.UpdateListMock(l)
+}
+
+func (m ListProviderMock) Delete(id int64) error {
+	return m.DeleteListMock(id)
+}
+
+func (m ListProviderMock) AddItem(listID int64, item *models.ListItem) error {
+	return m.AddItemMock(listID, item)
+}
+
+func (m ListProviderMock) UpdateNote(listID int64, item *models.ListItem) error {
+	return m.UpdateNoteMock(listID, item)
+}
+
+func (m ListProviderMock) RemoveItem(listID, movieID int64) error {
+	return m.RemoveItemMock(listID, movieID)
+}
+
+func (m ListProviderMock) Reorder(listID int64, movieIDs []int64) error {
+	return m.ReorderMock(listID, movieIDs)
+}
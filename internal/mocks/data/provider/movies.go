@@ -6,11 +6,14 @@ import (
 )
 
 type MovieProviderMock struct {
-	GetMovieMock     func(int64) (*models.Movie, error)
-	GetAllMoviesMock func(data.Search) ([]*models.Movie, *models.Metadata, error)
-	CreateMovieMock  func(*models.Movie) (*models.Movie, error)
-	UpdateMovieMock  func(models.Movie) error
-	DeleteMovieMock  func(int64) error
+	GetMovieMock      func(int64) (*models.Movie, error)
+	GetAllMoviesMock  func(data.Search) ([]*models.Movie, *models.Metadata, error)
+	SearchMoviesMock  func(data.TextSearch) ([]*models.MovieMatch, *models.Metadata, error)
+	SuggestMoviesMock func(string, int) ([]models.Suggestion, error)
+	MovieFacetsMock   func(data.Search) (models.Facets, error)
+	CreateMovieMock   func(*models.Movie) (*models.Movie, error)
+	UpdateMovieMock   func(models.Movie) error
+	DeleteMovieMock   func(int64) error
 }
 
 func (m MovieProviderMock) Get(id int64) (*models.Movie, error) {
@@ -21,6 +24,18 @@ func (m MovieProviderMock) GetAll(params data.Search) ([]*models.Movie, *models.
 	return m.GetAllMoviesMock(params)
 }
 
+func (m MovieProviderMock) Search(params data.TextSearch) ([]*models.MovieMatch, *models.Metadata, error) {
+	return m.SearchMoviesMock(params)
+}
+
+func (m MovieProviderMock) Suggest(prefix string, limit int) ([]models.Suggestion, error) {
+	return m.SuggestMoviesMock(prefix, limit)
+}
+
+func (m MovieProviderMock) Facets(params data.Search) (models.Facets, error) {
+	return m.MovieFacetsMock(params)
+}
+
 func (m MovieProviderMock) Insert(movie *models.Movie) (*models.Movie, error) {
 	return m.CreateMovieMock(movie)
 }
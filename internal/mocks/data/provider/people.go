@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+)
+
+type PersonProviderMock struct {
+	GetPersonMock    func(int64) (*models.Person, error)
+	GetAllPeopleMock func(data.PeopleSearch) ([]*models.Person, *models.Metadata, error)
+	CreatePersonMock func(*models.Person) (*models.Person, error)
+	UpdatePersonMock func(*models.Person) error
+	DeletePersonMock func(int64) error
+	CreditsMock      func(...int64) (map[int64][]*models.Credit, error)
+	SetCreditsMock   func(int64, []*models.Credit) error
+	FilmographyMock  func(int64) ([]*models.Credit, error)
+}
+
+func (m PersonProviderMock) Get(id int64) (*models.Person, error) {
+	return m.GetPersonMock(id)
+}
+
+func (m PersonProviderMock) GetAll(params data.PeopleSearch) ([]*models.Person, *models.Metadata, error) {
+	return m.GetAllPeopleMock(params)
+}
+
+func (m PersonProviderMock) Insert(person *models.Person) (*models.Person, error) {
+	return m.CreatePersonMock(person)
+}
+
+func (m PersonProviderMock) Update(person *models.Person) error {
+	return m.UpdatePersonMock(person)
+}
+
+func (m PersonProviderMock) Delete(id int64) error {
+	return m.DeletePersonMock(id)
+}
+
+func (m PersonProviderMock) Credits(movieIDs ...int64) (map[int64][]*models.Credit, error) {
+	return m.CreditsMock(movieIDs...)
+}
+
+func (m PersonProviderMock) SetCredits(movieID int64, credits []*models.Credit) error {
+	return m.SetCreditsMock(movieID, credits)
+}
+
+func (m PersonProviderMock) Filmography(personID int64) ([]*models.Credit, error) {
+	return m.FilmographyMock(personID)
+}
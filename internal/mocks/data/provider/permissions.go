@@ -0,0 +1,16 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type PermissionProviderMock struct {
+	GetAllForUserMock func(int64) (models.Permissions, error)
+	AddForUserMock    func(int64, ...string) error
+}
+
+func (m PermissionProviderMock) GetAllForUser(userID int64) (models.Permissions, error) {
+	return m.GetAllForUserMock(userID)
+}
+
+func (m PermissionProviderMock) AddForUser(userID int64, codes ...string) error {
+	return m.AddForUserMock(userID, codes...)
+}
@@ -0,0 +1,26 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type RatingProviderMock struct {
+	SetRatingMock         func(int64, *models.Rating) (bool, error)
+	DeleteRatingMock      func(int64, int64) error
+	GetRatingsForUserMock func(int64) (map[int64]int, error)
+	ForEachRatingMock     func(func(int64, models.Rating) error) error
+}
+
+func (m RatingProviderMock) Set(userID int64, r *models.Rating) (bool, error) {
+	return m.SetRatingMock(userID, r)
+}
+
+func (m RatingProviderMock) Delete(userID, movieID int64) error {
+	return m.DeleteRatingMock(userID, movieID)
+}
+
+func (m RatingProviderMock) GetAllForUser(userID int64) (map[int64]int, error) {
+	return m.GetRatingsForUserMock(userID)
+}
+
+func (m RatingProviderMock) ForEach(fn func(userID int64, r models.Rating) error) error {
+	return m.ForEachRatingMock(fn)
+}
@@ -0,0 +1,21 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type RelationProviderMock struct {
+	RelatedMock        func(...int64) (map[int64][]*models.RelatedMovie, error)
+	SetRelationMock    func(*models.Relation) error
+	DeleteRelationMock func(int64, int64) error
+}
+
+func (m RelationProviderMock) Related(movieIDs ...int64) (map[int64][]*models.RelatedMovie, error) {
+	return m.RelatedMock(movieIDs...)
+}
+
+func (m RelationProviderMock) Set(r *models.Relation) error {
+	return m.SetRelationMock(r)
+}
+
+func (m RelationProviderMock) Delete(movieID, relatedID int64) error {
+	return m.DeleteRelationMock(movieID, relatedID)
+}
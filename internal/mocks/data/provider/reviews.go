@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"yamda_go/internal/data"
+	"yamda_go/internal/models"
+)
+
+type ReviewProviderMock struct {
+	GetReviewMock     func(int64) (*models.Review, error)
+	GetAllReviewsMock func(data.ReviewSearch) ([]*models.Review, *models.Metadata, error)
+	CreateReviewMock  func(*models.Review) (*models.Review, error)
+	ModerateMock      func(*models.Review, int64) error
+	SetHelpfulMock    func(int64, int64, bool) error
+}
+
+func (m ReviewProviderMock) Get(id int64) (*models.Review, error) {
+	return m.GetReviewMock(id)
+}
+
+func (m ReviewProviderMock) GetAll(params data.ReviewSearch) ([]*models.Review, *models.Metadata, error) {
+	return m.GetAllReviewsMock(params)
+}
+
+func (m ReviewProviderMock) Insert(r *models.Review) (*models.Review, error) {
+	return m.CreateReviewMock(r)
+}
+
+func (m ReviewProviderMock) Moderate(r *models.Review, moderatorID int64) error {
+	return m.ModerateMock(r, moderatorID)
+}
+
+func (m ReviewProviderMock) SetHelpful(reviewID, userID int64, helpful bool) error {
+	return m.SetHelpfulMock(reviewID, userID, helpful)
+}
@@ -0,0 +1,11 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type SimilarMovieProviderMock struct {
+	SimilarMock func(int64, int) ([]*models.SimilarMovie, error)
+}
+
+func (m SimilarMovieProviderMock) Similar(id int64, limit int) ([]*models.SimilarMovie, error) {
+	return m.SimilarMock(id, limit)
+}
@@ -0,0 +1,19 @@
+package provider
+
+import (
+	"time"
+	"yamda_go/internal/models"
+)
+
+type TokenProviderMock struct {
+	NewTokenMock         func(int64, time.Duration, string) (*models.Token, error)
+	DeleteAllForUserMock func(string, int64) error
+}
+
+func (m TokenProviderMock) New(userID int64, ttl time.Duration, scope string) (*models.Token, error) {
+	return m.NewTokenMock(userID, ttl, scope)
+}
+
+func (m TokenProviderMock) DeleteAllForUser(scope string, userID int64) error {
+	return m.DeleteAllForUserMock(scope, userID)
+}
@@ -0,0 +1,26 @@
+package provider
+
+import "yamda_go/internal/models"
+
+type TranslationProviderMock struct {
+	GetAllTranslationsMock func(int64) ([]*models.Translation, error)
+	BestTranslationsMock   func([]string, ...int64) (map[int64]*models.Translation, error)
+	SetTranslationMock     func(*models.Translation) error
+	DeleteTranslationMock  func(int64, string) error
+}
+
+func (m TranslationProviderMock) GetAll(movieID int64) ([]*models.Translation, error) {
+	return m.GetAllTranslationsMock(movieID)
+}
+
+func (m TranslationProviderMock) Best(languages []string, movieIDs ...int64) (map[int64]*models.Translation, error) {
+	return m.BestTranslationsMock(languages, movieIDs...)
+}
+
+func (m TranslationProviderMock) Set(t *models.Translation) error {
+	return m.SetTranslationMock(t)
+}
+
+func (m TranslationProviderMock) Delete(movieID int64, language string) error {
+	return m.DeleteTranslationMock(movieID, language)
+}
@@ -3,10 +3,11 @@ package provider
 import "yamda_go/internal/models"
 
 type UserProviderMock struct {
-	InsertMock     func(*models.User) (*models.User, error)
-	GetByEmailMock func(string) (*models.User, error)
-	UpdateMock     func(user *models.User) error
-	DeleteMock     func(id int64) error
+	InsertMock      func(*models.User) (*models.User, error)
+	GetByEmailMock  func(string) (*models.User, error)
+	GetForTokenMock func(string, string) (*models.User, error)
+	UpdateMock      func(user *models.User) error
+	DeleteMock      func(id int64) error
 }
 
 func (u UserProviderMock) Insert(user *models.User) (*models.User, error) {
@@ -17,6 +18,10 @@ func (u UserProviderMock) GetByEmail(email string) (*models.User, error) {
 	return u.GetByEmailMock(email)
 }
 
+func (u UserProviderMock) GetForToken(scope, plaintext string) (*models.User, error) {
+	return u.GetForTokenMock(scope, plaintext)
+}
+
 func (u UserProviderMock) Update(user *models.User) error {
 	return u.UpdateMock(user)
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Windows of trending movies, by name.
+var TrendingWindows = map[string]time.Duration{
+	"day":  24 * time.Hour,
+	"week": 7 * 24 * time.Hour,
+}
+
+// Activity counts the events of a movie during an hour.
+type Activity struct {
+	MovieID  int64
+	Hour     time.Time
+	Views    int
+	ListAdds int
+	Ratings  int
+}
+
+// Trend is the weighted activity of a movie during a window.
+type Trend struct {
+	MovieID  int64
+	Activity int
+}
+
+// TrendingMovie is a movie with its weighted activity during a window.
+type TrendingMovie struct {
+	*Movie
+	Activity int `json:"activity"`
+}
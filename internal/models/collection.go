@@ -0,0 +1,129 @@
+package models
+
+import (
+	"strings"
+	"yamda_go/internal/validator"
+)
+
+// MaxCollectionMovies is the number of movies a collection can hold.
+const MaxCollectionMovies = 100
+
+// Collection is a set of movies meant to be watched in order, like a trilogy.
+type Collection struct {
+	ID          int64              `json:"id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	MovieCount  int                `json:"movieCount"`
+	Movies      []*CollectionMovie `json:"movies,omitempty"`
+	Version     int                `json:"version"`
+	CreatedAt   []uint8            `json:"-"`
+}
+
+// CollectionMovie is a movie of a collection.
+type CollectionMovie struct {
+	ID       int64  `json:"id"`
+	Title    string `json:"title"`
+	Year     int32  `json:"year"`
+	Position int    `json:"position"` //starting at 1
+}
+
+// CollectionSummary is the collection of a movie, as shown along with it.
+type CollectionSummary struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	Position   int    `json:"position"` //of the movie in the collection
+	MovieCount int    `json:"movieCount"`
+}
+
+// Validate uses a validator interface to validate the contents of a given collection.
+func (c *Collection) Validate(v *validator.Validator) {
+	name := strings.TrimSpace(c.Name)
+	v.Check(name != "", "name", "must be provided")
+	v.Check(len(name) <= 200, "name", "must not be more than 200 bytes long")
+	v.Check(len(c.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+}
+
+// ValidateCollectionMovies checks the movies of a collection, in order: each
+// once, at most MaxCollectionMovies.
+func ValidateCollectionMovies(v *validator.Validator, movieIDs []int64) {
+	v.Check(movieIDs != nil, "movieIds", "must be provided")
+	v.Check(len(movieIDs) <= MaxCollectionMovies, "movieIds", "must not contain more than 100 movies")
+	seen := make(map[int64]bool, len(movieIDs))
+	for _, id := range movieIDs {
+		v.Check(id > 0, "movieIds", "must contain movie ids only")
+		v.Check(!seen[id], "movieIds", "must not contain duplicate movies")
+		seen[id] = true
+	}
+}
+
+// Kinds of relations between two movies, read "<movie> <kind> <related movie>".
+const (
+	RelationSequelOf  = "sequel_of"
+	RelationRemakeOf  = "remake_of"
+	RelationSpinOffOf = "spin_off_of"
+)
+
+var RelationKinds = []string{RelationSequelOf, RelationRemakeOf, RelationSpinOffOf}
+
+// relationRoles are what each movie of a relation is to the other one: the
+// movie first, then the related movie.
+var relationRoles = map[string][2]string{
+	RelationSequelOf:  {"sequel", "prequel"},
+	RelationRemakeOf:  {"remake", "original"},
+	RelationSpinOffOf: {"spin_off", "parent"},
+}
+
+// Relation links a movie to another one.
+type Relation struct {
+	MovieID   int64  `json:"movieId"`
+	RelatedID int64  `json:"relatedId"`
+	Kind      string `json:"kind"`
+}
+
+// Validate uses a validator interface to validate the contents of a given relation.
+func (r *Relation) Validate(v *validator.Validator) {
+	v.Check(r.RelatedID > 0, "relatedId", "must be provided")
+	v.Check(r.RelatedID != r.MovieID, "relatedId", "must not be the movie itself")
+	v.Check(validator.In(r.Kind, RelationKinds...), "kind", "must be one of sequel_of, remake_of, spin_off_of")
+}
+
+// RelatedMovie is a movie related to another one, as shown along with it.
+// Role is what it is to that movie, e.g. "sequel" or "original".
+type RelatedMovie struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Year  int32  `json:"year"`
+	Role  string `json:"relation"`
+}
+
+// RelationRole returns what the related movie of a relation is to the movie,
+// e.g. "prequel" for sequel_of, or the other way round when reversed ("sequel").
+func RelationRole(kind string, reversed bool) string {
+	roles := relationRoles[kind]
+	if reversed {
+		return roles[0]
+	}
+	return roles[1]
+}
+
+// ClosesCycle tells whether linking from to to would close a cycle in the
+// graph of the given links, from a movie to the ones it follows: whether from
+// can already be reached from to.
+func ClosesCycle(links map[int64][]int64, from, to int64) bool {
+	seen := map[int64]bool{to: true}
+	stack := []int64{to}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == from {
+			return true
+		}
+		for _, next := range links[id] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"yamda_go/internal/validator"
+)
+
+// SlugRX matches lower case words separated by hyphens, e.g. "science-fiction".
+var SlugRX = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+
+type Genre struct {
+	ID      int64    `json:"id"`
+	Slug    string   `json:"slug"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+	Version int      `json:"version"`
+}
+
+// Validate uses a validator interface to validate the contents of a given genre.
+func (g *Genre) Validate(v *validator.Validator) {
+	v.Check(g.Name != "", "name", "must be provided")
+	v.Check(len(g.Name) <= 50, "name", "must not be more than 50 bytes long")
+	v.Check(g.Slug != "", "slug", "must be provided")
+	v.Check(len(g.Slug) <= 50, "slug", "must not be more than 50 bytes long")
+	v.Check(validator.Matches(g.Slug, SlugRX), "slug", "must only contain lower case letters, digits and hyphens")
+	v.Check(len(g.Aliases) <= 20, "aliases", "must not contain more than 20 aliases")
+	for _, a := range g.Aliases {
+		v.Check(strings.TrimSpace(a) != "", "aliases", "must not contain empty values")
+		v.Check(len(a) <= 50, "aliases", "must not contain values longer than 50 bytes")
+		v.Check(!strings.Contains(a, ","), "aliases", "must not contain commas")
+	}
+	v.Check(validator.Unique(g.Aliases), "aliases", "must not contain duplicate values")
+}
+
+// Slugify derives a slug from a genre name, e.g. "Sci-Fi & Fantasy" -> "sci-fi-fantasy".
+func Slugify(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r))
+	})
+	return strings.Join(words, "-")
+}
+
+// GenreCatalog resolves the names clients use for genres (display names, slugs
+// and aliases, whatever their case or punctuation) to the canonical names.
+type GenreCatalog map[string]string
+
+// genreKey reduces a genre name to its letters and digits in lower case, so
+// that "Sci-Fi", "sci fi" and "SciFi" are the same.
+func genreKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// NewGenreCatalog builds the catalog of the genres. Display names and slugs
+// win over aliases when they clash.
+func NewGenreCatalog(genres []*Genre) GenreCatalog {
+	c := make(GenreCatalog)
+	for _, g := range genres {
+		for _, a := range g.Aliases {
+			c[genreKey(a)] = g.Name
+		}
+	}
+	for _, g := range genres {
+		c[genreKey(g.Slug)] = g.Name
+		c[genreKey(g.Name)] = g.Name
+	}
+	return c
+}
+
+// Resolve returns the canonical name of a genre, or false when it is unknown.
+func (c GenreCatalog) Resolve(name string) (string, bool) {
+	canonical, ok := c[genreKey(name)]
+	return canonical, ok
+}
+
+// ResolveAll replaces the genres by their canonical names, adding an error for
+// the first unknown one.
+func (c GenreCatalog) ResolveAll(genres []string, v *validator.Validator) []string {
+	return resolveGenres(genres, c, v)
+}
+
+// resolveGenres replaces the genres by their canonical names, adding an error
+// for the first unknown one.
+func resolveGenres(genres []string, catalog GenreCatalog, v *validator.Validator) []string {
+	resolved := make([]string, 0, len(genres))
+	for _, g := range genres {
+		canonical, ok := catalog.Resolve(g)
+		if !ok {
+			v.AddError("genres", fmt.Sprintf("unknown genre %q", strings.TrimSpace(g)))
+			canonical = g
+		}
+		resolved = append(resolved, canonical)
+	}
+	return resolved
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+	"yamda_go/internal/validator"
+)
+
+// DateLayout is how days are written, e.g. "2024-03-31".
+const DateLayout = "2006-01-02"
+
+// Watch is a movie a user watched on a day.
+type Watch struct {
+	ID        int64   `json:"id"`
+	UserID    int64   `json:"-"`
+	MovieID   int64   `json:"movieId"`
+	WatchedOn string  `json:"watchedOn"`
+	Rewatch   *bool   `json:"rewatch"` //told by the provider when not given
+	Runtime   Runtime `json:"runtime"` //of the movie when it was logged
+	CreatedAt []uint8 `json:"-"`
+}
+
+// Validate uses a validator interface to validate the contents of a given watch.
+func (w *Watch) Validate(v *validator.Validator) {
+	v.Check(w.MovieID > 0, "movieId", "must be provided")
+	day, err := time.Parse(DateLayout, w.WatchedOn)
+	v.Check(err == nil, "watchedOn", "must be a date like 2024-03-31")
+	if err == nil {
+		v.Check(day.Year() >= 1888, "watchedOn", "must be greater than 1888")
+		//a day of margin for the users ahead of UTC
+		v.Check(!day.After(time.Now().UTC().AddDate(0, 0, 1)), "watchedOn", "must not be in the future")
+	}
+}
+
+// How many genres and streaks the stats show.
+const (
+	StatsTopGenres = 5
+	StatsStreaks   = 3
+)
+
+// WatchStats sums up the watch history of a user.
+type WatchStats struct {
+	Watched   int           `json:"watched"` //rewatches included
+	Movies    int           `json:"movies"`
+	Rewatches int           `json:"rewatches"`
+	Runtime   Runtime       `json:"runtime"`
+	TopGenres []GenreCount  `json:"topGenres"`
+	PerYear   []PeriodCount `json:"perYear"`
+	PerMonth  []PeriodCount `json:"perMonth"`
+	Streaks   []Streak      `json:"longestStreaks"`
+}
+
+type GenreCount struct {
+	Genre string `json:"genre"`
+	Count int    `json:"count"`
+}
+
+// PeriodCount is the number of movies watched in a year ("2024") or a month ("2024-03").
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+// Streak is a run of days in a row with at least a movie watched each.
+type Streak struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Days int    `json:"days"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"yamda_go/internal/validator"
+)
+
+// Who can see a list. Private lists are for their owner only, unlisted ones
+// for whoever has their share slug and public ones are listed for everybody.
+const (
+	ListPrivate  = "private"
+	ListUnlisted = "unlisted"
+	ListPublic   = "public"
+)
+
+var ListVisibilities = []string{ListPrivate, ListUnlisted, ListPublic}
+
+// MaxListItems is the number of movies a list can hold.
+const MaxListItems = 1000
+
+type List struct {
+	ID          int64       `json:"id"`
+	UserID      int64       `json:"userId"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Visibility  string      `json:"visibility"`
+	Slug        string      `json:"slug"` //shares the list at /v1/lists/:slug, unless private
+	ItemCount   int         `json:"itemCount"`
+	Items       []*ListItem `json:"items,omitempty"`
+	Version     int         `json:"version"`
+	CreatedAt   []uint8     `json:"-"`
+}
+
+// ListItem is a movie in a list, along with the note of the owner.
+type ListItem struct {
+	MovieID  int64   `json:"movieId"`
+	Title    string  `json:"title"`
+	Year     int32   `json:"year"`
+	Position int     `json:"position"` //starting at 1
+	Note     string  `json:"note,omitempty"`
+	AddedAt  []uint8 `json:"-"`
+}
+
+// Validate uses a validator interface to validate the contents of a given list.
+func (l *List) Validate(v *validator.Validator) {
+	name := strings.TrimSpace(l.Name)
+	v.Check(name != "", "name", "must be provided")
+	v.Check(len(name) <= 100, "name", "must not be more than 100 bytes long")
+	v.Check(len(l.Description) <= 2000, "description", "must not be more than 2000 bytes long")
+	v.Check(validator.In(l.Visibility, ListVisibilities...), "visibility", "must be one of private, unlisted, public")
+}
+
+// Validate uses a validator interface to validate the contents of a given item.
+func (i *ListItem) Validate(v *validator.Validator) {
+	v.Check(i.MovieID > 0, "movieId", "must be provided")
+	v.Check(i.Position >= 0, "position", "must not be negative")
+	v.Check(len(i.Note) <= 500, "note", "must not be more than 500 bytes long")
+}
+
+// ValidateOrder checks a new order for the movies of a list: all of them,
+// each once.
+func ValidateOrder(v *validator.Validator, movieIDs []int64) {
+	v.Check(len(movieIDs) <= MaxListItems, "movieIds", "must not contain more than 1000 movies")
+	seen := make(map[int64]bool, len(movieIDs))
+	for _, id := range movieIDs {
+		v.Check(id > 0, "movieIds", "must contain movie ids only")
+		v.Check(!seen[id], "movieIds", "must not contain duplicate movies")
+		seen[id] = true
+	}
+}
+
+// GenerateSlug returns a random, unguessable slug to share a list with.
+func GenerateSlug() (string, error) {
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)), nil
+}
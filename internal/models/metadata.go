@@ -8,6 +8,9 @@ type Metadata struct {
 	FirstPage    int `json:"firstPage,omitempty"`
 	LastPage     int `json:"lastPage,omitempty"`
 	TotalRecords int `json:"totalRecords,omitempty"`
+	//opaque cursors to the next and previous pages (keyset pagination)
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
 }
 
 func New(totalRecords, page, pageSize int) Metadata {
@@ -24,3 +27,12 @@ func New(totalRecords, page, pageSize int) Metadata {
 		TotalRecords: totalRecords,
 	}
 }
+
+// FacetCount is the number of results having a value of a facet.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets are the counts of each requested facet, keyed by facet name.
+type Facets map[string][]FacetCount
@@ -14,10 +14,19 @@ type Movie struct {
 	Year      int32    `json:"year,omitempty"`
 	Version   int      `json:"version"`
 	CreatedAt []uint8  `json:"-"`
+	//in the language of the request, see Translation
+	OriginalTitle string `json:"originalTitle,omitempty"` //when Title is translated
+	Overview      string `json:"overview,omitempty"`
+	//ratings of the users, see Rating
+	AverageRating  float64 `json:"averageRating,omitempty"`
+	Votes          int     `json:"votes,omitempty"`
+	WeightedRating float64 `json:"-"` //what sort=rating goes by
+	Popularity     float64 `json:"-"` //what sort=popularity goes by, see Activity
 }
 
 // Validate uses a validator interface to validate the contents of a given movie.
-func (m *Movie) Validate(v *validator.Validator) {
+// Genres are replaced by their canonical name in the catalog, unknown ones are rejected.
+func (m *Movie) Validate(v *validator.Validator, genres GenreCatalog) {
 	v.Check(m.Title != "", "title", "must be provided")
 	title := strings.TrimSpace(m.Title)
 	v.Check(len(title) >= 1 && len(title) <= 500, "title", "must not be empty or more than 500 bytes long")
@@ -29,12 +38,47 @@ func (m *Movie) Validate(v *validator.Validator) {
 	v.Check(m.Genres != nil, "genres", "must be provided")
 	v.Check(len(m.Genres) >= 1, "genres", "must contain at least 1 genre")
 	v.Check(len(m.Genres) <= 5, "genres", "must not contain more than 5 genres")
+	m.Genres = resolveGenres(m.Genres, genres, v)
+	//aliases of the same genre are duplicates as well
 	v.Check(validator.Unique(m.Genres), "genres", "must not contain duplicate values")
 }
 
 // ValidateWithId performs the same validations as the functions Validate plus validates
 // if the movie has an ID bigger than 0.
-func (m *Movie) ValidateWithId(v *validator.Validator) {
+func (m *Movie) ValidateWithId(v *validator.Validator, genres GenreCatalog) {
 	v.Check(m.ID > 0, "ID", "ID must be provided and bigger than 0")
-	m.Validate(v)
+	m.Validate(v, genres)
+}
+
+// MovieMatch is a movie found by a full-text search, with its relevance score.
+type MovieMatch struct {
+	Movie *Movie
+	Score float64
+}
+
+// Suggestion is a movie title completing what a user started to type.
+type Suggestion struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+// SimilarMovie is a movie like another one, with their similarity between 0 and 1.
+type SimilarMovie struct {
+	*Movie
+	Similarity float64 `json:"similarity"`
+}
+
+// Where a recommendation comes from, from the most to the least personal.
+const (
+	RecommendedCollaborative = "collaborative" //users who liked the same movies liked it
+	RecommendedContent       = "content"       //alike movies the user liked
+	RecommendedPopular       = "popular"       //rated best by everybody
+)
+
+// Recommendation is a movie recommended to a user. PredictedScore is the score
+// they would likely give to it, for collaborative recommendations only.
+type Recommendation struct {
+	*Movie
+	Source         string  `json:"source"`
+	PredictedScore float64 `json:"predictedScore,omitempty"`
 }
@@ -0,0 +1,22 @@
+package models
+
+// Permission codes granted to users.
+const (
+	PermissionModerateReviews = "reviews:moderate"
+)
+
+// PermissionCodes are every code that can be granted.
+var PermissionCodes = []string{PermissionModerateReviews}
+
+// Permissions are the codes granted to a user.
+type Permissions []string
+
+// Include tells if the code was granted.
+func (p Permissions) Include(code string) bool {
+	for _, c := range p {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"yamda_go/internal/validator"
+)
+
+// Roles people can have in a movie.
+var CreditRoles = []string{"director", "writer", "actor", "producer", "composer", "cinematographer", "editor"}
+
+type Person struct {
+	ID        int64   `json:"id"`
+	Name      string  `json:"name"`
+	BirthYear int32   `json:"birthYear,omitempty"`
+	Version   int     `json:"version"`
+	CreatedAt []uint8 `json:"-"`
+}
+
+// Validate uses a validator interface to validate the contents of a given person.
+func (p *Person) Validate(v *validator.Validator) {
+	name := strings.TrimSpace(p.Name)
+	v.Check(name != "", "name", "must be provided")
+	v.Check(len(name) <= 200, "name", "must not be more than 200 bytes long")
+	v.Check(p.BirthYear == 0 || p.BirthYear >= 1800, "birthYear", "must be greater than 1800")
+	v.Check(p.BirthYear <= int32(time.Now().Year()), "birthYear", "must not be in the future")
+}
+
+// Credit is the part a person had in a movie. Movie credits carry the person
+// (PersonID, Name), filmographies the movie (MovieID, Title, Year).
+type Credit struct {
+	ID        int64  `json:"id"`
+	MovieID   int64  `json:"movieId,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Year      int32  `json:"year,omitempty"`
+	PersonID  int64  `json:"personId,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Role      string `json:"role"`
+	Character string `json:"character,omitempty"` //actors only
+	Billing   int    `json:"billing"`             //order in the credits, starting at 1
+}
+
+// ValidateCredits checks the credits of a movie. A person plays a character
+// once in a role, whatever the case of the character.
+func ValidateCredits(v *validator.Validator, credits []*Credit) {
+	v.Check(len(credits) <= 500, "credits", "must not contain more than 500 credits")
+	keys := make([]string, len(credits))
+	for i, c := range credits {
+		keys[i] = fmt.Sprintf("%d|%s|%s", c.PersonID, c.Role, strings.ToLower(c.Character))
+		v.Check(c.PersonID > 0, "personId", "must be provided")
+		v.Check(validator.In(c.Role, CreditRoles...), "role", "must be one of "+strings.Join(CreditRoles, ", "))
+		v.Check(c.Character == "" || c.Role == "actor", "character", "only actors play a character")
+		v.Check(len(c.Character) <= 200, "character", "must not be more than 200 bytes long")
+		v.Check(c.Billing > 0, "billing", "must be bigger than 0")
+	}
+	v.Check(validator.Unique(keys), "credits", "must not contain the same credit twice")
+}
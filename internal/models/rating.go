@@ -0,0 +1,31 @@
+package models
+
+import (
+	"math"
+	"yamda_go/internal/validator"
+)
+
+// Scores users can give to a movie.
+const (
+	MinScore = 1
+	MaxScore = 10
+)
+
+// Rating is the score a user gave to a movie.
+type Rating struct {
+	MovieID int64 `json:"movieId"`
+	Score   int   `json:"score"`
+}
+
+func (r *Rating) Validate(v *validator.Validator) {
+	v.Check(r.Score >= MinScore && r.Score <= MaxScore, "score", "must be between 1 and 10")
+}
+
+// AverageRating is the mean of the scores, rounded to two decimals, or 0
+// without votes.
+func AverageRating(sum, votes int) float64 {
+	if votes == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(votes)*100) / 100
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+	"yamda_go/internal/validator"
+)
+
+// Moderation states of a review. Reviews start pending and are either approved,
+// becoming public, or rejected by a moderator.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
+var ReviewStatuses = []string{ReviewPending, ReviewApproved, ReviewRejected}
+
+// reviewTransitions lists the states each state can move to.
+var reviewTransitions = map[string][]string{
+	ReviewPending: {ReviewApproved, ReviewRejected},
+}
+
+var ErrInvalidTransition = errors.New("invalid review status transition")
+
+type Review struct {
+	ID          int64    `json:"id"`
+	MovieID     int64    `json:"movieId"`
+	UserID      int64    `json:"userId"`
+	Body        string   `json:"body"`
+	Status      string   `json:"status"`
+	Flags       []string `json:"flags,omitempty"` //set by the pre-screen, for moderators
+	Helpful     int      `json:"helpful"`
+	ModeratedBy int64    `json:"-"`
+	Version     int      `json:"version"`
+	CreatedAt   []uint8  `json:"-"`
+}
+
+// Validate uses a validator interface to validate the contents of a given review.
+func (r *Review) Validate(v *validator.Validator) {
+	body := strings.TrimSpace(r.Body)
+	v.Check(body != "", "body", "must be provided")
+	v.Check(utf8.RuneCountInString(body) >= 20, "body", "must be at least 20 characters long")
+	v.Check(len(body) <= 10_000, "body", "must not be more than 10000 bytes long")
+}
+
+// Transition moves the review to another moderation state, if it can go there.
+func (r *Review) Transition(to string) error {
+	for _, s := range reviewTransitions[r.Status] {
+		if s == to {
+			r.Status = to
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: from %s to %s", ErrInvalidTransition, r.Status, to)
+}
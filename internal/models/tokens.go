@@ -0,0 +1,53 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"time"
+	"yamda_go/internal/validator"
+)
+
+// Scopes a token can be used for.
+const (
+	ScopeAuthentication = "authentication"
+	ScopeActivation     = "activation"
+)
+
+// Token is handed out to clients in plain text, only its hash is stored.
+type Token struct {
+	Plaintext string    `json:"token"`
+	Hash      []byte    `json:"-"`
+	UserID    int64     `json:"-"`
+	Expiry    time.Time `json:"expiry"`
+	Scope     string    `json:"-"`
+}
+
+// GenerateToken creates a random token for the user, valid for ttl.
+func GenerateToken(userID int64, ttl time.Duration, scope string) (*Token, error) {
+	token := &Token{
+		UserID: userID,
+		Expiry: time.Now().Add(ttl).UTC().Truncate(time.Second),
+		Scope:  scope,
+	}
+
+	randomBytes := make([]byte, 16)
+	if _, err := rand.Read(randomBytes); err != nil {
+		return nil, err
+	}
+	//26 characters long, e.g. "Y3QMGX3PJ3WLRL2YRTQGQ6KRHU"
+	token.Plaintext = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(randomBytes)
+	token.Hash = TokenHash(token.Plaintext)
+	return token, nil
+}
+
+// TokenHash returns the hash a token is stored under.
+func TokenHash(plaintext string) []byte {
+	hash := sha256.Sum256([]byte(plaintext))
+	return hash[:]
+}
+
+func ValidateTokenPlaintext(v *validator.Validator, plaintext string) {
+	v.Check(plaintext != "", "token", "must be provided")
+	v.Check(len(plaintext) == 26, "token", "must be 26 bytes long")
+}
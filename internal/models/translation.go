@@ -0,0 +1,30 @@
+package models
+
+import (
+	"strings"
+	"yamda_go/internal/locale"
+	"yamda_go/internal/validator"
+)
+
+// Translation is the title and overview of a movie in another language than
+// the one of the catalogue.
+type Translation struct {
+	MovieID  int64  `json:"movieId"`
+	Language string `json:"language"` //BCP 47 tag, e.g. "pt-BR"
+	Title    string `json:"title"`
+	Overview string `json:"overview"`
+}
+
+// Validate uses a validator interface to validate the contents of a given translation.
+// The language is replaced by its canonical tag, e.g. "pt-br" by "pt-BR".
+func (t *Translation) Validate(v *validator.Validator) {
+	tag, err := locale.Canonical(t.Language)
+	v.Check(err == nil, "language", "must be a BCP 47 language tag, e.g. pt-BR")
+	if err == nil {
+		t.Language = tag
+	}
+	t.Title = strings.TrimSpace(t.Title)
+	v.Check(t.Title != "", "title", "must be provided")
+	v.Check(len(t.Title) <= 500, "title", "must not be more than 500 bytes long")
+	v.Check(len(t.Overview) <= 5000, "overview", "must not be more than 5000 bytes long")
+}
@@ -0,0 +1,69 @@
+// Package moderation pre-screens user content before a moderator looks at it.
+package moderation
+
+import (
+	"regexp"
+	"strings"
+	"yamda_go/internal/search"
+)
+
+// Reasons a text can be flagged for.
+const (
+	FlagProfanity = "profanity"
+	FlagLink      = "link"
+)
+
+// linkRX matches URLs and bare domain names, e.g. "https://x.io/a", "www.spam.net"
+// or "cheap-pills.com".
+var linkRX = regexp.MustCompile(`(?i)(\b[a-z][a-z0-9+.-]*://\S+|\bwww\.\S+|\b[a-z0-9-]+(\.[a-z0-9-]+)*\.(com|net|org|info|biz|io|co|ru|xyz|top|ly|me)\b)`)
+
+// Screener flags texts containing words of a list or links. It is safe for
+// concurrent use once created.
+type Screener struct {
+	words   map[string]bool
+	phrases [][]string //entries of several words, matched in a row
+}
+
+// NewScreener creates a screener for the words of the list. Entries are
+// matched whatever their case or accents, and may span several words.
+func NewScreener(list []string) *Screener {
+	s := &Screener{words: make(map[string]bool)}
+	for _, entry := range list {
+		words := search.Words(entry)
+		switch len(words) {
+		case 0:
+		case 1:
+			s.words[words[0]] = true
+		default:
+			s.phrases = append(s.phrases, words)
+		}
+	}
+	return s
+}
+
+// Screen returns the reasons the text should be looked at by a moderator, in
+// a stable order, or nothing when it looks fine.
+func (s *Screener) Screen(text string) []string {
+	var flags []string
+	if s.profane(search.Words(text)) {
+		flags = append(flags, FlagProfanity)
+	}
+	if linkRX.MatchString(text) {
+		flags = append(flags, FlagLink)
+	}
+	return flags
+}
+
+func (s *Screener) profane(words []string) bool {
+	for i, w := range words {
+		if s.words[w] {
+			return true
+		}
+		for _, p := range s.phrases {
+			if i+len(p) <= len(words) && strings.Join(words[i:i+len(p)], " ") == strings.Join(p, " ") {
+				return true
+			}
+		}
+	}
+	return false
+}
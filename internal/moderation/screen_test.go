@@ -0,0 +1,32 @@
+package moderation
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestScreener_Screen(t *testing.T) {
+	is := is2.New(t)
+
+	s := NewScreener([]string{"Darn", "piece of junk", " "})
+
+	tests := []struct {
+		text  string
+		flags []string
+	}{
+		{"A moving film about grief.", nil},
+		{"What a DARN waste of time", []string{FlagProfanity}},
+		{"Dárn it", []string{FlagProfanity}},
+		{"darning socks is more fun", nil},
+		{"This piece of junk bored me", []string{FlagProfanity}},
+		{"a piece of cake", nil},
+		{"Watch it free at https://example.com/watch", []string{FlagLink}},
+		{"go to www.cheap-movies.net now", []string{FlagLink}},
+		{"buy at cheap-pills.com, darn cheap", []string{FlagProfanity, FlagLink}},
+		{"The end... it was fine.Really", nil},
+	}
+	for _, tt := range tests {
+		is.Equal(s.Screen(tt.text), tt.flags) // tt.text
+	}
+}
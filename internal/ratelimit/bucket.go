@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// Result describes the outcome of taking a token from a bucket. Besides telling
+// whether the request is allowed, it exposes the bucket state so callers can
+// advertise it to clients (e.g. RateLimit-* headers).
+type Result struct {
+	Allowed    bool
+	Limit      int           // size of the bucket
+	Remaining  int           // whole tokens left after this request
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token is available, zero when allowed
+}
+
+// TokenBucket implements the token-bucket algorithm. It starts full with Burst
+// tokens and refills at Rate tokens per second, up to Burst.
+//
+// TokenBucket is not safe for concurrent use.
+type TokenBucket struct {
+	Rate   float64
+	Burst  int
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full bucket.
+func NewTokenBucket(rate float64, burst int, now time.Time) *TokenBucket {
+	return &TokenBucket{
+		Rate:   rate,
+		Burst:  burst,
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// Take tries to remove one token from the bucket at the instant now.
+func (b *TokenBucket) Take(now time.Time) Result {
+	b.refill(now)
+
+	res := Result{Limit: b.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else if b.Rate > 0 {
+		res.RetryAfter = durationFromTokens(1-b.tokens, b.Rate)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	if b.Rate > 0 {
+		res.Reset = durationFromTokens(float64(b.Burst)-b.tokens, b.Rate)
+	}
+	return res
+}
+
+func (b *TokenBucket) refill(now time.Time) {
+	if now.Before(b.last) {
+		//clock went backwards; don't hand out tokens for it.
+		b.last = now
+		return
+	}
+	elapsed := now.Sub(b.last).Seconds()
+	b.tokens = math.Min(float64(b.Burst), b.tokens+elapsed*b.Rate)
+	b.last = now
+}
+
+func durationFromTokens(tokens, rate float64) time.Duration {
+	return time.Duration(tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,47 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestTokenBucket_TakeUntilEmpty(t *testing.T) {
+	is := is2.New(t)
+
+	now := time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC)
+	b := NewTokenBucket(2, 4, now)
+
+	for i := 3; i >= 0; i-- {
+		res := b.Take(now)
+		is.True(res.Allowed)
+		is.Equal(res.Limit, 4)
+		is.Equal(res.Remaining, i)
+		is.Equal(res.RetryAfter, time.Duration(0))
+	}
+
+	res := b.Take(now)
+	is.True(!res.Allowed)
+	is.Equal(res.Remaining, 0)
+	is.Equal(res.RetryAfter, 500*time.Millisecond) //2 tokens per second
+	is.Equal(res.Reset, 2*time.Second)
+}
+
+func TestTokenBucket_Refills(t *testing.T) {
+	is := is2.New(t)
+
+	now := time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC)
+	b := NewTokenBucket(1, 2, now)
+	b.Take(now)
+	b.Take(now)
+	is.True(!b.Take(now).Allowed)
+
+	res := b.Take(now.Add(time.Second))
+	is.True(res.Allowed)
+
+	//never more than the burst
+	res = b.Take(now.Add(time.Hour))
+	is.True(res.Allowed)
+	is.Equal(res.Remaining, 1)
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Policy defines how many requests per second a client is allowed and how
+// big a burst it may send.
+type Policy struct {
+	RPS   float64
+	Burst int
+}
+
+// Policies holds the policy applied to each route, identified as
+// "METHOD /pattern" (e.g. "POST /v1/users"). Routes without a policy of their
+// own share the Default one.
+type Policies struct {
+	Default Policy
+	Routes  map[string]Policy
+}
+
+// ParsePolicies builds Policies from a specification in the form
+//
+//	METHOD /pattern=RPS:BURST,METHOD /pattern=RPS:BURST
+//
+// An empty specification means every route uses the default policy.
+func ParsePolicies(def Policy, spec string) (Policies, error) {
+	p := Policies{Default: def, Routes: make(map[string]Policy)}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		route, limits, ok := strings.Cut(entry, "=")
+		if !ok {
+			return Policies{}, fmt.Errorf("invalid rate limit policy %q: missing '='", entry)
+		}
+		route = strings.Join(strings.Fields(route), " ")
+		if len(strings.Fields(route)) != 2 {
+			return Policies{}, fmt.Errorf("invalid rate limit policy %q: route must be 'METHOD /pattern'", entry)
+		}
+		rps, burst, ok := strings.Cut(limits, ":")
+		if !ok {
+			return Policies{}, fmt.Errorf("invalid rate limit policy %q: limits must be 'RPS:BURST'", entry)
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(rps), 64)
+		if err != nil || r < 0 {
+			return Policies{}, fmt.Errorf("invalid rate limit policy %q: bad rps", entry)
+		}
+		b, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil || b < 1 {
+			return Policies{}, fmt.Errorf("invalid rate limit policy %q: bad burst", entry)
+		}
+		p.Routes[route] = Policy{RPS: r, Burst: b}
+	}
+	return p, nil
+}
+
+// For returns the policy of a route together with the scope its buckets live in.
+// Routes without a policy of their own all share the "default" scope, so a
+// client has a single bucket for all of them.
+func (p Policies) For(route string) (string, Policy) {
+	if pol, ok := p.Routes[route]; ok {
+		return route, pol
+	}
+	return "default", p.Default
+}
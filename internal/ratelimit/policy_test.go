@@ -0,0 +1,38 @@
+package ratelimit
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestParsePolicies_Ok(t *testing.T) {
+	is := is2.New(t)
+
+	def := Policy{RPS: 2, Burst: 4}
+	p, err := ParsePolicies(def, "POST /v1/tokens/authentication=0.2:3, POST  /v1/users=0.5:2")
+	is.NoErr(err)
+
+	scope, pol := p.For("POST /v1/users")
+	is.Equal(scope, "POST /v1/users")
+	is.Equal(pol, Policy{RPS: 0.5, Burst: 2})
+
+	scope, pol = p.For("GET /v1/movies")
+	is.Equal(scope, "default")
+	is.Equal(pol, def)
+}
+
+func TestParsePolicies_Invalid(t *testing.T) {
+	is := is2.New(t)
+
+	for _, spec := range []string{
+		"POST /v1/users",
+		"/v1/users=1:2",
+		"POST /v1/users=1",
+		"POST /v1/users=x:2",
+		"POST /v1/users=1:0",
+	} {
+		_, err := ParsePolicies(Policy{}, spec)
+		is.True(err != nil)
+	}
+}
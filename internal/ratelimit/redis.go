@@ -0,0 +1,252 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gcraScript implements the Generic Cell Rate Algorithm atomically inside Redis.
+// The only state kept per key is the theoretical arrival time (TAT) of the next
+// request, in microseconds. The clock of the Redis server is used so that every
+// replica of the API agrees on the current time.
+//
+// KEYS[1] bucket key, ARGV[1] emission interval (µs), ARGV[2] burst.
+// Returns {allowed, remaining, reset (µs), retry after (µs)}.
+//
+// The TAT is stored formatted as an integer: Redis would write a Lua number
+// with 14 significant digits, dropping the last ones of a µs timestamp.
+const gcraScript = `
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+  tat = now
+end
+local new_tat = tat + interval
+local allow_at = new_tat - burst * interval
+if now < allow_at then
+  return {0, 0, tat - now, allow_at - now}
+end
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, math.floor((now - allow_at) / interval), new_tat - now, 0}
+`
+
+var gcraScriptSHA = func() string {
+	sum := sha1.Sum([]byte(gcraScript))
+	return hex.EncodeToString(sum[:])
+}()
+
+// maxInterval is used as emission interval for policies that never refill.
+const maxInterval = 365 * 24 * time.Hour
+
+// RedisStore keeps GCRA state in a Redis server, so that every instance of the
+// API shares the same quota. It speaks the Redis protocol (RESP) directly.
+type RedisStore struct {
+	addr    string
+	prefix  string
+	timeout time.Duration
+	idle    chan *redisConn
+}
+
+// NewRedisStore creates a store talking to the Redis server at addr. Keys are
+// namespaced with prefix and at most poolSize idle connections are kept around.
+func NewRedisStore(addr, prefix string, poolSize int) *RedisStore {
+	if poolSize < 1 {
+		poolSize = 1
+	}
+	return &RedisStore{
+		addr:    addr,
+		prefix:  prefix,
+		timeout: time.Second,
+		idle:    make(chan *redisConn, poolSize),
+	}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	interval := maxInterval
+	if policy.RPS > 0 {
+		interval = time.Duration(float64(time.Second) / policy.RPS)
+	}
+	args := []string{
+		s.prefix + key,
+		strconv.FormatInt(interval.Microseconds(), 10),
+		strconv.Itoa(policy.Burst),
+	}
+
+	reply, err := s.do(ctx, append([]string{"EVALSHA", gcraScriptSHA, "1"}, args...)...)
+	var rerr redisError
+	if errors.As(err, &rerr) && strings.HasPrefix(string(rerr), "NOSCRIPT") {
+		//first use on this server, EVAL caches the script for the next calls.
+		reply, err = s.do(ctx, append([]string{"EVAL", gcraScript, "1"}, args...)...)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return Result{}, fmt.Errorf("unexpected reply from rate limit script: %v", reply)
+	}
+	nums := make([]int64, len(values))
+	for i, v := range values {
+		if nums[i], ok = v.(int64); !ok {
+			return Result{}, fmt.Errorf("unexpected reply from rate limit script: %v", reply)
+		}
+	}
+	return Result{
+		Allowed:    nums[0] == 1,
+		Limit:      policy.Burst,
+		Remaining:  int(nums[1]),
+		Reset:      time.Duration(nums[2]) * time.Microsecond,
+		RetryAfter: time.Duration(nums[3]) * time.Microsecond,
+	}, nil
+}
+
+// Close closes every idle connection.
+func (s *RedisStore) Close() error {
+	for {
+		select {
+		case c := <-s.idle:
+			_ = c.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// do sends a command on a pooled connection and returns its reply. Connections
+// that failed at the network level are discarded.
+func (s *RedisStore) do(ctx context.Context, args ...string) (interface{}, error) {
+	c, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = c.SetDeadline(deadline)
+
+	reply, err := c.do(args...)
+	var rerr redisError
+	if err != nil && !errors.As(err, &rerr) {
+		_ = c.Close()
+		return nil, err
+	}
+
+	select {
+	case s.idle <- c:
+	default:
+		_ = c.Close()
+	}
+	return reply, err
+}
+
+func (s *RedisStore) conn(ctx context.Context) (*redisConn, error) {
+	select {
+	case c := <-s.idle:
+		return c, nil
+	default:
+	}
+	d := net.Dialer{Timeout: s.timeout}
+	nc, err := d.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, err
+	}
+	return &redisConn{Conn: nc, rd: bufio.NewReader(nc)}, nil
+}
+
+// redisError is an error reply sent by the server. The connection is still usable.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+type redisConn struct {
+	net.Conn
+	rd *bufio.Reader
+}
+
+// do writes the command as a RESP array of bulk strings and reads the reply.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	if _, err := io.WriteString(c.Conn, b.String()); err != nil {
+		return nil, err
+	}
+	return readReply(c.rd)
+}
+
+// readReply parses a single RESP value. Integers are returned as int64, bulk and
+// simple strings as string, nil bulk strings as nil and arrays as []interface{}.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			v, err := readReply(rd)
+			var rerr redisError
+			if err != nil && !errors.As(err, &rerr) {
+				return nil, err
+			}
+			if err != nil {
+				v = rerr
+			}
+			values[i] = v
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
@@ -0,0 +1,196 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+// fakeRedis is a tiny Redis-protocol server understanding just what the
+// RedisStore sends. The GCRA script is emulated in Go, with a controllable clock:
+// the tests using it check the protocol and the replies, never the Lua itself.
+// Only TestRedisStore_RealServer runs the script, see `make test-redis`.
+type fakeRedis struct {
+	ln      net.Listener
+	mu      sync.Mutex
+	now     time.Time
+	tat     map[string]int64
+	scripts map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeRedis{
+		ln:      ln,
+		now:     time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC),
+		tat:     make(map[string]int64),
+		scripts: make(map[string]bool),
+	}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(c)
+		}
+	}()
+	t.Cleanup(func() { _ = ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	rd := bufio.NewReader(c)
+	for {
+		v, err := readReply(rd)
+		if err != nil {
+			return
+		}
+		var args []string
+		for _, a := range v.([]interface{}) {
+			args = append(args, a.(string))
+		}
+		_, _ = io.WriteString(c, f.exec(args))
+	}
+}
+
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "EVALSHA":
+		if !f.scripts[args[1]] {
+			return "-NOSCRIPT No matching script. Please use EVAL.\r\n"
+		}
+	case "EVAL":
+		if args[1] != gcraScript {
+			return "-ERR unknown script\r\n"
+		}
+		f.scripts[gcraScriptSHA] = true
+	default:
+		return "-ERR unknown command\r\n"
+	}
+
+	key := args[3]
+	interval, _ := strconv.ParseInt(args[4], 10, 64)
+	burst, _ := strconv.ParseInt(args[5], 10, 64)
+	now := f.now.UnixMicro()
+
+	tat, ok := f.tat[key]
+	if !ok || tat < now {
+		tat = now
+	}
+	newTat := tat + interval
+	allowAt := newTat - burst*interval
+	if now < allowAt {
+		return fmt.Sprintf("*4\r\n:0\r\n:0\r\n:%d\r\n:%d\r\n", tat-now, allowAt-now)
+	}
+	f.tat[key] = newTat
+	return fmt.Sprintf("*4\r\n:1\r\n:%d\r\n:%d\r\n:0\r\n", (now-allowAt)/interval, newTat-now)
+}
+
+func TestRedisStore_GCRA(t *testing.T) {
+	is := is2.New(t)
+
+	f := newFakeRedis(t)
+	s := NewRedisStore(f.ln.Addr().String(), "test:", 2)
+	defer s.Close()
+
+	p := Policy{RPS: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		res, err := s.Take(ctx, "client", p)
+		is.NoErr(err)
+		is.True(res.Allowed)
+		is.Equal(res.Limit, 3)
+		is.Equal(res.Remaining, i)
+	}
+
+	res, err := s.Take(ctx, "client", p)
+	is.NoErr(err)
+	is.True(!res.Allowed)
+	is.Equal(res.RetryAfter, 500*time.Millisecond)
+	is.Equal(res.Reset, 1500*time.Millisecond)
+
+	//other keys are not affected
+	res, err = s.Take(ctx, "other", p)
+	is.NoErr(err)
+	is.True(res.Allowed)
+
+	f.advance(500 * time.Millisecond)
+	res, err = s.Take(ctx, "client", p)
+	is.NoErr(err)
+	is.True(res.Allowed)
+}
+
+func TestRedisStore_ServerUnavailable(t *testing.T) {
+	is := is2.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	addr := ln.Addr().String()
+	_ = ln.Close()
+
+	s := NewRedisStore(addr, "test:", 1)
+	_, err = s.Take(context.Background(), "client", Policy{RPS: 1, Burst: 1})
+	is.True(err != nil)
+}
+
+// TestRedisStore_RealServer runs the script against a real redis-server when
+// RATE_LIMITER_REDIS_ADDR points to one. It is skipped otherwise, leaving the
+// Lua untested.
+func TestRedisStore_RealServer(t *testing.T) {
+	addr := os.Getenv("RATE_LIMITER_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("RATE_LIMITER_REDIS_ADDR not set")
+	}
+	is := is2.New(t)
+
+	prefix := fmt.Sprintf("test:%d:", time.Now().UnixNano())
+	s := NewRedisStore(addr, prefix, 1)
+	defer s.Close()
+
+	//the TAT is kept to the µs: each request moves it by exactly one interval
+	var tats []int64
+	p := Policy{RPS: 0.1, Burst: 2}
+	for i := 1; i >= 0; i-- {
+		res, err := s.Take(context.Background(), "client", p)
+		is.NoErr(err)
+		is.True(res.Allowed)
+		is.Equal(res.Remaining, i)
+
+		stored, err := s.do(context.Background(), "GET", prefix+"client")
+		is.NoErr(err)
+		tat, err := strconv.ParseInt(stored.(string), 10, 64)
+		is.NoErr(err) //stored as an integer, not as 1.7609000001235e+15
+		tats = append(tats, tat)
+	}
+	is.Equal(tats[1]-tats[0], int64(10*time.Second/time.Microsecond))
+
+	res, err := s.Take(context.Background(), "client", p)
+	is.NoErr(err)
+	is.True(!res.Allowed)
+	is.True(res.RetryAfter > 0)
+}
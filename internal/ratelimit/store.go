@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the state of the rate limiting buckets. Take consumes one
+// request from the bucket identified by key, creating it with the given policy
+// if it doesn't exist yet.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// MemoryStore keeps token buckets in process memory. It is the default store
+// and is only accurate when a single instance of the API is running.
+type MemoryStore struct {
+	mu      sync.Mutex
+	clients map[string]*memoryClient
+	now     func() time.Time
+}
+
+type memoryClient struct {
+	bucket *TokenBucket
+	//with the last time seen we can clean up the mapping.
+	lastSeen time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		clients: make(map[string]*memoryClient),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	c, found := s.clients[key]
+	if !found {
+		c = &memoryClient{bucket: NewTokenBucket(policy.RPS, policy.Burst, now)}
+		s.clients[key] = c
+	}
+	c.lastSeen = now
+	return c.bucket.Take(now), nil
+}
+
+// Cleanup removes, every interval, the buckets that haven't been used for longer
+// than idle. It blocks until ctx is done.
+func (s *MemoryStore) Cleanup(ctx context.Context, interval, idle time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.evict(idle)
+		}
+	}
+}
+
+func (s *MemoryStore) evict(idle time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for key, c := range s.clients {
+		if now.Sub(c.lastSeen) > idle {
+			delete(s.clients, key)
+		}
+	}
+}
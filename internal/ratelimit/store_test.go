@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestMemoryStore_BucketsArePerKey(t *testing.T) {
+	is := is2.New(t)
+
+	s := NewMemoryStore()
+	p := Policy{RPS: 1, Burst: 1}
+
+	res, err := s.Take(context.Background(), "a", p)
+	is.NoErr(err)
+	is.True(res.Allowed)
+	res, _ = s.Take(context.Background(), "a", p)
+	is.True(!res.Allowed)
+	res, _ = s.Take(context.Background(), "b", p)
+	is.True(res.Allowed)
+}
+
+func TestMemoryStore_CleanupEvictsIdleAndStops(t *testing.T) {
+	is := is2.New(t)
+
+	s := NewMemoryStore()
+	now := time.Date(2023, 4, 18, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	_, _ = s.Take(context.Background(), "a", Policy{RPS: 1, Burst: 1})
+
+	now = now.Add(time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Cleanup(ctx, time.Millisecond, time.Minute)
+		close(done)
+	}()
+
+	deadline := time.After(time.Second)
+	for {
+		s.mu.Lock()
+		n := len(s.clients)
+		s.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatal("idle client was not evicted")
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("cleanup did not stop")
+	}
+	is.True(true)
+}
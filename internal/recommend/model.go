@@ -0,0 +1,222 @@
+package recommend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+	"yamda_go/internal/jsonlog"
+)
+
+// modelVersion changes whenever the file format does.
+const modelVersion = 1
+
+var ErrModelVersion = errors.New("unsupported model version")
+
+// Neighbor is a movie alike another one for the users who rated both.
+type Neighbor struct {
+	ID         int64   `json:"id"`
+	Similarity float64 `json:"similarity"`
+}
+
+// Model is the trained item-item model, persisted as JSON.
+type Model struct {
+	Version   int                  `json:"version"`
+	TrainedAt time.Time            `json:"trainedAt"`
+	Ratings   int                  `json:"ratings"`
+	Users     int                  `json:"users"`
+	Neighbors map[int64][]Neighbor `json:"neighbors"`
+}
+
+// Hit is a movie recommended to a user, with the score they would likely give it.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// Recommend predicts the score the user would give to the movies alike the ones
+// they rated, from how they rated those, and returns up to limit of the best.
+// Movies the user rated are never recommended.
+func (m *Model) Recommend(rated map[int64]int, limit int) []Hit {
+	if len(rated) == 0 {
+		return nil
+	}
+	mean := 0.0
+	for _, score := range rated {
+		mean += float64(score)
+	}
+	mean /= float64(len(rated))
+
+	type prediction struct{ num, den float64 }
+	predictions := make(map[int64]*prediction)
+	for id, score := range rated {
+		for _, n := range m.Neighbors[id] {
+			if _, seen := rated[n.ID]; seen {
+				continue
+			}
+			p := predictions[n.ID]
+			if p == nil {
+				p = &prediction{}
+				predictions[n.ID] = p
+			}
+			p.num += n.Similarity * (float64(score) - mean)
+			p.den += n.Similarity
+		}
+	}
+
+	hits := make([]Hit, 0, len(predictions))
+	support := make(map[int64]float64, len(predictions))
+	for id, p := range predictions {
+		hits = append(hits, Hit{ID: id, Score: mean + p.num/p.den})
+		support[id] = p.den
+	}
+	//more evidence breaks ties, e.g. for users giving the same score to everything
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if support[hits[i].ID] != support[hits[j].ID] {
+			return support[hits[i].ID] > support[hits[j].ID]
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// Save writes the model to path, through a temporary file so that readers
+// never see half of it.
+func (m *Model) Save(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err = json.NewEncoder(tmp).Encode(m); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads a model saved by Save.
+func Load(path string) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var m Model
+	if err = json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, err
+	}
+	if m.Version != modelVersion {
+		return nil, fmt.Errorf("%w: %d", ErrModelVersion, m.Version)
+	}
+	return &m, nil
+}
+
+// Recommender serves the model of a file, swapping it for the new one whenever
+// the file is trained again. It is safe for concurrent use.
+type Recommender struct {
+	path string
+
+	mu      sync.RWMutex
+	model   *Model
+	modTime time.Time
+}
+
+// NewRecommender serves the model saved at path. There is none until Reload.
+func NewRecommender(path string) *Recommender {
+	return &Recommender{path: path}
+}
+
+// NewRecommenderWith serves a model that is already loaded, e.g. for tests.
+func NewRecommenderWith(m *Model) *Recommender {
+	return &Recommender{model: m}
+}
+
+// Model returns the current model, nil when none has been loaded.
+func (r *Recommender) Model() *Model {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.model
+}
+
+// Recommend is Model.Recommend, with no recommendations without a model.
+func (r *Recommender) Recommend(rated map[int64]int, limit int) []Hit {
+	m := r.Model()
+	if m == nil {
+		return nil
+	}
+	return m.Recommend(rated, limit)
+}
+
+// Reload loads the model file if it changed since the last time, telling
+// whether it did. A missing file isn't an error, there is just no model yet.
+func (r *Recommender) Reload() (bool, error) {
+	if r.path == "" {
+		return false, nil
+	}
+	info, err := os.Stat(r.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	m, err := Load(r.path)
+	if err != nil {
+		return false, err
+	}
+	r.mu.Lock()
+	r.model, r.modTime = m, info.ModTime()
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Watch reloads the model every interval. It blocks until ctx is done.
+func (r *Recommender) Watch(ctx context.Context, interval time.Duration, logger *jsonlog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			swapped, err := r.Reload()
+			if err != nil {
+				//keep serving the previous model
+				logger.PrintError(err, map[string]string{"model": r.path})
+				continue
+			}
+			if swapped {
+				m := r.Model()
+				logger.PrintInfo("loaded recommendation model", map[string]string{
+					"model":     r.path,
+					"trainedAt": m.TrainedAt.Format(time.RFC3339),
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package recommend
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	is2 "github.com/matryer/is"
+)
+
+// ratings of 4 users: 1 and 2 are sci-fi fans (movies 10, 11, 12), 3 and 4
+// like dramas (movies 20, 21).
+var testRatings = []Rating{
+	{1, 10, 9}, {1, 11, 9}, {1, 12, 9}, {1, 20, 2},
+	{2, 10, 8}, {2, 11, 9}, {2, 12, 8}, {2, 21, 3},
+	{3, 10, 2}, {3, 20, 9}, {3, 21, 8},
+	{4, 11, 3}, {4, 20, 8}, {4, 21, 9}, {4, 12, 2},
+}
+
+func TestTrainAndRecommend(t *testing.T) {
+	is := is2.New(t)
+
+	m := Train(testRatings, TrainOptions{Neighbors: 10, MinSupport: 2, Shrinkage: 0, MaxRated: 100})
+	is.Equal(m.Users, 4)
+	is.Equal(m.Ratings, len(testRatings))
+	is.True(len(m.Neighbors[10]) > 0)
+	is.Equal(m.Neighbors[10][0].ID, int64(11)) //the sci-fi movies are alike
+	for _, n := range m.Neighbors[20] {
+		is.True(n.ID != 10 && n.ID != 11 && n.ID != 12) //dramas are no sci-fi
+	}
+
+	//a sci-fi fan who only saw 10 and a drama gets the other sci-fi movies
+	hits := m.Recommend(map[int64]int{10: 10, 20: 3}, 2)
+	is.Equal(len(hits), 2)
+	for _, h := range hits {
+		is.True(h.ID == 11 || h.ID == 12)
+		is.True(h.Score > 6)
+	}
+
+	//rated movies are never recommended
+	for _, h := range m.Recommend(map[int64]int{10: 10, 11: 9, 12: 8}, 10) {
+		is.True(h.ID != 10 && h.ID != 11 && h.ID != 12)
+	}
+	is.Equal(len(m.Recommend(nil, 10)), 0)
+}
+
+func TestTrainer_KeepsTheFirstRatingsOfEachUser(t *testing.T) {
+	is := is2.New(t)
+
+	tr := NewTrainer(TrainOptions{Neighbors: 10, MinSupport: 1, Shrinkage: 0, MaxRated: 3})
+	//latest first: the oldest rating of user 1, movie 2, is left out
+	for _, r := range []Rating{{1, 4, 9}, {1, 3, 9}, {1, 1, 1}, {1, 2, 9}, {2, 2, 5}} {
+		tr.Add(r)
+	}
+	m := tr.Model()
+	is.Equal(m.Users, 2)
+	is.Equal(m.Ratings, 5)
+	is.Equal(len(m.Neighbors[2]), 0)
+	is.Equal(m.Neighbors[3], []Neighbor{{ID: 4, Similarity: 1}})
+}
+
+func TestModel_SaveLoad(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	m := Train(testRatings, DefaultTrainOptions)
+	is.NoErr(m.Save(path))
+
+	loaded, err := Load(path)
+	is.NoErr(err)
+	is.Equal(loaded.TrainedAt, m.TrainedAt)
+	is.Equal(loaded.Neighbors, m.Neighbors)
+
+	is.NoErr(os.WriteFile(path, []byte(`{"version":99}`), 0o644))
+	_, err = Load(path)
+	is.True(err != nil)
+}
+
+func TestRecommender_Reload(t *testing.T) {
+	is := is2.New(t)
+
+	path := filepath.Join(t.TempDir(), "model.json")
+	r := NewRecommender(path)
+
+	//no model yet
+	swapped, err := r.Reload()
+	is.NoErr(err)
+	is.True(!swapped)
+	is.Equal(len(r.Recommend(map[int64]int{10: 10}, 5)), 0)
+
+	is.NoErr(Train(testRatings, TrainOptions{Neighbors: 10, MinSupport: 2, MaxRated: 100}).Save(path))
+	swapped, err = r.Reload()
+	is.NoErr(err)
+	is.True(swapped)
+	is.True(len(r.Recommend(map[int64]int{10: 10}, 5)) > 0)
+
+	//unchanged file
+	swapped, err = r.Reload()
+	is.NoErr(err)
+	is.True(!swapped)
+
+	//a model trained again is swapped in
+	empty := &Model{Version: modelVersion, Neighbors: map[int64][]Neighbor{}}
+	is.NoErr(empty.Save(path))
+	later := time.Now().Add(time.Minute)
+	is.NoErr(os.Chtimes(path, later, later))
+	swapped, err = r.Reload()
+	is.NoErr(err)
+	is.True(swapped)
+	is.Equal(len(r.Recommend(map[int64]int{10: 10}, 5)), 0)
+
+	//a broken file keeps the previous model
+	is.NoErr(os.WriteFile(path, []byte("{"), 0o644))
+	later = later.Add(time.Minute)
+	is.NoErr(os.Chtimes(path, later, later))
+	_, err = r.Reload()
+	is.True(err != nil)
+	is.Equal(r.Model(), empty)
+}
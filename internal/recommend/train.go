@@ -0,0 +1,169 @@
+package recommend
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Rating is the score a user gave to a movie, as the model learns from them.
+type Rating struct {
+	UserID  int64
+	MovieID int64
+	Score   float64
+}
+
+// TrainOptions tune the model.
+type TrainOptions struct {
+	Neighbors  int     //most similar movies kept for each movie
+	MinSupport int     //users who rated both movies, below which they aren't compared
+	Shrinkage  float64 //pulls similarities backed by few users towards 0
+	MaxRated   int     //ratings per user looked at, the first given, their pairs grow quadratically
+}
+
+// DefaultTrainOptions suit a catalogue of a few thousand movies.
+var DefaultTrainOptions = TrainOptions{Neighbors: 50, MinSupport: 2, Shrinkage: 10, MaxRated: 500}
+
+// pair is two movies, the lowest id first.
+type pair struct{ a, b int64 }
+
+type pairStats struct {
+	dot     float64
+	support int
+}
+
+// Trainer computes the item-item model of the ratings: the adjusted cosine
+// similarity of every two movies rated by the same users, their ratings
+// centered on the mean of each user so that harsh and generous users compare.
+//
+// Ratings are fed one at a time, grouped by user: only the ratings of the
+// current user and the statistics of the pairs of movies are kept in memory.
+type Trainer struct {
+	opts    TrainOptions
+	pairs   map[pair]*pairStats
+	norms   map[int64]float64
+	user    int64
+	rated   []Rating //by the current user, at most MaxRated
+	ratings int
+	users   int
+}
+
+func NewTrainer(opts TrainOptions) *Trainer {
+	return &Trainer{
+		opts:  opts,
+		pairs: make(map[pair]*pairStats),
+		norms: make(map[int64]float64),
+	}
+}
+
+// Add feeds a rating to the model. The ratings of a user must come one after
+// the other; beyond MaxRated of them the rest are ignored, so give the ones
+// that matter most (e.g. the latest) first.
+func (t *Trainer) Add(r Rating) {
+	t.ratings++
+	if len(t.rated) > 0 && r.UserID != t.user {
+		t.flush()
+	}
+	t.user = r.UserID
+	if len(t.rated) < t.opts.MaxRated {
+		t.rated = append(t.rated, r)
+	}
+}
+
+// flush adds the pairs of movies rated by the current user.
+func (t *Trainer) flush() {
+	rated := t.rated
+	if len(rated) == 0 {
+		return
+	}
+	t.users++
+	t.rated = t.rated[:0]
+
+	mean := 0.0
+	for _, r := range rated {
+		mean += r.Score
+	}
+	mean /= float64(len(rated))
+
+	centered := make([]float64, len(rated))
+	for i, r := range rated {
+		centered[i] = r.Score - mean
+		t.norms[r.MovieID] += centered[i] * centered[i]
+	}
+	for i := range rated {
+		for j := i + 1; j < len(rated); j++ {
+			key := pair{rated[i].MovieID, rated[j].MovieID}
+			if key.a > key.b {
+				key.a, key.b = key.b, key.a
+			}
+			s := t.pairs[key]
+			if s == nil {
+				s = &pairStats{}
+				t.pairs[key] = s
+			}
+			s.dot += centered[i] * centered[j]
+			s.support++
+		}
+	}
+}
+
+// Train computes the model of ratings in any order, see Trainer. The ratings
+// of each user are looked at in the order given.
+func Train(ratings []Rating, opts TrainOptions) *Model {
+	byUser := make(map[int64][]Rating)
+	var users []int64
+	for _, r := range ratings {
+		if _, ok := byUser[r.UserID]; !ok {
+			users = append(users, r.UserID)
+		}
+		byUser[r.UserID] = append(byUser[r.UserID], r)
+	}
+
+	t := NewTrainer(opts)
+	for _, u := range users {
+		for _, r := range byUser[u] {
+			t.Add(r)
+		}
+	}
+	return t.Model()
+}
+
+// Model returns the model of the ratings fed so far.
+func (t *Trainer) Model() *Model {
+	t.flush()
+	opts, pairs, norms := t.opts, t.pairs, t.norms
+
+	neighbors := make(map[int64][]Neighbor)
+	for key, s := range pairs {
+		if s.support < opts.MinSupport || norms[key.a] == 0 || norms[key.b] == 0 {
+			continue
+		}
+		sim := s.dot / (math.Sqrt(norms[key.a]) * math.Sqrt(norms[key.b]))
+		sim *= float64(s.support) / (float64(s.support) + opts.Shrinkage)
+		//movies liked by different users tell nothing about each other
+		if sim <= 0 {
+			continue
+		}
+		neighbors[key.a] = append(neighbors[key.a], Neighbor{ID: key.b, Similarity: sim})
+		neighbors[key.b] = append(neighbors[key.b], Neighbor{ID: key.a, Similarity: sim})
+	}
+	for id, n := range neighbors {
+		sort.Slice(n, func(i, j int) bool {
+			if n[i].Similarity != n[j].Similarity {
+				return n[i].Similarity > n[j].Similarity
+			}
+			return n[i].ID < n[j].ID
+		})
+		if len(n) > opts.Neighbors {
+			neighbors[id] = n[:opts.Neighbors]
+		}
+	}
+
+	return &Model{
+		Version:   modelVersion,
+		TrainedAt: time.Now().UTC().Truncate(time.Second),
+		Ratings:   t.ratings,
+		Users:     t.users,
+		Neighbors: neighbors,
+	}
+}
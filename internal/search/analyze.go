@@ -0,0 +1,50 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// stopWords are too common to tell documents apart, they are not indexed.
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"with": true,
+}
+
+// Fold lower cases s and strips its diacritics, e.g. "Amélie" -> "amelie".
+func Fold(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue //combining accent left over by the decomposition
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// Words folds s and splits it into words. Apostrophes are dropped so that
+// "Schindler's" gives a single word.
+func Words(s string) []string {
+	s = strings.NewReplacer("'", "", "’", "").Replace(Fold(s))
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Analyze turns a text into the terms that are indexed and searched for:
+// folded words, without stop words, reduced to their stem.
+func Analyze(s string) []string {
+	var terms []string
+	for _, w := range Words(s) {
+		if stopWords[w] {
+			continue
+		}
+		terms = append(terms, Stem(w))
+	}
+	return terms
+}
@@ -0,0 +1,237 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Suggestion is a title completing a prefix.
+type Suggestion struct {
+	ID    int64
+	Title string
+}
+
+// trieNode is a node of the completion trie. Entries are the titles whose key
+// ends here, true when the key is the start of the title rather than one of
+// its other words.
+type trieNode struct {
+	children map[rune]*trieNode
+	entries  map[int64]bool
+}
+
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode)}
+}
+
+// Completer suggests titles starting with a prefix, tolerating a few typos.
+// Each title is reachable from the start of any of its words, so "godf"
+// completes "The Godfather". It is safe for concurrent use.
+type Completer struct {
+	mu         sync.RWMutex
+	root       *trieNode
+	titles     map[int64]string
+	keys       map[int64][]string //keys of each title, to remove it later
+	popularity map[int64]float64
+}
+
+// NewCompleter creates an empty completer.
+func NewCompleter() *Completer {
+	return &Completer{
+		root:       newTrieNode(),
+		titles:     make(map[int64]string),
+		keys:       make(map[int64][]string),
+		popularity: make(map[int64]float64),
+	}
+}
+
+// completionKeys returns the folded title starting at each of its words.
+func completionKeys(title string) []string {
+	words := Words(title)
+	keys := make([]string, 0, len(words))
+	for i := range words {
+		keys = append(keys, strings.Join(words[i:], " "))
+	}
+	return keys
+}
+
+// Put adds the title, replacing the previous one of the same id.
+func (c *Completer) Put(id int64, title string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.remove(id)
+	keys := completionKeys(title)
+	for i, k := range keys {
+		n := c.root
+		for _, r := range k {
+			child, ok := n.children[r]
+			if !ok {
+				child = newTrieNode()
+				n.children[r] = child
+			}
+			n = child
+		}
+		if n.entries == nil {
+			n.entries = make(map[int64]bool)
+		}
+		n.entries[id] = n.entries[id] || i == 0
+	}
+	c.titles[id] = title
+	c.keys[id] = keys
+}
+
+// Remove forgets the title and its popularity.
+func (c *Completer) Remove(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remove(id)
+	delete(c.popularity, id)
+}
+
+func (c *Completer) remove(id int64) {
+	for _, k := range c.keys[id] {
+		removeKey(c.root, []rune(k), id)
+	}
+	delete(c.titles, id)
+	delete(c.keys, id)
+}
+
+// removeKey drops the entry at the end of key and prunes the nodes left empty.
+// It tells if n itself is empty afterwards.
+func removeKey(n *trieNode, key []rune, id int64) bool {
+	if len(key) == 0 {
+		delete(n.entries, id)
+	} else if child, ok := n.children[key[0]]; ok && removeKey(child, key[1:], id) {
+		delete(n.children, key[0])
+	}
+	return len(n.entries) == 0 && len(n.children) == 0
+}
+
+// Touch records that a title was looked at, making it more popular.
+func (c *Completer) Touch(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.titles[id]; ok {
+		c.popularity[id]++
+	}
+}
+
+// candidate is a title found for a prefix and how well it matches it.
+type candidate struct {
+	id    int64
+	edits int
+	start bool //the prefix matches the start of the title
+}
+
+// Complete returns at most limit titles completing the prefix. Exact matches
+// come before those with typos, then titles starting with the prefix, then the
+// most popular ones.
+func (c *Completer) Complete(prefix string, limit int) []Suggestion {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	query := []rune(strings.Join(Words(prefix), " "))
+	if len(query) == 0 {
+		return []Suggestion{}
+	}
+	max := maxEdits(len(query))
+
+	found := make(map[int64]candidate)
+	row := make([]int, len(query)+1)
+	for i := range row {
+		row[i] = i
+	}
+	c.walk(c.root, query, row, max+1, max, found)
+
+	candidates := make([]candidate, 0, len(found))
+	for _, cand := range found {
+		candidates = append(candidates, cand)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.edits != b.edits:
+			return a.edits < b.edits
+		case a.start != b.start:
+			return a.start
+		}
+		if pa, pb := c.popularity[a.id], c.popularity[b.id]; pa != pb {
+			return pa > pb
+		}
+		ta, tb := c.titles[a.id], c.titles[b.id]
+		if len(ta) != len(tb) {
+			return len(ta) < len(tb)
+		}
+		return a.id < b.id
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	suggestions := make([]Suggestion, 0, len(candidates))
+	for _, cand := range candidates {
+		suggestions = append(suggestions, Suggestion{ID: cand.id, Title: c.titles[cand.id]})
+	}
+	return suggestions
+}
+
+// walk goes down the trie computing the Levenshtein distance between the query
+// and the path so far, one row per node. A title completes the query with the
+// fewest edits found along its path (best), as long as it is within max.
+func (c *Completer) walk(n *trieNode, query []rune, row []int, best, max int, found map[int64]candidate) {
+	if d := row[len(query)]; d < best {
+		best = d
+	}
+	if best == 0 || minInt(row...) > max {
+		//nothing better below: either exact already or every longer path is further
+		if best <= max {
+			collect(n, best, found)
+		}
+		return
+	}
+	if best <= max {
+		for id, start := range n.entries {
+			found[id] = better(found[id], candidate{id: id, edits: best, start: start})
+		}
+	}
+	for r, child := range n.children {
+		next := make([]int, len(row))
+		next[0] = row[0] + 1
+		for i := 1; i < len(row); i++ {
+			cost := 1
+			if query[i-1] == r {
+				cost = 0
+			}
+			next[i] = minInt(row[i]+1, next[i-1]+1, row[i-1]+cost)
+		}
+		c.walk(child, query, next, best, max, found)
+	}
+}
+
+// collect records every title below n, keeping the best match of each.
+func collect(n *trieNode, edits int, found map[int64]candidate) {
+	for id, start := range n.entries {
+		found[id] = better(found[id], candidate{id: id, edits: edits, start: start})
+	}
+	for _, child := range n.children {
+		collect(child, edits, found)
+	}
+}
+
+// better returns the best of two matches of the same title, prev being the
+// zero value when there was none yet.
+func better(prev, cand candidate) candidate {
+	switch {
+	case prev.id == 0:
+		return cand
+	case cand.edits != prev.edits:
+		if cand.edits < prev.edits {
+			return cand
+		}
+		return prev
+	case cand.start:
+		return cand
+	}
+	return prev
+}
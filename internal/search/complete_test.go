@@ -0,0 +1,66 @@
+package search
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func newCompleter() *Completer {
+	c := NewCompleter()
+	c.Put(1, "The Godfather")
+	c.Put(2, "The Godfather Part II")
+	c.Put(3, "Good Will Hunting")
+	c.Put(4, "Godzilla")
+	return c
+}
+
+func titles(suggestions []Suggestion) []string {
+	var out []string
+	for _, s := range suggestions {
+		out = append(out, s.Title)
+	}
+	return out
+}
+
+func TestCompleter_Complete_MatchesAnyWordAndRanksTitleStartsFirst(t *testing.T) {
+	is := is2.New(t)
+
+	c := newCompleter()
+	is.Equal(titles(c.Complete("god", 10)), []string{"Godzilla", "The Godfather", "The Godfather Part II"})
+	is.Equal(titles(c.Complete("the godf", 1)), []string{"The Godfather"})
+}
+
+func TestCompleter_Complete_ToleratesTypos(t *testing.T) {
+	is := is2.New(t)
+
+	c := newCompleter()
+	is.Equal(titles(c.Complete("godfahter", 10)), []string{"The Godfather", "The Godfather Part II"})
+	is.Equal(titles(c.Complete("Gödzila", 10)), []string{"Godzilla"})
+	//exact matches first
+	is.Equal(titles(c.Complete("godz", 10))[0], "Godzilla")
+}
+
+func TestCompleter_Complete_PopularTitlesFirst(t *testing.T) {
+	is := is2.New(t)
+
+	c := newCompleter()
+	c.Touch(2)
+	is.Equal(titles(c.Complete("the god", 10)), []string{"The Godfather Part II", "The Godfather"})
+}
+
+func TestCompleter_PutAndRemoveKeepTheTrieInSync(t *testing.T) {
+	is := is2.New(t)
+
+	c := newCompleter()
+	c.Put(4, "Mothra")
+	for _, s := range c.Complete("godzilla", 10) {
+		is.True(s.ID != 4) //the old title is gone
+	}
+	is.Equal(titles(c.Complete("moth", 10)), []string{"Mothra"})
+
+	c.Remove(4)
+	is.Equal(len(c.Complete("moth", 10)), 0)
+	_, ok := c.root.children['m']
+	is.True(!ok) //emptied branches are pruned
+}
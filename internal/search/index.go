@@ -0,0 +1,194 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 parameters: k1 limits how much repeating a term helps, b how much long
+// fields are penalised.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Hit is a document matching a query.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// field is the inverted index of one field of the documents.
+type field struct {
+	weight   float64
+	postings map[string]map[int64]int //term -> document -> term frequency
+	lengths  map[int64]int            //document -> number of terms
+	total    int                      //sum of lengths, for the average
+}
+
+// Index is an in-memory inverted index of documents made of named text fields,
+// ranked with BM25 (summed over fields, each with its own weight). Query terms
+// that aren't in the index match the indexed terms within a few typos.
+// It is safe for concurrent use.
+type Index struct {
+	mu     sync.RWMutex
+	fields map[string]*field
+	docs   map[int64]map[string][]string //document -> field -> terms, to remove it later
+	vocab  map[string]int                //term -> number of postings, for typo tolerance
+}
+
+// NewIndex creates an index of the given fields and their weights.
+// e.g. NewIndex(map[string]float64{"title": 2, "genres": 1})
+func NewIndex(weights map[string]float64) *Index {
+	idx := &Index{
+		fields: make(map[string]*field),
+		docs:   make(map[int64]map[string][]string),
+		vocab:  make(map[string]int),
+	}
+	for name, w := range weights {
+		idx.fields[name] = &field{
+			weight:   w,
+			postings: make(map[string]map[int64]int),
+			lengths:  make(map[int64]int),
+		}
+	}
+	return idx
+}
+
+// Put indexes the document, replacing any previous version of it. Fields that
+// the index doesn't know about are ignored.
+func (idx *Index) Put(id int64, fields map[string]string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	doc := make(map[string][]string)
+	for name, text := range fields {
+		f, ok := idx.fields[name]
+		if !ok {
+			continue
+		}
+		terms := Analyze(text)
+		doc[name] = terms
+		f.lengths[id] = len(terms)
+		f.total += len(terms)
+		for _, t := range terms {
+			if f.postings[t] == nil {
+				f.postings[t] = make(map[int64]int)
+			}
+			if f.postings[t][id] == 0 {
+				idx.vocab[t]++
+			}
+			f.postings[t][id]++
+		}
+	}
+	idx.docs[id] = doc
+}
+
+// Remove drops the document from the index.
+func (idx *Index) Remove(id int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id int64) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	for name, terms := range doc {
+		f := idx.fields[name]
+		f.total -= f.lengths[id]
+		delete(f.lengths, id)
+		for _, t := range terms {
+			if _, ok := f.postings[t][id]; !ok {
+				continue //repeated term, already removed
+			}
+			delete(f.postings[t], id)
+			if len(f.postings[t]) == 0 {
+				delete(f.postings, t)
+			}
+			if idx.vocab[t]--; idx.vocab[t] == 0 {
+				delete(idx.vocab, t)
+			}
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// Len returns the number of indexed documents.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.docs)
+}
+
+// Search returns at most limit documents matching any term of the query, the
+// best ones first. Ties are broken by id.
+func (idx *Index) Search(query string, limit int) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	scores := make(map[int64]float64)
+	for _, w := range Words(query) {
+		if stopWords[w] {
+			continue
+		}
+		for t, weight := range idx.expand(Stem(w), maxEdits(len([]rune(w)))) {
+			for _, f := range idx.fields {
+				f.score(t, weight*f.weight, len(idx.docs), scores)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{ID: id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// expand returns the indexed terms a query term stands for, with the weight of
+// each: the term itself when indexed, otherwise the terms within max typos,
+// weighed down by their distance.
+func (idx *Index) expand(term string, max int) map[string]float64 {
+	if idx.vocab[term] > 0 {
+		return map[string]float64{term: 1}
+	}
+	if max == 0 {
+		return nil
+	}
+	terms := make(map[string]float64)
+	for t := range idx.vocab {
+		if d := distance(term, t, max); d <= max {
+			terms[t] = 1 / float64(1+d)
+		}
+	}
+	return terms
+}
+
+// score adds the BM25 score of the term in this field to every document containing it.
+func (f *field) score(term string, weight float64, n int, scores map[int64]float64) {
+	postings := f.postings[term]
+	if len(postings) == 0 {
+		return
+	}
+	df := float64(len(postings))
+	idf := math.Log(1 + (float64(n)-df+0.5)/(df+0.5))
+	avg := float64(f.total) / float64(len(f.lengths))
+	for id, tf := range postings {
+		norm := k1 * (1 - b + b*float64(f.lengths[id])/avg)
+		scores[id] += weight * idf * float64(tf) * (k1 + 1) / (float64(tf) + norm)
+	}
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestAnalyze_FoldsStemsAndDropsStopWords(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(Analyze("The Fabulous Destiny of Amélie Poulain"), []string{"fabul", "destini", "ameli", "poulain"})
+	is.Equal(Analyze("Schindler's List"), []string{"schindler", "list"})
+}
+
+func TestStem(t *testing.T) {
+	is := is2.New(t)
+
+	for word, stem := range map[string]string{
+		"caresses":    "caress",
+		"ponies":      "poni",
+		"hopping":     "hop",
+		"filing":      "file",
+		"relational":  "relat",
+		"hopeful":     "hope",
+		"controlling": "control",
+		"fights":      "fight",
+		"fighting":    "fight",
+	} {
+		is.Equal(Stem(word), stem)
+	}
+}
+
+func TestDistance_IsBounded(t *testing.T) {
+	is := is2.New(t)
+
+	is.Equal(distance("godfather", "godfahter", 2), 2)
+	is.Equal(distance("kitten", "sitting", 3), 3)
+	is.Equal(distance("kitten", "sitting", 1), 2)
+}
+
+func newMovieIndex() *Index {
+	idx := NewIndex(map[string]float64{"title": 2, "genres": 1})
+	idx.Put(1, map[string]string{"title": "The Godfather", "genres": "Crime Drama"})
+	idx.Put(2, map[string]string{"title": "The Godfather Part II", "genres": "Crime Drama"})
+	idx.Put(3, map[string]string{"title": "Fight Club", "genres": "Drama"})
+	idx.Put(4, map[string]string{"title": "Crime Story", "genres": "Thriller"})
+	return idx
+}
+
+func TestIndex_Search_RanksWithBM25(t *testing.T) {
+	is := is2.New(t)
+
+	hits := newMovieIndex().Search("crime", 0)
+	is.Equal(len(hits), 3)
+	//a match in the short title outweighs matches in the genres
+	is.Equal(hits[0].ID, int64(4))
+
+	hits = newMovieIndex().Search("fighting", 0)
+	is.Equal(len(hits), 1)
+	is.Equal(hits[0].ID, int64(3))
+}
+
+func TestIndex_Search_ToleratesTypos(t *testing.T) {
+	is := is2.New(t)
+
+	hits := newMovieIndex().Search("godfahter", 1)
+	is.Equal(len(hits), 1)
+	is.Equal(hits[0].ID, int64(1)) //the shortest title wins
+}
+
+func TestIndex_PutReplacesAndRemoveForgets(t *testing.T) {
+	is := is2.New(t)
+
+	idx := newMovieIndex()
+	idx.Put(3, map[string]string{"title": "Fight Club (Director's Cut)", "genres": "Drama"})
+	is.Equal(idx.Len(), 4)
+	is.Equal(len(idx.Search("director", 0)), 1)
+
+	idx.Remove(3)
+	is.Equal(idx.Len(), 3)
+	is.Equal(len(idx.Search("fight", 0)), 0)
+	is.Equal(len(idx.vocab), len(newMovieIndex().vocab)-2) //"fight" and "club" are gone
+}
@@ -0,0 +1,225 @@
+package search
+
+// Stem reduces an English word to its stem with the Porter algorithm, so that
+// "fighting", "fights" and "fighter" are indexed alike. The word must already
+// be lower case; words with anything else than ASCII letters are returned as is.
+func Stem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	s := &stemmer{b: []byte(word)}
+	s.step1a()
+	s.step1b()
+	s.step1c()
+	s.step2()
+	s.step3()
+	s.step4()
+	s.step5()
+	return string(s.b)
+}
+
+// stemmer holds the word being stemmed. j marks the end of the stem once a
+// suffix was matched by ends.
+type stemmer struct {
+	b []byte
+	j int
+}
+
+// cons tells if b[i] is a consonant. "y" is one at the start of the word or
+// after a vowel.
+func (s *stemmer) cons(i int) bool {
+	switch s.b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !s.cons(i-1)
+	}
+	return true
+}
+
+// measure counts the vowel-consonant sequences in b[:j].
+func (s *stemmer) measure() int {
+	n, i := 0, 0
+	for ; i < s.j && s.cons(i); i++ {
+	}
+	for i < s.j {
+		for ; i < s.j && !s.cons(i); i++ {
+		}
+		if i >= s.j {
+			break
+		}
+		n++
+		for ; i < s.j && s.cons(i); i++ {
+		}
+	}
+	return n
+}
+
+// hasVowel tells if b[:j] contains a vowel.
+func (s *stemmer) hasVowel() bool {
+	for i := 0; i < s.j; i++ {
+		if !s.cons(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// doubleCons tells if b[i-1:i+1] is a double consonant.
+func (s *stemmer) doubleCons(i int) bool {
+	return i >= 1 && s.b[i] == s.b[i-1] && s.cons(i)
+}
+
+// cvc tells if b[i-2:i+1] is consonant-vowel-consonant, the last one not being
+// w, x or y. e.g. "hop" but not "snow".
+func (s *stemmer) cvc(i int) bool {
+	if i < 2 || !s.cons(i) || s.cons(i-1) || !s.cons(i-2) {
+		return false
+	}
+	switch s.b[i] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+// ends tells if the word ends with suffix and sets j to where it starts.
+func (s *stemmer) ends(suffix string) bool {
+	n := len(suffix)
+	if n > len(s.b) || string(s.b[len(s.b)-n:]) != suffix {
+		return false
+	}
+	s.j = len(s.b) - n
+	return true
+}
+
+// setTo replaces the matched suffix.
+func (s *stemmer) setTo(replacement string) {
+	s.b = append(s.b[:s.j], replacement...)
+}
+
+// replace applies the first matching rule when the stem measures more than min.
+func (s *stemmer) replace(rules [][2]string, min int) {
+	for _, r := range rules {
+		if s.ends(r[0]) {
+			if s.measure() > min {
+				s.setTo(r[1])
+			}
+			return
+		}
+	}
+}
+
+// step1a handles plurals: caresses -> caress, ponies -> poni, cats -> cat.
+func (s *stemmer) step1a() {
+	switch {
+	case s.ends("sses"), s.ends("ies"):
+		s.b = s.b[:len(s.b)-2]
+	case s.ends("ss"):
+	case s.ends("s"):
+		s.b = s.b[:len(s.b)-1]
+	}
+}
+
+// step1b handles -ed and -ing: agreed -> agree, hopping -> hop, filing -> file.
+func (s *stemmer) step1b() {
+	if s.ends("eed") {
+		if s.measure() > 0 {
+			s.b = s.b[:len(s.b)-1]
+		}
+		return
+	}
+	if !(s.ends("ed") || s.ends("ing")) || !s.hasVowel() {
+		return
+	}
+	s.b = s.b[:s.j]
+
+	last := len(s.b) - 1
+	switch {
+	case s.ends("at"), s.ends("bl"), s.ends("iz"):
+		s.b = append(s.b, 'e')
+	case s.doubleCons(last):
+		switch s.b[last] {
+		case 'l', 's', 'z':
+		default:
+			s.b = s.b[:last]
+		}
+	default:
+		s.j = len(s.b)
+		if s.measure() == 1 && s.cvc(last) {
+			s.b = append(s.b, 'e')
+		}
+	}
+}
+
+// step1c turns a terminal y into i when there is another vowel: happy -> happi.
+func (s *stemmer) step1c() {
+	if s.ends("y") && s.hasVowel() {
+		s.b[len(s.b)-1] = 'i'
+	}
+}
+
+var step2Rules = [][2]string{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"}, {"eli", "e"},
+	{"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"}, {"ator", "ate"},
+	{"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"}, {"ousness", "ous"},
+	{"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"}, {"logi", "log"},
+}
+
+// step2 maps double suffixes to single ones: relational -> relate.
+func (s *stemmer) step2() {
+	s.replace(step2Rules, 0)
+}
+
+var step3Rules = [][2]string{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+// step3 handles -ic-, -full, -ness etc: hopeful -> hope.
+func (s *stemmer) step3() {
+	s.replace(step3Rules, 0)
+}
+
+var step4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+// step4 removes the remaining suffixes of long stems: revival -> reviv.
+func (s *stemmer) step4() {
+	for _, suffix := range step4Suffixes {
+		if !s.ends(suffix) {
+			continue
+		}
+		if suffix == "ion" && (s.j == 0 || (s.b[s.j-1] != 's' && s.b[s.j-1] != 't')) {
+			return
+		}
+		//"ement" and "ment" are checked before "ent" so the longest suffix wins
+		if s.measure() > 1 {
+			s.b = s.b[:s.j]
+		}
+		return
+	}
+}
+
+// step5 removes a final -e and reduces -ll: probate -> probat, controll -> control.
+func (s *stemmer) step5() {
+	if s.ends("e") {
+		m := s.measure()
+		if m > 1 || (m == 1 && !s.cvc(s.j-1)) {
+			s.b = s.b[:s.j]
+		}
+	}
+	s.j = len(s.b)
+	if s.measure() > 1 && s.b[len(s.b)-1] == 'l' && s.doubleCons(len(s.b)-1) {
+		s.b = s.b[:len(s.b)-1]
+	}
+}
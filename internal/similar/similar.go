@@ -0,0 +1,222 @@
+package similar
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Scales of the proximity of years and runtimes: movies that many years, or
+// minutes, apart are about a third as close as movies of the same year.
+const (
+	yearScale    = 10
+	runtimeScale = 30
+)
+
+// Features describe a movie for comparing it with others.
+type Features struct {
+	Genres  []string
+	People  []int64 //cast and crew, when known
+	Year    int32
+	Runtime int32
+}
+
+// Weights tell how much each feature counts in the similarity.
+type Weights struct {
+	Genres  float64
+	People  float64
+	Year    float64
+	Runtime float64
+}
+
+// Hit is a movie similar to another one, with its similarity between 0 and 1.
+type Hit struct {
+	ID    int64
+	Score float64
+}
+
+// vector is the precomputed form of Features: sets for the Jaccard indexes.
+type vector struct {
+	genres  map[string]bool
+	people  map[int64]bool
+	year    float64
+	runtime float64
+}
+
+// Index keeps the feature vectors of the movies and finds the ones most like
+// a given movie. Movies are only compared with the ones sharing a genre or a
+// person with them, found through inverted indexes.
+// It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	weights  Weights
+	vectors  map[int64]*vector
+	byGenre  map[string]map[int64]bool
+	byPerson map[int64]map[int64]bool
+}
+
+func NewIndex(w Weights) *Index {
+	return &Index{
+		weights:  w,
+		vectors:  make(map[int64]*vector),
+		byGenre:  make(map[string]map[int64]bool),
+		byPerson: make(map[int64]map[int64]bool),
+	}
+}
+
+// Put indexes the features of the movie, replacing the previous ones.
+func (idx *Index) Put(id int64, f Features) {
+	v := &vector{
+		genres:  make(map[string]bool, len(f.Genres)),
+		year:    float64(f.Year),
+		runtime: float64(f.Runtime),
+	}
+	for _, g := range f.Genres {
+		v.genres[g] = true
+	}
+	v.people = peopleSet(f.People)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.put(id, v)
+}
+
+func (idx *Index) put(id int64, v *vector) {
+	idx.remove(id)
+	idx.vectors[id] = v
+	for g := range v.genres {
+		if idx.byGenre[g] == nil {
+			idx.byGenre[g] = make(map[int64]bool)
+		}
+		idx.byGenre[g][id] = true
+	}
+	for p := range v.people {
+		if idx.byPerson[p] == nil {
+			idx.byPerson[p] = make(map[int64]bool)
+		}
+		idx.byPerson[p][id] = true
+	}
+}
+
+func peopleSet(people []int64) map[int64]bool {
+	set := make(map[int64]bool, len(people))
+	for _, p := range people {
+		set[p] = true
+	}
+	return set
+}
+
+func (idx *Index) Remove(id int64) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.remove(id)
+}
+
+func (idx *Index) remove(id int64) {
+	v, ok := idx.vectors[id]
+	if !ok {
+		return
+	}
+	for g := range v.genres {
+		delete(idx.byGenre[g], id)
+		if len(idx.byGenre[g]) == 0 {
+			delete(idx.byGenre, g)
+		}
+	}
+	for p := range v.people {
+		delete(idx.byPerson[p], id)
+		if len(idx.byPerson[p]) == 0 {
+			delete(idx.byPerson, p)
+		}
+	}
+	delete(idx.vectors, id)
+}
+
+// Has tells if the movie is indexed.
+func (idx *Index) Has(id int64) bool {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	_, ok := idx.vectors[id]
+	return ok
+}
+
+// Similar returns up to limit movies like the given one, the most similar first.
+func (idx *Index) Similar(id int64, limit int) []Hit {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	v, ok := idx.vectors[id]
+	if !ok {
+		return nil
+	}
+	candidates := make(map[int64]bool)
+	for g := range v.genres {
+		for c := range idx.byGenre[g] {
+			candidates[c] = true
+		}
+	}
+	for p := range v.people {
+		for c := range idx.byPerson[p] {
+			candidates[c] = true
+		}
+	}
+	delete(candidates, id)
+
+	hits := make([]Hit, 0, len(candidates))
+	for c := range candidates {
+		hits = append(hits, Hit{ID: c, Score: idx.score(v, idx.vectors[c])})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// score is the weighted mean of the similarity of each feature. People only
+// count when both movies have credits, unknown casts say nothing either way.
+func (idx *Index) score(a, b *vector) float64 {
+	w := idx.weights
+	genres := 0
+	for g := range a.genres {
+		if b.genres[g] {
+			genres++
+		}
+	}
+	sum := w.Genres*jaccard(genres, len(a.genres), len(b.genres)) +
+		w.Year*proximity(a.year, b.year, yearScale) +
+		w.Runtime*proximity(a.runtime, b.runtime, runtimeScale)
+	total := w.Genres + w.Year + w.Runtime
+	if len(a.people) > 0 && len(b.people) > 0 {
+		people := 0
+		for p := range a.people {
+			if b.people[p] {
+				people++
+			}
+		}
+		sum += w.People * jaccard(people, len(a.people), len(b.people))
+		total += w.People
+	}
+	if total == 0 {
+		return 0
+	}
+	return sum / total
+}
+
+// jaccard is the size of the intersection of two sets over the size of their union.
+func jaccard(shared, a, b int) float64 {
+	if a+b == 0 {
+		return 0
+	}
+	return float64(shared) / float64(a+b-shared)
+}
+
+// proximity is 1 for equal values, decaying exponentially with their distance.
+func proximity(a, b, scale float64) float64 {
+	return math.Exp(-math.Abs(a-b) / scale)
+}
@@ -0,0 +1,46 @@
+package similar
+
+import (
+	"testing"
+
+	is2 "github.com/matryer/is"
+)
+
+func TestIndex_Similar(t *testing.T) {
+	is := is2.New(t)
+
+	idx := NewIndex(Weights{Genres: 3, People: 2, Year: 1, Runtime: 0.5})
+	idx.Put(1, Features{Genres: []string{"Crime", "Drama"}, People: []int64{10, 11}, Year: 1972, Runtime: 175})
+	idx.Put(2, Features{Genres: []string{"Crime", "Drama"}, People: []int64{10, 11}, Year: 1974, Runtime: 202})
+	idx.Put(3, Features{Genres: []string{"Crime", "Drama"}, People: []int64{12}, Year: 1974, Runtime: 202})
+	idx.Put(4, Features{Genres: []string{"Crime"}, Year: 1990, Runtime: 146})
+	idx.Put(5, Features{Genres: []string{"Animation"}, Year: 1972, Runtime: 175})
+
+	hits := idx.Similar(1, 10)
+	//nothing in common but the year and runtime isn't enough
+	is.Equal(len(hits), 3)
+	is.Equal(hits[0].ID, int64(2))
+	is.Equal(hits[1].ID, int64(3))
+	is.Equal(hits[2].ID, int64(4))
+	is.True(hits[0].Score > hits[1].Score)
+	is.True(hits[0].Score <= 1)
+
+	is.Equal(len(idx.Similar(1, 1)), 1)
+	is.Equal(idx.Similar(42, 10), nil)
+}
+
+func TestIndex_PutReplaces(t *testing.T) {
+	is := is2.New(t)
+
+	idx := NewIndex(Weights{Genres: 1})
+	idx.Put(1, Features{Genres: []string{"Western"}})
+	idx.Put(2, Features{Genres: []string{"Western"}})
+	is.Equal(idx.Similar(1, 10), []Hit{{ID: 2, Score: 1}})
+
+	idx.Put(2, Features{Genres: []string{"Horror"}})
+	is.Equal(len(idx.Similar(1, 10)), 0)
+
+	idx.Remove(1)
+	is.True(!idx.Has(1))
+	is.True(idx.Has(2))
+}
@@ -0,0 +1 @@
+ALTER TABLE Movie DROP INDEX IF EXISTS movies_title_fulltext;
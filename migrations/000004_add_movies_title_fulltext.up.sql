@@ -0,0 +1 @@
+ALTER TABLE Movie ADD FULLTEXT INDEX movies_title_fulltext (title);
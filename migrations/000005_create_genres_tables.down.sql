@@ -0,0 +1,12 @@
+ALTER TABLE Movie ADD COLUMN genres TINYTEXT NOT NULL DEFAULT '';
+
+UPDATE Movie SET genres = COALESCE((
+    SELECT GROUP_CONCAT(g.name ORDER BY mg.position SEPARATOR ',')
+    FROM movie_genres mg JOIN genres g ON g.id = mg.genre_id
+    WHERE mg.movie_id = Movie.Id), '');
+
+ALTER TABLE Movie ADD FULLTEXT(genres);
+
+DROP TABLE IF EXISTS movie_genres;
+DROP TABLE IF EXISTS genre_aliases;
+DROP TABLE IF EXISTS genres;
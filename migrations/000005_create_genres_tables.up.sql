@@ -0,0 +1,75 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    slug varchar(50) UNIQUE NOT NULL,
+    name varchar(50) UNIQUE NOT NULL,
+    version int NOT NULL DEFAULT 1
+    );
+
+CREATE TABLE IF NOT EXISTS genre_aliases (
+    alias varchar(50) PRIMARY KEY,
+    genre_id bigint(20) NOT NULL,
+    FOREIGN KEY (genre_id) REFERENCES genres (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+    movie_id bigint(20) NOT NULL,
+    genre_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES genres (id) ON DELETE RESTRICT
+    );
+
+INSERT IGNORE INTO genres (slug, name) VALUES
+    ('action', 'Action'), ('adventure', 'Adventure'), ('animation', 'Animation'), ('comedy', 'Comedy'),
+    ('crime', 'Crime'), ('documentary', 'Documentary'), ('drama', 'Drama'), ('family', 'Family'),
+    ('fantasy', 'Fantasy'), ('history', 'History'), ('horror', 'Horror'), ('music', 'Music'),
+    ('mystery', 'Mystery'), ('romance', 'Romance'), ('science-fiction', 'Science Fiction'),
+    ('thriller', 'Thriller'), ('war', 'War'), ('western', 'Western');
+
+INSERT IGNORE INTO genre_aliases (alias, genre_id)
+SELECT a.alias, g.id FROM genres g JOIN (
+    SELECT 'animated' AS alias, 'animation' AS slug UNION ALL
+    SELECT 'cartoon', 'animation' UNION ALL
+    SELECT 'doc', 'documentary' UNION ALL
+    SELECT 'historical', 'history' UNION ALL
+    SELECT 'musical', 'music' UNION ALL
+    SELECT 'romantic', 'romance' UNION ALL
+    SELECT 'sci-fi', 'science-fiction' UNION ALL
+    SELECT 'scifi', 'science-fiction' UNION ALL
+    SELECT 'sf', 'science-fiction'
+) a ON a.slug = g.slug;
+
+-- split the comma separated genres of every movie, there are 5 at most
+CREATE TEMPORARY TABLE movie_genre_names AS
+SELECT m.Id AS movie_id, n.n AS position,
+       TRIM(SUBSTRING_INDEX(SUBSTRING_INDEX(m.genres, ',', n.n), ',', -1)) AS name,
+       CAST(NULL AS CHAR(50)) AS slug
+FROM Movie m
+JOIN (SELECT 1 AS n UNION ALL SELECT 2 UNION ALL SELECT 3 UNION ALL SELECT 4 UNION ALL SELECT 5) n
+  ON n.n <= 1 + LENGTH(m.genres) - LENGTH(REPLACE(m.genres, ',', ''));
+
+-- slugs as models.Slugify makes them: lower case ASCII letters and digits, other runs turned into one hyphen
+UPDATE movie_genre_names SET slug = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name), '[^a-z0-9]+', '-'));
+UPDATE movie_genre_names SET slug = CONCAT('genre-', MD5(name)) WHERE slug = '';
+
+-- keep the genres nobody thought of, they can be merged through the API later;
+-- names spelled alike ("Sci Fi", "sci-fi") end up as one genre
+INSERT IGNORE INTO genres (slug, name)
+SELECT s.slug, MIN(s.name)
+FROM movie_genre_names s
+WHERE s.name <> ''
+  AND NOT EXISTS (SELECT 1 FROM genres g WHERE g.name = s.name OR g.slug = s.slug)
+  AND NOT EXISTS (SELECT 1 FROM genre_aliases a WHERE a.alias = s.name)
+GROUP BY s.slug;
+
+INSERT IGNORE INTO movie_genres (movie_id, genre_id, position)
+SELECT s.movie_id, g.id, s.position
+FROM movie_genre_names s
+JOIN genres g ON g.name = s.name OR g.slug = s.slug
+    OR g.id IN (SELECT genre_id FROM genre_aliases WHERE alias = s.name);
+
+DROP TEMPORARY TABLE movie_genre_names;
+
+ALTER TABLE Movie DROP CONSTRAINT IF EXISTS genres_length_check;
+ALTER TABLE Movie DROP COLUMN genres;
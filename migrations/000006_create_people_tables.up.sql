@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS people (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name varchar(200) NOT NULL,
+    birth_year int NULL,
+    version int NOT NULL DEFAULT 1,
+    INDEX people_name_idx (name)
+    );
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    movie_id bigint(20) NOT NULL,
+    person_id bigint(20) NOT NULL,
+    role varchar(20) NOT NULL,
+    character_name varchar(200) NOT NULL DEFAULT '',
+    billing int NOT NULL,
+    UNIQUE KEY movie_credits_unique (movie_id, person_id, role, character_name),
+    INDEX movie_credits_person_idx (person_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (person_id) REFERENCES people (id) ON DELETE CASCADE
+    );
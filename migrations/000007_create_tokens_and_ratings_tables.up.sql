@@ -0,0 +1,32 @@
+-- bcrypt hashes are 60 bytes long, and users need an id of their own to log in
+ALTER TABLE users
+    MODIFY id bigint(20) NOT NULL AUTO_INCREMENT,
+    MODIFY password_hash BINARY(60) NOT NULL;
+
+CREATE TABLE IF NOT EXISTS tokens (
+    hash BINARY(32) PRIMARY KEY,
+    user_id bigint(20) NOT NULL,
+    expiry timestamp(0) NOT NULL,
+    scope varchar(20) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE IF NOT EXISTS ratings (
+    user_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    score tinyint NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, movie_id),
+    INDEX ratings_movie_idx (movie_id),
+    CONSTRAINT ratings_score_check CHECK (score BETWEEN 1 AND 10),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- aggregates kept up to date with every vote; rating is the weighted score used for sorting
+ALTER TABLE Movie
+    ADD COLUMN rating_sum int NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count int NOT NULL DEFAULT 0,
+    ADD COLUMN rating double NOT NULL DEFAULT 0,
+    ADD INDEX movies_rating_idx (rating);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS review_votes;
+DROP TABLE IF EXISTS reviews;
+DROP TABLE IF EXISTS users_permissions;
+DROP TABLE IF EXISTS permissions;
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    code varchar(50) NOT NULL UNIQUE
+    );
+
+CREATE TABLE IF NOT EXISTS users_permissions (
+    user_id bigint(20) NOT NULL,
+    permission_id bigint(20) NOT NULL,
+    PRIMARY KEY (user_id, permission_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+    );
+
+INSERT IGNORE INTO permissions (code) VALUES ('reviews:moderate');
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    movie_id bigint(20) NOT NULL,
+    user_id bigint(20) NOT NULL,
+    body text NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    flags varchar(100) NOT NULL DEFAULT '', -- reasons of the pre-screen, comma separated
+    helpful int NOT NULL DEFAULT 0,
+    moderated_by bigint(20) NULL,
+    version int NOT NULL DEFAULT 1,
+    UNIQUE KEY reviews_movie_user_unique (movie_id, user_id),
+    INDEX reviews_movie_status_idx (movie_id, status, helpful),
+    CONSTRAINT reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected')),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (moderated_by) REFERENCES users (id) ON DELETE SET NULL
+    );
+
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id bigint(20) NOT NULL,
+    user_id bigint(20) NOT NULL,
+    PRIMARY KEY (review_id, user_id),
+    FOREIGN KEY (review_id) REFERENCES reviews (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
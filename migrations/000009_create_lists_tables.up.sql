@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS lists (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    user_id bigint(20) NOT NULL,
+    name varchar(100) NOT NULL,
+    description text NOT NULL,
+    visibility varchar(20) NOT NULL DEFAULT 'private',
+    slug char(26) NOT NULL, -- random, to share unlisted lists
+    version int NOT NULL DEFAULT 1,
+    UNIQUE KEY lists_slug_unique (slug),
+    INDEX lists_user_idx (user_id),
+    INDEX lists_visibility_idx (visibility),
+    CONSTRAINT lists_visibility_check CHECK (visibility IN ('private', 'unlisted', 'public')),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+-- entries go away with their movie, so lists never point to deleted movies
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    note varchar(500) NOT NULL DEFAULT '',
+    added_at timestamp(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, movie_id),
+    INDEX list_items_position_idx (list_id, position),
+    FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
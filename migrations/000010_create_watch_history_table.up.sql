@@ -0,0 +1,14 @@
+-- runtime is copied from the movie when logged, so totals don't need a join
+CREATE TABLE IF NOT EXISTS watch_history (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    user_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    watched_on date NOT NULL,
+    rewatch boolean NOT NULL DEFAULT false,
+    runtime int NOT NULL,
+    INDEX watch_history_user_date_idx (user_id, watched_on),
+    INDEX watch_history_user_movie_idx (user_id, movie_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
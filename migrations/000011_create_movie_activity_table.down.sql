@@ -0,0 +1,5 @@
+ALTER TABLE Movie
+    DROP INDEX movies_popularity_idx,
+    DROP COLUMN popularity;
+
+DROP TABLE IF EXISTS movie_activity;
@@ -0,0 +1,17 @@
+-- events counted per movie and hour, flushed in batches by the API. There is no
+-- foreign key: a flush must not fail because a movie was deleted meanwhile, the
+-- rows of deleted movies never join Movie and are pruned with the old ones.
+CREATE TABLE IF NOT EXISTS movie_activity (
+    movie_id bigint(20) NOT NULL,
+    hour datetime NOT NULL,
+    views int NOT NULL DEFAULT 0,
+    list_adds int NOT NULL DEFAULT 0,
+    ratings int NOT NULL DEFAULT 0,
+    PRIMARY KEY (movie_id, hour),
+    INDEX movie_activity_hour_idx (hour)
+    );
+
+-- time-decayed activity, recomputed in the background; what sort=popularity goes by
+ALTER TABLE Movie
+    ADD COLUMN popularity double NOT NULL DEFAULT 0,
+    ADD INDEX movies_popularity_idx (popularity);
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS collections (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name varchar(200) NOT NULL,
+    description varchar(2000) NOT NULL DEFAULT '',
+    version int NOT NULL DEFAULT 1,
+    UNIQUE INDEX collections_name_idx (name)
+    );
+
+-- a movie belongs to one collection at most
+CREATE TABLE IF NOT EXISTS collection_movies (
+    collection_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (collection_id, movie_id),
+    UNIQUE INDEX collection_movies_movie_idx (movie_id),
+    FOREIGN KEY (collection_id) REFERENCES collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- read "<movie_id> <kind> <related_id>", e.g. The Godfather Part II sequel_of The Godfather
+CREATE TABLE IF NOT EXISTS movie_relations (
+    movie_id bigint(20) NOT NULL,
+    related_id bigint(20) NOT NULL,
+    kind varchar(20) NOT NULL,
+    PRIMARY KEY (movie_id, related_id),
+    INDEX movie_relations_related_idx (related_id),
+    CONSTRAINT movie_relations_kind_check CHECK (kind IN ('sequel_of', 'remake_of', 'spin_off_of')),
+    CONSTRAINT movie_relations_self_check CHECK (movie_id <> related_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (related_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
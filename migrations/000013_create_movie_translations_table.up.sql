@@ -0,0 +1,9 @@
+-- the title and overview of a movie in other languages, keyed by BCP 47 tag, e.g. pt-BR
+CREATE TABLE IF NOT EXISTS movie_translations (
+    movie_id bigint(20) NOT NULL,
+    language varchar(35) NOT NULL,
+    title varchar(500) NOT NULL,
+    overview varchar(5000) NOT NULL DEFAULT '',
+    PRIMARY KEY (movie_id, language),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
@@ -8,7 +8,8 @@ CREATE TABLE IF NOT EXISTS Movie (
     `version` int NOT NULL DEFAULT 1,
     PRIMARY KEY (`Id`),
     UNIQUE KEY `ID_UNIQUE` (`Id`),
-    FULLTEXT(`genres`)
+    FULLTEXT(`genres`),
+    FULLTEXT INDEX movies_title_fulltext (`title`)
     );
 
 ALTER TABLE Movie ADD CONSTRAINT movies_runtime_check CHECK (runtime >= 0);
@@ -25,6 +26,83 @@ INSERT INTO Movie (title, year, runtime, genres) VALUES ("From Dusk Till Dawn",
 INSERT INTO Movie (title, year, runtime, genres) VALUES ("The Knight Before Christmas", 1997, 115, "Comedy,Romance");
 INSERT INTO Movie (title, year, runtime, genres) VALUES ("The Ghost and the Darkness", 1996, 165, "Adventure");
 
+-- genres move to their own tables, as in migration 000005
+CREATE TABLE IF NOT EXISTS genres (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    slug varchar(50) UNIQUE NOT NULL,
+    name varchar(50) UNIQUE NOT NULL,
+    version int NOT NULL DEFAULT 1
+    );
+
+CREATE TABLE IF NOT EXISTS genre_aliases (
+    alias varchar(50) PRIMARY KEY,
+    genre_id bigint(20) NOT NULL,
+    FOREIGN KEY (genre_id) REFERENCES genres (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE IF NOT EXISTS movie_genres (
+    movie_id bigint(20) NOT NULL,
+    genre_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES genres (id) ON DELETE RESTRICT
+    );
+
+INSERT IGNORE INTO genres (slug, name) VALUES
+    ('action', 'Action'), ('adventure', 'Adventure'), ('animation', 'Animation'), ('comedy', 'Comedy'),
+    ('crime', 'Crime'), ('documentary', 'Documentary'), ('drama', 'Drama'), ('family', 'Family'),
+    ('fantasy', 'Fantasy'), ('history', 'History'), ('horror', 'Horror'), ('music', 'Music'),
+    ('mystery', 'Mystery'), ('romance', 'Romance'), ('science-fiction', 'Science Fiction'),
+    ('thriller', 'Thriller'), ('war', 'War'), ('western', 'Western');
+
+INSERT IGNORE INTO genre_aliases (alias, genre_id)
+SELECT a.alias, g.id FROM genres g JOIN (
+    SELECT 'animated' AS alias, 'animation' AS slug UNION ALL
+    SELECT 'cartoon', 'animation' UNION ALL
+    SELECT 'doc', 'documentary' UNION ALL
+    SELECT 'historical', 'history' UNION ALL
+    SELECT 'musical', 'music' UNION ALL
+    SELECT 'romantic', 'romance' UNION ALL
+    SELECT 'sci-fi', 'science-fiction' UNION ALL
+    SELECT 'scifi', 'science-fiction' UNION ALL
+    SELECT 'sf', 'science-fiction'
+) a ON a.slug = g.slug;
+
+-- split the comma separated genres of every movie, there are 5 at most
+CREATE TEMPORARY TABLE movie_genre_names AS
+SELECT m.Id AS movie_id, n.n AS position,
+       TRIM(SUBSTRING_INDEX(SUBSTRING_INDEX(m.genres, ',', n.n), ',', -1)) AS name,
+       CAST(NULL AS CHAR(50)) AS slug
+FROM Movie m
+JOIN (SELECT 1 AS n UNION ALL SELECT 2 UNION ALL SELECT 3 UNION ALL SELECT 4 UNION ALL SELECT 5) n
+  ON n.n <= 1 + LENGTH(m.genres) - LENGTH(REPLACE(m.genres, ',', ''));
+
+-- slugs as models.Slugify makes them: lower case ASCII letters and digits, other runs turned into one hyphen
+UPDATE movie_genre_names SET slug = TRIM(BOTH '-' FROM REGEXP_REPLACE(LOWER(name), '[^a-z0-9]+', '-'));
+UPDATE movie_genre_names SET slug = CONCAT('genre-', MD5(name)) WHERE slug = '';
+
+-- keep the genres nobody thought of, they can be merged through the API later;
+-- names spelled alike ("Sci Fi", "sci-fi") end up as one genre
+INSERT IGNORE INTO genres (slug, name)
+SELECT s.slug, MIN(s.name)
+FROM movie_genre_names s
+WHERE s.name <> ''
+  AND NOT EXISTS (SELECT 1 FROM genres g WHERE g.name = s.name OR g.slug = s.slug)
+  AND NOT EXISTS (SELECT 1 FROM genre_aliases a WHERE a.alias = s.name)
+GROUP BY s.slug;
+
+INSERT IGNORE INTO movie_genres (movie_id, genre_id, position)
+SELECT s.movie_id, g.id, s.position
+FROM movie_genre_names s
+JOIN genres g ON g.name = s.name OR g.slug = s.slug
+    OR g.id IN (SELECT genre_id FROM genre_aliases WHERE alias = s.name);
+
+DROP TEMPORARY TABLE movie_genre_names;
+
+ALTER TABLE Movie DROP CONSTRAINT IF EXISTS genres_length_check;
+ALTER TABLE Movie DROP COLUMN genres;
+
 
 
 CREATE TABLE IF NOT EXISTS users (
@@ -32,7 +110,209 @@ CREATE TABLE IF NOT EXISTS users (
   created_at timestamp(0) NOT NULL DEFAULT NOW(),
   name varchar(200) NOT NULL,
   email varchar(50) UNIQUE NOT NULL,
-  password_hash BINARY(28) NOT NULL,
+  password_hash BINARY(60) NOT NULL,
   activated bool NOT NULL,
   version int NOT NULL DEFAULT 1
   );
+
+-- people and their credits, as in migration 000006
+CREATE TABLE IF NOT EXISTS people (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name varchar(200) NOT NULL,
+    birth_year int NULL,
+    version int NOT NULL DEFAULT 1,
+    INDEX people_name_idx (name)
+    );
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    movie_id bigint(20) NOT NULL,
+    person_id bigint(20) NOT NULL,
+    role varchar(20) NOT NULL,
+    character_name varchar(200) NOT NULL DEFAULT '',
+    billing int NOT NULL,
+    UNIQUE KEY movie_credits_unique (movie_id, person_id, role, character_name),
+    INDEX movie_credits_person_idx (person_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (person_id) REFERENCES people (id) ON DELETE CASCADE
+    );
+
+-- tokens, ratings and their aggregates, as in migration 000007
+CREATE TABLE IF NOT EXISTS tokens (
+    hash BINARY(32) PRIMARY KEY,
+    user_id bigint(20) NOT NULL,
+    expiry timestamp(0) NOT NULL,
+    scope varchar(20) NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+CREATE TABLE IF NOT EXISTS ratings (
+    user_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    score tinyint NOT NULL,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    updated_at timestamp(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, movie_id),
+    INDEX ratings_movie_idx (movie_id),
+    CONSTRAINT ratings_score_check CHECK (score BETWEEN 1 AND 10),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- aggregates kept up to date with every vote; rating is the weighted score used for sorting
+ALTER TABLE Movie
+    ADD COLUMN rating_sum int NOT NULL DEFAULT 0,
+    ADD COLUMN rating_count int NOT NULL DEFAULT 0,
+    ADD COLUMN rating double NOT NULL DEFAULT 0,
+    ADD INDEX movies_rating_idx (rating);
+
+-- permissions and reviews, as in migration 000008
+CREATE TABLE IF NOT EXISTS permissions (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    code varchar(50) NOT NULL UNIQUE
+    );
+
+CREATE TABLE IF NOT EXISTS users_permissions (
+    user_id bigint(20) NOT NULL,
+    permission_id bigint(20) NOT NULL,
+    PRIMARY KEY (user_id, permission_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (permission_id) REFERENCES permissions (id) ON DELETE CASCADE
+    );
+
+INSERT IGNORE INTO permissions (code) VALUES ('reviews:moderate');
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    movie_id bigint(20) NOT NULL,
+    user_id bigint(20) NOT NULL,
+    body text NOT NULL,
+    status varchar(20) NOT NULL DEFAULT 'pending',
+    flags varchar(100) NOT NULL DEFAULT '', -- reasons of the pre-screen, comma separated
+    helpful int NOT NULL DEFAULT 0,
+    moderated_by bigint(20) NULL,
+    version int NOT NULL DEFAULT 1,
+    UNIQUE KEY reviews_movie_user_unique (movie_id, user_id),
+    INDEX reviews_movie_status_idx (movie_id, status, helpful),
+    CONSTRAINT reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected')),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (moderated_by) REFERENCES users (id) ON DELETE SET NULL
+    );
+
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id bigint(20) NOT NULL,
+    user_id bigint(20) NOT NULL,
+    PRIMARY KEY (review_id, user_id),
+    FOREIGN KEY (review_id) REFERENCES reviews (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+-- lists of movies, as in migration 000009
+CREATE TABLE IF NOT EXISTS lists (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    user_id bigint(20) NOT NULL,
+    name varchar(100) NOT NULL,
+    description text NOT NULL,
+    visibility varchar(20) NOT NULL DEFAULT 'private',
+    slug char(26) NOT NULL, -- random, to share unlisted lists
+    version int NOT NULL DEFAULT 1,
+    UNIQUE KEY lists_slug_unique (slug),
+    INDEX lists_user_idx (user_id),
+    INDEX lists_visibility_idx (visibility),
+    CONSTRAINT lists_visibility_check CHECK (visibility IN ('private', 'unlisted', 'public')),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+    );
+
+-- entries go away with their movie, so lists never point to deleted movies
+CREATE TABLE IF NOT EXISTS list_items (
+    list_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    note varchar(500) NOT NULL DEFAULT '',
+    added_at timestamp(0) NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (list_id, movie_id),
+    INDEX list_items_position_idx (list_id, position),
+    FOREIGN KEY (list_id) REFERENCES lists (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- watch history, as in migration 000010
+-- runtime is copied from the movie when logged, so totals don't need a join
+CREATE TABLE IF NOT EXISTS watch_history (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    user_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    watched_on date NOT NULL,
+    rewatch boolean NOT NULL DEFAULT false,
+    runtime int NOT NULL,
+    INDEX watch_history_user_date_idx (user_id, watched_on),
+    INDEX watch_history_user_movie_idx (user_id, movie_id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- movie activity and popularity, as in migration 000011
+-- events counted per movie and hour, flushed in batches by the API
+CREATE TABLE IF NOT EXISTS movie_activity (
+    movie_id bigint(20) NOT NULL,
+    hour datetime NOT NULL,
+    views int NOT NULL DEFAULT 0,
+    list_adds int NOT NULL DEFAULT 0,
+    ratings int NOT NULL DEFAULT 0,
+    PRIMARY KEY (movie_id, hour),
+    INDEX movie_activity_hour_idx (hour)
+    );
+
+-- time-decayed activity, recomputed in the background; what sort=popularity goes by
+ALTER TABLE Movie
+    ADD COLUMN popularity double NOT NULL DEFAULT 0,
+    ADD INDEX movies_popularity_idx (popularity);
+
+-- collections and movie relations, as in migration 000012
+CREATE TABLE IF NOT EXISTS collections (
+    id bigint(20) PRIMARY KEY AUTO_INCREMENT,
+    created_at timestamp(0) NOT NULL DEFAULT NOW(),
+    name varchar(200) NOT NULL,
+    description varchar(2000) NOT NULL DEFAULT '',
+    version int NOT NULL DEFAULT 1,
+    UNIQUE INDEX collections_name_idx (name)
+    );
+
+-- a movie belongs to one collection at most
+CREATE TABLE IF NOT EXISTS collection_movies (
+    collection_id bigint(20) NOT NULL,
+    movie_id bigint(20) NOT NULL,
+    position int NOT NULL,
+    PRIMARY KEY (collection_id, movie_id),
+    UNIQUE INDEX collection_movies_movie_idx (movie_id),
+    FOREIGN KEY (collection_id) REFERENCES collections (id) ON DELETE CASCADE,
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- read "<movie_id> <kind> <related_id>", e.g. The Godfather Part II sequel_of The Godfather
+CREATE TABLE IF NOT EXISTS movie_relations (
+    movie_id bigint(20) NOT NULL,
+    related_id bigint(20) NOT NULL,
+    kind varchar(20) NOT NULL,
+    PRIMARY KEY (movie_id, related_id),
+    INDEX movie_relations_related_idx (related_id),
+    CONSTRAINT movie_relations_kind_check CHECK (kind IN ('sequel_of', 'remake_of', 'spin_off_of')),
+    CONSTRAINT movie_relations_self_check CHECK (movie_id <> related_id),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE,
+    FOREIGN KEY (related_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );
+
+-- localised titles and overviews, as in migration 000013
+CREATE TABLE IF NOT EXISTS movie_translations (
+    movie_id bigint(20) NOT NULL,
+    language varchar(35) NOT NULL,
+    title varchar(500) NOT NULL,
+    overview varchar(5000) NOT NULL DEFAULT '',
+    PRIMARY KEY (movie_id, language),
+    FOREIGN KEY (movie_id) REFERENCES Movie (Id) ON DELETE CASCADE
+    );